@@ -0,0 +1,101 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"library/internal/catalog"
+	"library/internal/config"
+	"library/internal/logger"
+	"library/internal/server"
+	"library/internal/service"
+	"library/internal/storage"
+	"os"
+)
+
+// runImport is the "import" subcommand: library [flags] import [-format csv|marc|marcxml] file...
+// It loads the files straight into the database and prints the report of every file to stdout.
+func runImport(cfg config.ConfigStruct, args []string) error {
+
+	log := logger.Get()
+
+	flags := flag.NewFlagSet("import", flag.ExitOnError)
+	format := flags.String("format", "", "csv, marc or marcxml, by default taken from the file extension")
+
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+
+	if flags.NArg() == 0 {
+		return errors.New("usage: library import [-format csv|marc|marcxml] file...")
+	}
+
+	if err := storage.Migrations(cfg.DbDSN, cfg.MigratePath); err != nil {
+		return err
+	}
+
+	DBStorage, err := storage.NewDBStorage(context.Background(), cfg.DbDSN, dbPool(cfg), dbTimeouts(cfg))
+
+	if err != nil {
+		return err
+	}
+
+	defer DBStorage.Close()
+
+	bookService := service.NewBookService(DBStorage,
+		service.NewAuthorService(DBStorage),
+		cfg.ImportBatch,
+		cfg.TrashRetention,
+		service.NewAuditService(DBStorage))
+	valid := server.NewValidator()
+
+	encoder := json.NewEncoder(os.Stdout)
+	encoder.SetIndent("", "  ")
+
+	for _, path := range flags.Args() {
+
+		fileFormat := *format
+
+		if fileFormat == "" {
+			fileFormat = catalog.DetectFormat(path)
+		}
+
+		file, err := os.Open(path)
+
+		if err != nil {
+			return err
+		}
+
+		records, err := catalog.Read(file, fileFormat)
+
+		file.Close()
+
+		if err != nil {
+			return err
+		}
+
+		// The command line has no authenticated user, so the audit entries have no actor.
+		report, err := bookService.ImportBooks(context.Background(), "", catalog.Rows(records, valid))
+
+		// The rows committed before the error stay saved, so their report is printed anyway.
+		if err != nil {
+			_ = encoder.Encode(map[string]any{"file": path, "report": report, "error": err.Error()})
+			return err
+		}
+
+		log.Info().Str("file", path).
+			Int("created", report.Created).
+			Int("duplicate", report.Duplicate).
+			Int("invalid", report.Invalid).
+			Msg("import finished")
+
+		if err = encoder.Encode(map[string]any{"file": path, "report": report}); err != nil {
+			return err
+		}
+
+	}
+
+	return nil
+
+}
@@ -3,12 +3,14 @@ package main
 import (
 	"context"
 	"errors"
+	"flag"
 	_ "github.com/golang-migrate/migrate/v4/database/postgres"
 	_ "github.com/golang-migrate/migrate/v4/source/file"
 	"golang.org/x/sync/errgroup"
 	"library/internal/config"
 	"library/internal/logger"
 	"library/internal/server"
+	"library/internal/server/utils"
 	"library/internal/service"
 	"library/internal/storage"
 	"net/http"
@@ -28,6 +30,16 @@ func main() {
 	//log.Error().Msg("error")
 	//log.Fatal().Msg("fatal")
 
+	if flag.Arg(0) == "import" {
+
+		if err := runImport(cfg, flag.Args()[1:]); err != nil {
+			log.Fatal().Err(err).Msg("import failed")
+		}
+
+		return
+
+	}
+
 	ctx, cancel := context.WithCancel(context.Background())
 	go func() {
 		c := make(chan os.Signal, 1)
@@ -55,25 +67,65 @@ func main() {
 	var MapStorage *storage.MapStorage
 	var userService service.UserServiceStruct
 	var bookService service.BookServiceStruct
-
-	DBStorage, err = storage.NewDBStorage(context.Background(), cfg.DbDSN)
+	var loanService service.LoanServiceStruct
+	var copyService service.CopyServiceStruct
+	var holdService service.HoldServiceStruct
+	var fineService service.FineServiceStruct
+	var sessionService service.SessionServiceStruct
+	var authorService service.AuthorServiceStruct
+	var genreService service.GenreServiceStruct
+	var tagService service.TagServiceStruct
+	var auditService service.AuditServiceStruct
+	var statsService service.StatsServiceStruct
+
+	DBStorage, err = storage.NewDBStorage(context.Background(), cfg.DbDSN, dbPool(cfg), dbTimeouts(cfg))
 
 	if err != nil {
 
 		log.Error().Err(err).Send()
 
 		MapStorage = storage.NewMapStorage()
-		userService = service.NewUserService(MapStorage)
-		bookService = service.NewBookService(MapStorage)
+		auditService = service.NewAuditService(MapStorage)
+		userService = service.NewUserService(MapStorage, cfg.AdminEmail, auditService)
+		authorService = service.NewAuthorService(MapStorage)
+		genreService = service.NewGenreService(MapStorage)
+		tagService = service.NewTagService(MapStorage)
+		bookService = service.NewBookService(MapStorage, authorService, cfg.ImportBatch, cfg.TrashRetention, auditService)
+		holdService = service.NewHoldService(MapStorage, cfg.HoldExpiry)
+		fineService = service.NewFineService(MapStorage, cfg.FineRate, cfg.FineGrace)
+		sessionService = service.NewSessionService(MapStorage, cfg.RefreshTTL)
+		loanService = service.NewLoanService(MapStorage, holdService, fineService, cfg.LoanPeriod, cfg.MaxRenewals)
+		copyService = service.NewCopyService(MapStorage, holdService)
+		statsService = service.NewStatsService(MapStorage)
 
 	} else {
 
-		userService = service.NewUserService(DBStorage)
-		bookService = service.NewBookService(DBStorage)
+		auditService = service.NewAuditService(DBStorage)
+		userService = service.NewUserService(DBStorage, cfg.AdminEmail, auditService)
+		authorService = service.NewAuthorService(DBStorage)
+		genreService = service.NewGenreService(DBStorage)
+		tagService = service.NewTagService(DBStorage)
+		bookService = service.NewBookService(DBStorage, authorService, cfg.ImportBatch, cfg.TrashRetention, auditService)
+		holdService = service.NewHoldService(DBStorage, cfg.HoldExpiry)
+		fineService = service.NewFineService(DBStorage, cfg.FineRate, cfg.FineGrace)
+		sessionService = service.NewSessionService(DBStorage, cfg.RefreshTTL)
+		loanService = service.NewLoanService(DBStorage, holdService, fineService, cfg.LoanPeriod, cfg.MaxRenewals)
+		copyService = service.NewCopyService(DBStorage, holdService)
+		statsService = service.NewStatsService(DBStorage)
+
+	}
+
+	keys, err := util.NewKeySet(cfg)
 
+	if err != nil {
+		log.Fatal().Err(err).Msg("failed load jwt keys")
 	}
 
-	s := server.New(cfg, userService, bookService)
+	if cfg.JWTSecret == "" && cfg.JWTKeyFile == "" {
+		log.Warn().Msg("JWT_SECRET is not set, tokens are signed with a random key and expire on restart")
+	}
+
+	s := server.New(cfg, keys, userService, bookService, loanService, copyService, holdService, fineService, sessionService, authorService, genreService, tagService, auditService, statsService)
 
 	group, gCtx := errgroup.WithContext(ctx)
 	group.Go(func() error {
@@ -109,3 +161,17 @@ func main() {
 	log.Info().Msg("shutdown complete")
 
 }
+
+func dbPool(cfg config.ConfigStruct) storage.PoolConfigStruct {
+	return storage.PoolConfigStruct{
+		MinConns:          cfg.DbMinConns,
+		MaxConns:          cfg.DbMaxConns,
+		MaxConnLifetime:   cfg.DbMaxConnLifetime,
+		MaxConnIdleTime:   cfg.DbMaxConnIdleTime,
+		HealthCheckPeriod: cfg.DbHealthCheckPeriod,
+	}
+}
+
+func dbTimeouts(cfg config.ConfigStruct) storage.TimeoutsStruct {
+	return storage.TimeoutsStruct{Query: cfg.QueryTimeout, Import: cfg.ImportTimeout, Export: cfg.ExportTimeout}
+}
@@ -0,0 +1,118 @@
+// Package catalog reads bibliographic records from CSV, MARC21 (ISO 2709) and MARCXML files,
+// maps them to books, and writes books back as CSV, JSON Lines or MARCXML.
+package catalog
+
+import (
+	"errors"
+	"fmt"
+	"github.com/go-playground/validator"
+	"io"
+	"library/internal/domain/models"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+const (
+	FormatCSV     = "csv"
+	FormatMARC    = "marc"
+	FormatMARCXML = "marcxml"
+)
+
+var ErrUnknownFormat = errors.New("unknown catalogue format")
+
+// RecordStruct is one record of the file. Row is the line of a CSV file or the number of a MARC record.
+// Err is set when the record could not be read, Book is then incomplete.
+type RecordStruct struct {
+	Row  int
+	Book models.BookStruct
+	Err  error
+}
+
+// Read parses the whole file in the given format.
+func Read(r io.Reader, format string) ([]RecordStruct, error) {
+
+	switch format {
+	case FormatCSV:
+		return readCSV(r)
+	case FormatMARC:
+		return readMARC(r)
+	case FormatMARCXML:
+		return readMARCXML(r)
+	}
+
+	return nil, fmt.Errorf("%w '%s'", ErrUnknownFormat, format)
+
+}
+
+// DetectFormat guesses the format by the file extension.
+func DetectFormat(filename string) string {
+
+	switch strings.ToLower(filepath.Ext(filename)) {
+	case ".csv":
+		return FormatCSV
+	case ".mrc", ".marc", ".iso", ".iso2709":
+		return FormatMARC
+	case ".xml", ".marcxml":
+		return FormatMARCXML
+	}
+
+	return ""
+
+}
+
+// parseYear accepts "1869", "c1869.", "[1869?]" and full dates as they appear in the records.
+func parseYear(s string) (time.Time, error) {
+
+	s = strings.TrimSpace(s)
+
+	if s == "" {
+		return time.Time{}, nil
+	}
+
+	if t, err := time.Parse("2006-01-02", s); err == nil {
+		return t, nil
+	}
+
+	digits := strings.Map(func(r rune) rune {
+		if r >= '0' && r <= '9' {
+			return r
+		}
+		return -1
+	}, s)
+
+	if len(digits) < 4 {
+		return time.Time{}, fmt.Errorf("invalid date '%s'", s)
+	}
+
+	return time.Parse("2006", digits[:4])
+
+}
+
+// Rows turns the records into import rows. Records that could not be read or fail validation
+// are marked invalid, the rest are left for the service to save.
+func Rows(records []RecordStruct, valid *validator.Validate) []models.ImportRowStruct {
+
+	rows := make([]models.ImportRowStruct, 0, len(records))
+
+	for _, record := range records {
+
+		row := models.ImportRowStruct{Row: record.Row, Book: record.Book}
+
+		err := record.Err
+
+		if err == nil {
+			err = valid.Struct(record.Book)
+		}
+
+		if err != nil {
+			row.Status, row.Error = models.ImportInvalid, err.Error()
+		}
+
+		rows = append(rows, row)
+
+	}
+
+	return rows
+
+}
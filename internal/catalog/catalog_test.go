@@ -0,0 +1,91 @@
+package catalog_test
+
+import (
+	"library/internal/catalog"
+	"library/internal/domain/models"
+	"library/internal/server"
+	"strings"
+	"testing"
+)
+
+// wantRecord is what a parser test expects of a record after catalog.Rows. An invalid record is checked
+// by status and by a part of the error, a valid one by its fields.
+type wantRecord struct {
+	name   string
+	author string
+	isbn10 string
+	isbn13 string
+	year   int
+	status string
+	err    string
+}
+
+func checkRecords(t *testing.T, records []catalog.RecordStruct, want []wantRecord) {
+
+	t.Helper()
+
+	rows := catalog.Rows(records, server.NewValidator())
+
+	if len(rows) != len(want) {
+		t.Fatalf("got %d records, want %d: %+v", len(rows), len(want), rows)
+	}
+
+	for i, row := range rows {
+
+		w := want[i]
+
+		if row.Status != w.status {
+			t.Errorf("record %d: got status %q (%s), want %q", row.Row, row.Status, row.Error, w.status)
+			continue
+		}
+
+		if w.status != "" {
+
+			if !strings.Contains(row.Error, w.err) {
+				t.Errorf("record %d: got error %q, want it to mention %q", row.Row, row.Error, w.err)
+			}
+
+			continue
+
+		}
+
+		book := row.Book
+
+		if book.Name != w.name || book.Author != w.author || book.ISBN10 != w.isbn10 || book.ISBN13 != w.isbn13 {
+			t.Errorf("record %d: got %q by %q, ISBN %q/%q, want %q by %q, ISBN %q/%q", row.Row,
+				book.Name, book.Author, book.ISBN10, book.ISBN13, w.name, w.author, w.isbn10, w.isbn13)
+		}
+
+		if year := book.DateWriting.Year(); w.year != 0 && year != w.year {
+			t.Errorf("record %d: got year %d, want %d", row.Row, year, w.year)
+		}
+
+	}
+
+}
+
+func TestDetectFormat(t *testing.T) {
+
+	tests := map[string]string{
+		"books.csv":     catalog.FormatCSV,
+		"books.CSV":     catalog.FormatCSV,
+		"books.mrc":     catalog.FormatMARC,
+		"books.iso":     catalog.FormatMARC,
+		"books.xml":     catalog.FormatMARCXML,
+		"books.txt":     "",
+		"books":         "",
+		"books.marcxml": catalog.FormatMARCXML,
+	}
+
+	for name, want := range tests {
+
+		if got := catalog.DetectFormat(name); got != want {
+			t.Errorf("DetectFormat(%q) = %q, want %q", name, got, want)
+		}
+
+	}
+
+}
+
+// invalid is the status of a record catalog.Rows rejects.
+const invalid = models.ImportInvalid
@@ -0,0 +1,117 @@
+package catalog
+
+import (
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"library/internal/domain/isbn"
+	"strings"
+)
+
+// Header names understood in the first line of a CSV file, case does not matter.
+var csvColumns = map[string]string{
+	"name":        "name",
+	"title":       "name",
+	"author":      "author",
+	"authors":     "author",
+	"desc":        "desc",
+	"description": "desc",
+	"isbn":        "isbn",
+	"isbn10":      "isbn",
+	"isbn13":      "isbn",
+	"date":        "date",
+	"year":        "date",
+}
+
+func readCSV(r io.Reader) ([]RecordStruct, error) {
+
+	reader := csv.NewReader(r)
+	reader.FieldsPerRecord = -1
+	reader.TrimLeadingSpace = true
+
+	header, err := reader.Read()
+
+	if err != nil {
+		return nil, fmt.Errorf("read csv header: %w", err)
+	}
+
+	columns := make(map[string]int)
+
+	for i, name := range header {
+
+		if column, ok := csvColumns[strings.ToLower(strings.TrimSpace(name))]; ok {
+
+			if _, seen := columns[column]; !seen {
+				columns[column] = i
+			}
+
+		}
+
+	}
+
+	if _, ok := columns["name"]; !ok {
+		return nil, errors.New("csv header has no name or title column")
+	}
+
+	var records []RecordStruct
+
+	for row := 2; ; row++ {
+
+		fields, err := reader.Read()
+
+		if errors.Is(err, io.EOF) {
+			break
+		}
+
+		record := RecordStruct{Row: row}
+
+		if err != nil {
+			record.Err = err
+			records = append(records, record)
+			continue
+		}
+
+		get := func(column string) string {
+
+			if i, ok := columns[column]; ok && i < len(fields) {
+				return strings.TrimSpace(fields[i])
+			}
+
+			return ""
+
+		}
+
+		record.Book.Name = get("name")
+		record.Book.Author = get("author")
+		record.Book.Description = get("desc")
+		setISBN(&record, get("isbn"))
+
+		if record.Err == nil {
+			record.Book.DateWriting, record.Err = parseYear(get("date"))
+		}
+
+		records = append(records, record)
+
+	}
+
+	return records, nil
+
+}
+
+// setISBN puts the number into the field of its length, the service fills in the other form.
+func setISBN(record *RecordStruct, number string) {
+
+	number = isbn.Normalize(number)
+
+	switch {
+	case number == "":
+	case isbn.Valid13(number):
+		record.Book.ISBN13 = number
+	case isbn.Valid10(number):
+		record.Book.ISBN10 = number
+	default:
+		record.Err = fmt.Errorf("%w '%s'", isbn.ErrInvalid, number)
+	}
+
+}
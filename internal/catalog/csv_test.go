@@ -0,0 +1,77 @@
+package catalog_test
+
+import (
+	"errors"
+	"library/internal/catalog"
+	"strings"
+	"testing"
+)
+
+func TestReadCSV(t *testing.T) {
+
+	tests := []struct {
+		name string
+		file string
+		want []wantRecord
+	}{
+		{"valid", "Title,Authors,ISBN,Year,Description\n" +
+			"War and peace,\"Tolstoy, Leo\",0-306-40615-2,1869,A novel\n" +
+			"Title, Author, 9791090636071, 2001-05-01,\n", []wantRecord{
+			{name: "War and peace", author: "Tolstoy, Leo", isbn10: "0306406152", year: 1869},
+			{name: "Title", author: "Author", isbn13: "9791090636071", year: 2001},
+		}},
+		{"header in any case and order", "year,AUTHOR,name\n1869,Tolstoy,War and peace\n", []wantRecord{
+			{name: "War and peace", author: "Tolstoy", year: 1869},
+		}},
+		{"short row", "name,author,isbn\nTitle,Author\n", []wantRecord{
+			{name: "Title", author: "Author"},
+		}},
+		{"no author column", "name,isbn\nTitle,\n", []wantRecord{{status: invalid, err: "'author'"}}},
+		{"empty name", "name,author\n,Author\n", []wantRecord{{status: invalid, err: "'name'"}}},
+		{"invalid ISBN", "name,author,isbn\nTitle,Author,0306406153\n", []wantRecord{{status: invalid, err: "invalid ISBN"}}},
+		{"invalid date", "name,author,date\nTitle,Author,n.d.\n", []wantRecord{{status: invalid, err: "invalid date"}}},
+		{"bare quote", "name,author\n\"Title,Author\n", []wantRecord{{status: invalid, err: "quote"}}},
+	}
+
+	for _, test := range tests {
+
+		t.Run(test.name, func(t *testing.T) {
+
+			records, err := catalog.Read(strings.NewReader(test.file), catalog.FormatCSV)
+
+			if err != nil {
+				t.Fatalf("Read: %v", err)
+			}
+
+			checkRecords(t, records, test.want)
+
+		})
+
+	}
+
+}
+
+func TestReadCSVBadHeader(t *testing.T) {
+
+	for name, file := range map[string]string{
+		"empty file":        "",
+		"no name column":    "author,isbn\nAuthor,0306406152\n",
+		"unknown columns":   "foo,bar\n1,2\n",
+		"broken header row": "\"name,author\n",
+	} {
+
+		if _, err := catalog.Read(strings.NewReader(file), catalog.FormatCSV); err == nil {
+			t.Errorf("%s: Read returned no error", name)
+		}
+
+	}
+
+}
+
+func TestReadUnknownFormat(t *testing.T) {
+
+	if _, err := catalog.Read(strings.NewReader(""), "pdf"); !errors.Is(err, catalog.ErrUnknownFormat) {
+		t.Fatalf("Read of an unknown format: got %v, want %v", err, catalog.ErrUnknownFormat)
+	}
+
+}
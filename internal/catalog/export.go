@@ -0,0 +1,293 @@
+package catalog
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"library/internal/domain/models"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const FormatJSONL = "jsonl"
+
+// BookWriter writes books one at a time, so an export never holds the catalogue in memory.
+type BookWriter interface {
+	Write(models.BookStruct) error
+	Close() error
+}
+
+type UserWriter interface {
+	Write(models.UserStruct) error
+	Close() error
+}
+
+func ContentType(format string) string {
+
+	switch format {
+	case FormatCSV:
+		return "text/csv; charset=utf-8"
+	case FormatJSONL:
+		return "application/x-ndjson"
+	case FormatMARCXML:
+		return "application/marcxml+xml"
+	}
+
+	return "application/octet-stream"
+
+}
+
+// FileExtension is the extension of the export file, which is also what DetectFormat expects on import.
+func FileExtension(format string) string {
+
+	if format == FormatMARCXML {
+		return "xml"
+	}
+
+	return format
+
+}
+
+// NewBookWriter writes nothing to w before the first Write or Close, so the caller can still send its headers
+// or an error after the writer is created.
+func NewBookWriter(w io.Writer, format string) (BookWriter, error) {
+
+	switch format {
+	case FormatCSV:
+		cw, err := newCSVWriter(w, []string{"id", "title", "author", "isbn13", "isbn10", "year", "description"})
+		return csvBookWriter{cw}, err
+	case FormatJSONL:
+		return jsonlBookWriter{jsonlWriter{json.NewEncoder(w)}}, nil
+	case FormatMARCXML:
+		return &marcXMLWriter{w: w, encoder: xml.NewEncoder(w)}, nil
+	}
+
+	return nil, fmt.Errorf("%w '%s'", ErrUnknownFormat, format)
+
+}
+
+// NewUserWriter has no MARC form, users are exported as CSV or JSON Lines. Password hashes are never written.
+func NewUserWriter(w io.Writer, format string) (UserWriter, error) {
+
+	switch format {
+	case FormatCSV:
+		cw, err := newCSVWriter(w, []string{"id", "name", "email", "age", "role", "date_reg"})
+		return csvUserWriter{cw}, err
+	case FormatJSONL:
+		return jsonlUserWriter{jsonlWriter{json.NewEncoder(w)}}, nil
+	}
+
+	return nil, fmt.Errorf("%w '%s'", ErrUnknownFormat, format)
+
+}
+
+type csvWriter struct {
+	writer *csv.Writer
+}
+
+func newCSVWriter(w io.Writer, header []string) (csvWriter, error) {
+
+	writer := csv.NewWriter(w)
+
+	if err := writer.Write(header); err != nil {
+		return csvWriter{}, err
+	}
+
+	return csvWriter{writer: writer}, nil
+
+}
+
+func (cw csvWriter) Close() error {
+
+	cw.writer.Flush()
+
+	return cw.writer.Error()
+
+}
+
+type csvBookWriter struct {
+	csvWriter
+}
+
+func (cw csvBookWriter) Write(book models.BookStruct) error {
+	return cw.writer.Write([]string{book.ID.String(), book.Name, book.Author, book.ISBN13, book.ISBN10, year(book), book.Description})
+}
+
+type csvUserWriter struct {
+	csvWriter
+}
+
+func (cw csvUserWriter) Write(user models.UserStruct) error {
+
+	var age string
+
+	if user.Age != 0 {
+		age = strconv.Itoa(user.Age)
+	}
+
+	return cw.writer.Write([]string{user.ID.String(), user.Name, user.Email, age, user.Role, user.DateRegistration.Format(time.RFC3339)})
+
+}
+
+type jsonlWriter struct {
+	encoder *json.Encoder
+}
+
+func (jw jsonlWriter) Close() error {
+	return nil
+}
+
+type jsonlBookWriter struct {
+	jsonlWriter
+}
+
+func (jw jsonlBookWriter) Write(book models.BookStruct) error {
+	return jw.encoder.Encode(book)
+}
+
+type jsonlUserWriter struct {
+	jsonlWriter
+}
+
+// exportUserStruct is UserStruct without the password field.
+type exportUserStruct struct {
+	ID               string    `json:"id"`
+	Name             string    `json:"name"`
+	Email            string    `json:"email"`
+	Age              int       `json:"age,omitempty"`
+	Role             string    `json:"role,omitempty"`
+	DateRegistration time.Time `json:"date_reg"`
+}
+
+func (jw jsonlUserWriter) Write(user models.UserStruct) error {
+	return jw.encoder.Encode(exportUserStruct{
+		ID:               user.ID.String(),
+		Name:             user.Name,
+		Email:            user.Email,
+		Age:              user.Age,
+		Role:             user.Role,
+		DateRegistration: user.DateRegistration,
+	})
+}
+
+// marcXMLWriter writes the fields readMARCXML understands, so an export can be imported elsewhere.
+type marcXMLWriter struct {
+	w       io.Writer
+	encoder *xml.Encoder
+	started bool
+}
+
+type marcXMLOut struct {
+	XMLName       xml.Name         `xml:"record"`
+	Leader        string           `xml:"leader"`
+	ControlFields []marcXMLControl `xml:"controlfield"`
+	DataFields    []marcXMLField   `xml:"datafield"`
+}
+
+type marcXMLControl struct {
+	Tag   string `xml:"tag,attr"`
+	Value string `xml:",chardata"`
+}
+
+type marcXMLField struct {
+	Tag       string            `xml:"tag,attr"`
+	Ind1      string            `xml:"ind1,attr"`
+	Ind2      string            `xml:"ind2,attr"`
+	SubFields []marcXMLSubfield `xml:"subfield"`
+}
+
+type marcXMLSubfield struct {
+	Code  string `xml:"code,attr"`
+	Value string `xml:",chardata"`
+}
+
+// start writes the prolog and opens the collection. It is left to the first Write or Close,
+// writing to a response commits its headers.
+func (mw *marcXMLWriter) start() error {
+
+	if mw.started {
+		return nil
+	}
+
+	mw.started = true
+
+	_, err := io.WriteString(mw.w, xml.Header+`<collection xmlns="http://www.loc.gov/MARC21/slim">`+"\n")
+
+	return err
+
+}
+
+func (mw *marcXMLWriter) Write(book models.BookStruct) error {
+
+	if err := mw.start(); err != nil {
+		return err
+	}
+
+	record := marcXMLOut{
+		Leader:        "00000nam a2200000 a 4500",
+		ControlFields: []marcXMLControl{{Tag: "001", Value: book.ID.String()}},
+	}
+
+	field := func(tag string, code string, value string) {
+		if value != "" {
+			record.DataFields = append(record.DataFields, marcXMLField{Tag: tag, Ind1: " ", Ind2: " ",
+				SubFields: []marcXMLSubfield{{Code: code, Value: value}}})
+		}
+	}
+
+	field("020", "a", book.ISBN13)
+	field("020", "a", book.ISBN10)
+
+	// The import joins 100 and 700 with semicolons, the export splits the same way.
+	for i, name := range strings.Split(book.Author, "; ") {
+
+		if i == 0 {
+			field("100", "a", name)
+		} else {
+			field("700", "a", name)
+		}
+
+	}
+
+	field("245", "a", book.Name)
+	field("260", "c", year(book))
+	field("520", "a", book.Description)
+
+	if err := mw.encoder.Encode(record); err != nil {
+		return err
+	}
+
+	_, err := io.WriteString(mw.w, "\n")
+
+	return err
+
+}
+
+func (mw *marcXMLWriter) Close() error {
+
+	if err := mw.start(); err != nil {
+		return err
+	}
+
+	if err := mw.encoder.Flush(); err != nil {
+		return err
+	}
+
+	_, err := io.WriteString(mw.w, "</collection>\n")
+
+	return err
+
+}
+
+func year(book models.BookStruct) string {
+
+	if book.DateWriting.IsZero() {
+		return ""
+	}
+
+	return strconv.Itoa(book.DateWriting.Year())
+
+}
@@ -0,0 +1,239 @@
+package catalog
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"library/internal/domain/models"
+	"strconv"
+	"strings"
+)
+
+// ISO 2709 separators.
+const (
+	marcRecordEnd   = 0x1D
+	marcFieldEnd    = 0x1E
+	marcSubfieldSep = 0x1F
+	marcLeaderLen   = 24
+	marcDirEntryLen = 12
+)
+
+type marcSubfield struct {
+	code  byte
+	value string
+}
+
+// marcRecord keeps the data fields by tag, control fields are not needed for books.
+type marcRecord map[string][][]marcSubfield
+
+// first returns the first subfield with the code in the first field with the tag.
+func (rec marcRecord) first(tag string, code byte) string {
+
+	for _, field := range rec[tag] {
+
+		for _, sub := range field {
+
+			if sub.code == code {
+				return sub.value
+			}
+
+		}
+
+	}
+
+	return ""
+
+}
+
+func (rec marcRecord) all(tag string, code byte) []string {
+
+	var values []string
+
+	for _, field := range rec[tag] {
+
+		for _, sub := range field {
+
+			if sub.code == code {
+				values = append(values, sub.value)
+			}
+
+		}
+
+	}
+
+	return values
+
+}
+
+// toRecord maps the MARC21 bibliographic fields:
+// 245 $a $b title, 100/700 $a authors, 020 $a ISBN, 520 $a summary, 260/264 $c date.
+func (rec marcRecord) toRecord(row int) RecordStruct {
+
+	record := RecordStruct{Row: row}
+
+	title := trimISBD(rec.first("245", 'a'))
+
+	if subtitle := trimISBD(rec.first("245", 'b')); subtitle != "" {
+		title += ": " + subtitle
+	}
+
+	record.Book.Name = title
+
+	var names []string
+
+	for _, name := range append(rec.all("100", 'a'), rec.all("700", 'a')...) {
+
+		if name = trimISBD(name); name != "" {
+			names = append(names, name)
+			record.Book.Authors = append(record.Book.Authors, models.AuthorStruct{Name: name})
+		}
+
+	}
+
+	// MARC names are "Surname, Forename", so the author line is joined with semicolons.
+	record.Book.Author = strings.Join(names, "; ")
+	record.Book.Description = strings.TrimSpace(rec.first("520", 'a'))
+
+	// 020 $a may carry a qualifier: "0441172717 (pbk.)".
+	if number := strings.Fields(rec.first("020", 'a')); len(number) > 0 {
+		setISBN(&record, number[0])
+	}
+
+	if record.Err == nil {
+
+		date := rec.first("264", 'c')
+
+		if date == "" {
+			date = rec.first("260", 'c')
+		}
+
+		record.Book.DateWriting, record.Err = parseYear(date)
+
+	}
+
+	return record
+
+}
+
+// trimISBD drops the punctuation MARC cataloguers put at the end of subfields.
+func trimISBD(s string) string {
+	return strings.TrimSpace(strings.TrimRight(strings.TrimSpace(s), " /:;,."))
+}
+
+func readMARC(r io.Reader) ([]RecordStruct, error) {
+
+	reader := bufio.NewReader(r)
+
+	var records []RecordStruct
+
+	for row := 1; ; row++ {
+
+		data, err := reader.ReadBytes(marcRecordEnd)
+
+		if len(bytes.TrimSpace(data)) == 0 && errors.Is(err, io.EOF) {
+			break
+		}
+
+		if err != nil && !errors.Is(err, io.EOF) {
+			return nil, err
+		}
+
+		rec, parseErr := parseISO2709(data)
+
+		if parseErr != nil {
+			records = append(records, RecordStruct{Row: row, Err: parseErr})
+		} else {
+			records = append(records, rec.toRecord(row))
+		}
+
+		if errors.Is(err, io.EOF) {
+			break
+		}
+
+	}
+
+	return records, nil
+
+}
+
+// parseISO2709 reads one record: the leader, the directory of 12 byte entries
+// (tag, length, offset) and the fields starting at the base address.
+func parseISO2709(data []byte) (marcRecord, error) {
+
+	data = bytes.TrimLeft(data, "\r\n ")
+
+	if len(data) < marcLeaderLen {
+		return nil, errors.New("marc record is shorter than its leader")
+	}
+
+	base, err := marcNumber(data[12:17])
+
+	if err != nil || base <= marcLeaderLen || base > len(data) {
+		return nil, fmt.Errorf("marc record has invalid base address '%s'", data[12:17])
+	}
+
+	directory := data[marcLeaderLen : base-1]
+
+	if len(directory)%marcDirEntryLen != 0 {
+		return nil, errors.New("marc record has broken directory")
+	}
+
+	rec := make(marcRecord)
+
+	for i := 0; i < len(directory); i += marcDirEntryLen {
+
+		entry := directory[i : i+marcDirEntryLen]
+		tag := string(entry[0:3])
+
+		length, errLen := marcNumber(entry[3:7])
+		start, errStart := marcNumber(entry[7:12])
+
+		if errLen != nil || errStart != nil || base+start+length > len(data) {
+			return nil, fmt.Errorf("marc field %s is out of the record", tag)
+		}
+
+		// Control fields 00X have no indicators and subfields.
+		if strings.HasPrefix(tag, "00") {
+			continue
+		}
+
+		field := bytes.TrimRight(data[base+start:base+start+length], string([]byte{marcFieldEnd}))
+
+		var subfields []marcSubfield
+
+		// The first part holds the two indicators, subfields follow each delimiter.
+		for _, part := range bytes.Split(field, []byte{marcSubfieldSep})[1:] {
+
+			if len(part) == 0 {
+				continue
+			}
+
+			subfields = append(subfields, marcSubfield{code: part[0], value: string(part[1:])})
+
+		}
+
+		rec[tag] = append(rec[tag], subfields)
+
+	}
+
+	return rec, nil
+
+}
+
+// marcNumber reads a number of the leader or the directory. These are unsigned and zero-padded,
+// strconv.Atoi alone would also take a sign and let a negative offset through.
+func marcNumber(b []byte) (int, error) {
+
+	for _, c := range b {
+
+		if c < '0' || c > '9' {
+			return 0, fmt.Errorf("marc number '%s' is not all digits", b)
+		}
+
+	}
+
+	return strconv.Atoi(string(b))
+
+}
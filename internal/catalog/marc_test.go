@@ -0,0 +1,132 @@
+package catalog_test
+
+import (
+	"bytes"
+	"fmt"
+	"library/internal/catalog"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+// marcField is one data field of a test record: the tag and the content with indicators and subfields,
+// "$" stands for the subfield delimiter.
+type marcField struct {
+	tag     string
+	content string
+}
+
+// marcRecord builds an ISO 2709 record with a valid leader and directory.
+func marcRecord(fields ...marcField) string {
+
+	var directory, data strings.Builder
+
+	for _, field := range fields {
+
+		content := strings.ReplaceAll(field.content, "$", "\x1f") + "\x1e"
+
+		fmt.Fprintf(&directory, "%s%04d%05d", field.tag, len(content), data.Len())
+		data.WriteString(content)
+
+	}
+
+	base := 24 + directory.Len() + 1
+	length := base + data.Len() + 1
+
+	return fmt.Sprintf("%05dnam a22%05d   4500", length, base) + directory.String() + "\x1e" + data.String() + "\x1d"
+
+}
+
+// shiftBase moves the base address of the record, the directory then ends in the middle of an entry.
+func shiftBase(record string, by int) string {
+
+	base, _ := strconv.Atoi(record[12:17])
+
+	return fmt.Sprintf("%05d", base+by)
+
+}
+
+func TestReadMARC(t *testing.T) {
+
+	valid := marcRecord(
+		marcField{"008", "700101s1869"},
+		marcField{"020", "  $a0306406152 (pbk.)"},
+		marcField{"100", "1 $aTolstoy, Leo,"},
+		marcField{"245", "10$aWar and peace :$ba novel /$cLeo Tolstoy."},
+		marcField{"264", " 1$aMoscow :$c[1869?]"},
+		marcField{"520", "  $aA novel."},
+		marcField{"700", "1 $aMaude, Aylmer."},
+	)
+
+	tests := []struct {
+		name string
+		file string
+		want []wantRecord
+	}{
+		{"valid", valid, []wantRecord{{name: "War and peace: a novel", author: "Tolstoy, Leo; Maude, Aylmer",
+			isbn10: "0306406152", year: 1869}}},
+		{"two records and a trailing newline", valid + marcRecord(
+			marcField{"020", "  $a9791090636071"},
+			marcField{"100", "1 $aAuthor"},
+			marcField{"245", "00$aTitle."},
+			marcField{"260", "  $c2001."},
+		) + "\n", []wantRecord{
+			{name: "War and peace: a novel", author: "Tolstoy, Leo; Maude, Aylmer", isbn10: "0306406152", year: 1869},
+			{name: "Title", author: "Author", isbn13: "9791090636071", year: 2001},
+		}},
+		{"no 245", marcRecord(marcField{"100", "1 $aAuthor"}),
+			[]wantRecord{{status: invalid, err: "'name'"}}},
+		{"no 100", marcRecord(marcField{"245", "00$aTitle"}),
+			[]wantRecord{{status: invalid, err: "'author'"}}},
+		{"invalid ISBN", marcRecord(marcField{"020", "  $a0306406153"}, marcField{"100", "1 $aAuthor"}, marcField{"245", "00$aTitle"}),
+			[]wantRecord{{status: invalid, err: "invalid ISBN"}}},
+		{"invalid date", marcRecord(marcField{"100", "1 $aAuthor"}, marcField{"245", "00$aTitle"}, marcField{"260", "  $cn.d."}),
+			[]wantRecord{{status: invalid, err: "invalid date"}}},
+		{"short leader", "00020nam a22\x1d",
+			[]wantRecord{{status: invalid, err: "shorter than its leader"}}},
+		{"broken base address", valid[:12] + "00x37" + valid[17:],
+			[]wantRecord{{status: invalid, err: "invalid base address"}}},
+		{"signed base address", valid[:12] + "+0037" + valid[17:],
+			[]wantRecord{{status: invalid, err: "invalid base address"}}},
+		{"broken directory", valid[:12] + shiftBase(valid, -1) + valid[17:],
+			[]wantRecord{{status: invalid, err: "broken directory"}}},
+		{"field out of the record", valid[:27] + "9999" + valid[31:],
+			[]wantRecord{{status: invalid, err: "out of the record"}}},
+	}
+
+	for _, test := range tests {
+
+		t.Run(test.name, func(t *testing.T) {
+
+			records, err := catalog.Read(strings.NewReader(test.file), catalog.FormatMARC)
+
+			if err != nil {
+				t.Fatalf("Read: %v", err)
+			}
+
+			checkRecords(t, records, test.want)
+
+		})
+
+	}
+
+}
+
+func TestReadMARCNegativeDirectory(t *testing.T) {
+
+	record := marcRecord(marcField{"245", "10$aTitle"})
+
+	// The directory entry of the only field starts right after the leader.
+	record = record[:24] + "245-01000000" + record[36:]
+
+	records, err := catalog.Read(bytes.NewReader([]byte(record)), catalog.FormatMARC)
+
+	if err != nil {
+		t.Fatalf("Read: %v", err)
+	}
+
+	if len(records) != 1 || records[0].Err == nil {
+		t.Fatalf("got %+v, want one record with an error", records)
+	}
+
+}
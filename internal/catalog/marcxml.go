@@ -0,0 +1,80 @@
+package catalog
+
+import (
+	"encoding/xml"
+	"errors"
+	"io"
+)
+
+// The MARC21 slim schema; the namespace is left out of the tags so that files without it are read too.
+type marcXMLRecord struct {
+	DataFields []struct {
+		Tag       string `xml:"tag,attr"`
+		SubFields []struct {
+			Code  string `xml:"code,attr"`
+			Value string `xml:",chardata"`
+		} `xml:"subfield"`
+	} `xml:"datafield"`
+}
+
+// readMARCXML streams the records, so a <collection> of any size is read one record at a time.
+func readMARCXML(r io.Reader) ([]RecordStruct, error) {
+
+	decoder := xml.NewDecoder(r)
+
+	var records []RecordStruct
+
+	row := 0
+
+	for {
+
+		token, err := decoder.Token()
+
+		if errors.Is(err, io.EOF) {
+			break
+		}
+
+		if err != nil {
+			return records, err
+		}
+
+		start, ok := token.(xml.StartElement)
+
+		if !ok || start.Name.Local != "record" {
+			continue
+		}
+
+		row++
+
+		var xmlRecord marcXMLRecord
+
+		if err = decoder.DecodeElement(&xmlRecord, &start); err != nil {
+			records = append(records, RecordStruct{Row: row, Err: err})
+			return records, err
+		}
+
+		rec := make(marcRecord)
+
+		for _, field := range xmlRecord.DataFields {
+
+			var subfields []marcSubfield
+
+			for _, sub := range field.SubFields {
+
+				if sub.Code != "" {
+					subfields = append(subfields, marcSubfield{code: sub.Code[0], value: sub.Value})
+				}
+
+			}
+
+			rec[field.Tag] = append(rec[field.Tag], subfields)
+
+		}
+
+		records = append(records, rec.toRecord(row))
+
+	}
+
+	return records, nil
+
+}
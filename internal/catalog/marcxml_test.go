@@ -0,0 +1,84 @@
+package catalog_test
+
+import (
+	"library/internal/catalog"
+	"strings"
+	"testing"
+)
+
+const marcXMLValid = `<record>
+  <datafield tag="020" ind1=" " ind2=" "><subfield code="a">978-0-306-40615-7</subfield></datafield>
+  <datafield tag="100" ind1="1" ind2=" "><subfield code="a">Tolstoy, Leo,</subfield></datafield>
+  <datafield tag="245" ind1="1" ind2="0">
+    <subfield code="a">War and peace :</subfield>
+    <subfield code="b">a novel /</subfield>
+  </datafield>
+  <datafield tag="260" ind1=" " ind2=" "><subfield code="c">c1869.</subfield></datafield>
+</record>`
+
+func TestReadMARCXML(t *testing.T) {
+
+	tests := []struct {
+		name string
+		file string
+		want []wantRecord
+	}{
+		{"collection with the namespace", `<?xml version="1.0"?>
+<collection xmlns="http://www.loc.gov/MARC21/slim">` + marcXMLValid + `
+<record>
+  <datafield tag="100" ind1="1" ind2=" "><subfield code="a">Author</subfield></datafield>
+  <datafield tag="245" ind1="0" ind2="0"><subfield code="a">Title.</subfield></datafield>
+</record>
+</collection>`, []wantRecord{
+			{name: "War and peace: a novel", author: "Tolstoy, Leo", isbn13: "9780306406157", year: 1869},
+			{name: "Title", author: "Author"},
+		}},
+		{"single record without the namespace", marcXMLValid, []wantRecord{
+			{name: "War and peace: a novel", author: "Tolstoy, Leo", isbn13: "9780306406157", year: 1869},
+		}},
+		{"no 245", `<collection><record>
+  <datafield tag="100" ind1="1" ind2=" "><subfield code="a">Author</subfield></datafield>
+</record></collection>`, []wantRecord{{status: invalid, err: "'name'"}}},
+		{"no 100", `<collection><record>
+  <datafield tag="245" ind1="0" ind2="0"><subfield code="a">Title</subfield></datafield>
+</record></collection>`, []wantRecord{{status: invalid, err: "'author'"}}},
+		{"invalid ISBN", `<collection><record>
+  <datafield tag="020" ind1=" " ind2=" "><subfield code="a">9780306406158</subfield></datafield>
+  <datafield tag="100" ind1="1" ind2=" "><subfield code="a">Author</subfield></datafield>
+  <datafield tag="245" ind1="0" ind2="0"><subfield code="a">Title</subfield></datafield>
+</record></collection>`, []wantRecord{{status: invalid, err: "invalid ISBN"}}},
+		{"no records", `<collection></collection>`, nil},
+	}
+
+	for _, test := range tests {
+
+		t.Run(test.name, func(t *testing.T) {
+
+			records, err := catalog.Read(strings.NewReader(test.file), catalog.FormatMARCXML)
+
+			if err != nil {
+				t.Fatalf("Read: %v", err)
+			}
+
+			checkRecords(t, records, test.want)
+
+		})
+
+	}
+
+}
+
+func TestReadMARCXMLBroken(t *testing.T) {
+
+	for name, file := range map[string]string{
+		"unclosed record": `<collection>` + marcXMLValid + `<record><datafield tag="245">`,
+		"not xml":         `<collection><record></collection>`,
+	} {
+
+		if _, err := catalog.Read(strings.NewReader(file), catalog.FormatMARCXML); err == nil {
+			t.Errorf("%s: Read returned no error", name)
+		}
+
+	}
+
+}
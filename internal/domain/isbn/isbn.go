@@ -0,0 +1,180 @@
+// Package isbn checks ISBN-10/ISBN-13 checksums and converts between the two forms.
+// All functions take the number with or without hyphens and spaces.
+package isbn
+
+import (
+	"errors"
+	"strings"
+)
+
+var (
+	ErrInvalid  = errors.New("invalid ISBN")
+	ErrMismatch = errors.New("ISBN-10 and ISBN-13 are different books")
+)
+
+// Normalize drops hyphens and spaces and upper-cases the ISBN-10 check digit.
+func Normalize(s string) string {
+	return strings.ToUpper(strings.NewReplacer("-", "", " ", "").Replace(s))
+}
+
+func Valid10(s string) bool {
+
+	s = Normalize(s)
+
+	if len(s) != 10 {
+		return false
+	}
+
+	sum := 0
+
+	for i, r := range s {
+
+		var digit int
+
+		switch {
+		case r >= '0' && r <= '9':
+			digit = int(r - '0')
+		case r == 'X' && i == 9:
+			digit = 10
+		default:
+			return false
+		}
+
+		sum += (10 - i) * digit
+
+	}
+
+	return sum%11 == 0
+
+}
+
+func Valid13(s string) bool {
+
+	s = Normalize(s)
+
+	if len(s) != 13 || !digits(s) {
+		return false
+	}
+
+	return check13(s[:12]) == s[12]
+
+}
+
+// To13 converts a valid ISBN-10 to ISBN-13 by adding the 978 prefix.
+func To13(s string) (string, error) {
+
+	if !Valid10(s) {
+		return "", ErrInvalid
+	}
+
+	body := "978" + Normalize(s)[:9]
+
+	return body + string(check13(body)), nil
+
+}
+
+// To10 converts an ISBN-13. Only 978 numbers have an ISBN-10 form, for the others ok is false.
+func To10(s string) (string, bool, error) {
+
+	if !Valid13(s) {
+		return "", false, ErrInvalid
+	}
+
+	s = Normalize(s)
+
+	if !strings.HasPrefix(s, "978") {
+		return "", false, nil
+	}
+
+	body := s[3:12]
+	sum := 0
+
+	for i, r := range body {
+		sum += (10 - i) * int(r-'0')
+	}
+
+	check := (11 - sum%11) % 11
+
+	if check == 10 {
+		return body + "X", true, nil
+	}
+
+	return body + string(rune('0'+check)), true, nil
+
+}
+
+// Complete normalizes the pair and fills in the missing form.
+func Complete(isbn10 string, isbn13 string) (string, string, error) {
+
+	isbn10, isbn13 = Normalize(isbn10), Normalize(isbn13)
+
+	switch {
+	case isbn10 == "" && isbn13 == "":
+		return "", "", nil
+	case isbn13 == "":
+		converted, err := To13(isbn10)
+		return isbn10, converted, err
+	}
+
+	converted, ok, err := To10(isbn13)
+
+	if err != nil {
+		return "", "", err
+	}
+
+	if isbn10 == "" {
+		return converted, isbn13, nil
+	}
+
+	if !ok || converted != isbn10 {
+		return "", "", ErrMismatch
+	}
+
+	return isbn10, isbn13, nil
+
+}
+
+// To13Any takes either form and returns the ISBN-13, which is what books are looked up by.
+func To13Any(s string) (string, error) {
+
+	if Valid13(s) {
+		return Normalize(s), nil
+	}
+
+	return To13(s)
+
+}
+
+func check13(body string) byte {
+
+	sum := 0
+
+	for i, r := range body {
+
+		weight := 1
+
+		if i%2 == 1 {
+			weight = 3
+		}
+
+		sum += weight * int(r-'0')
+
+	}
+
+	return byte('0' + (10-sum%10)%10)
+
+}
+
+func digits(s string) bool {
+
+	for _, r := range s {
+
+		if r < '0' || r > '9' {
+			return false
+		}
+
+	}
+
+	return true
+
+}
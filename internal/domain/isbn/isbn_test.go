@@ -0,0 +1,163 @@
+package isbn
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestValid10(t *testing.T) {
+
+	tests := []struct {
+		isbn string
+		want bool
+	}{
+		{"0306406152", true},
+		{"0-306-40615-2", true},
+		{"0 306 40615 2", true},
+		{"080442957X", true},
+		{"080442957x", true},
+		{"0306406153", false},
+		{"X306406152", false},
+		{"030640615", false},
+		{"03064061522", false},
+		{"03064O6152", false},
+		{"", false},
+	}
+
+	for _, test := range tests {
+
+		if got := Valid10(test.isbn); got != test.want {
+			t.Errorf("Valid10(%q) = %v, want %v", test.isbn, got, test.want)
+		}
+
+	}
+
+}
+
+func TestValid13(t *testing.T) {
+
+	tests := []struct {
+		isbn string
+		want bool
+	}{
+		{"9780306406157", true},
+		{"978-0-306-40615-7", true},
+		{"978 0 306 40615 7", true},
+		{"9791090636071", true},
+		{"9780306406158", false},
+		{"978030640615X", false},
+		{"978030640615", false},
+		{"97803064061570", false},
+		{"", false},
+	}
+
+	for _, test := range tests {
+
+		if got := Valid13(test.isbn); got != test.want {
+			t.Errorf("Valid13(%q) = %v, want %v", test.isbn, got, test.want)
+		}
+
+	}
+
+}
+
+func TestConvert(t *testing.T) {
+
+	tests := []struct {
+		isbn10 string
+		isbn13 string
+	}{
+		{"0306406152", "9780306406157"},
+		{"080442957X", "9780804429573"},
+		{"1861972717", "9781861972712"},
+	}
+
+	for _, test := range tests {
+
+		got13, err := To13(test.isbn10)
+
+		if err != nil || got13 != test.isbn13 {
+			t.Errorf("To13(%q) = %q, %v, want %q", test.isbn10, got13, err, test.isbn13)
+		}
+
+		got10, ok, err := To10(test.isbn13)
+
+		if err != nil || !ok || got10 != test.isbn10 {
+			t.Errorf("To10(%q) = %q, %v, %v, want %q", test.isbn13, got10, ok, err, test.isbn10)
+		}
+
+		if back, err := To13(got10); err != nil || back != test.isbn13 {
+			t.Errorf("To13(To10(%q)) = %q, %v", test.isbn13, back, err)
+		}
+
+	}
+
+}
+
+func TestConvertErrors(t *testing.T) {
+
+	if _, err := To13("0306406153"); !errors.Is(err, ErrInvalid) {
+		t.Errorf("To13 of a bad checksum: got %v, want %v", err, ErrInvalid)
+	}
+
+	if _, _, err := To10("9780306406158"); !errors.Is(err, ErrInvalid) {
+		t.Errorf("To10 of a bad checksum: got %v, want %v", err, ErrInvalid)
+	}
+
+	if isbn10, ok, err := To10("979-10-90636-07-1"); err != nil || ok || isbn10 != "" {
+		t.Errorf("To10 of a 979 ISBN = %q, %v, %v, want no ISBN-10", isbn10, ok, err)
+	}
+
+}
+
+func TestComplete(t *testing.T) {
+
+	tests := []struct {
+		isbn10, isbn13 string
+		want10, want13 string
+		err            error
+	}{
+		{"", "", "", "", nil},
+		{"0-306-40615-2", "", "0306406152", "9780306406157", nil},
+		{"", "978-0-306-40615-7", "0306406152", "9780306406157", nil},
+		{"080442957x", "9780804429573", "080442957X", "9780804429573", nil},
+		{"", "9791090636071", "", "9791090636071", nil},
+		{"0306406152", "9791090636071", "", "", ErrMismatch},
+		{"0306406152", "9780804429573", "", "", ErrMismatch},
+		{"0306406153", "", "", "", ErrInvalid},
+		{"", "9780306406158", "", "", ErrInvalid},
+	}
+
+	for _, test := range tests {
+
+		got10, got13, err := Complete(test.isbn10, test.isbn13)
+
+		if !errors.Is(err, test.err) {
+			t.Errorf("Complete(%q, %q): got error %v, want %v", test.isbn10, test.isbn13, err, test.err)
+			continue
+		}
+
+		if err == nil && (got10 != test.want10 || got13 != test.want13) {
+			t.Errorf("Complete(%q, %q) = %q, %q, want %q, %q",
+				test.isbn10, test.isbn13, got10, got13, test.want10, test.want13)
+		}
+
+	}
+
+}
+
+func TestTo13Any(t *testing.T) {
+
+	for _, isbn := range []string{"0-306-40615-2", "978-0-306-40615-7"} {
+
+		if got, err := To13Any(isbn); err != nil || got != "9780306406157" {
+			t.Errorf("To13Any(%q) = %q, %v, want 9780306406157", isbn, got, err)
+		}
+
+	}
+
+	if _, err := To13Any("123"); !errors.Is(err, ErrInvalid) {
+		t.Errorf("To13Any(\"123\"): got %v, want %v", err, ErrInvalid)
+	}
+
+}
@@ -1,28 +1,326 @@
 package models
 
 import (
+	"encoding/json"
 	"github.com/google/uuid"
 	"time"
 )
 
 type UserStruct struct {
-	ID               uuid.UUID `json:"id"`
-	Name             string    `json:"name" validate:"required"`
-	Password         string    `json:"pwd" validate:"required,min=8"`
-	Email            string    `json:"email" validate:"required,email"`
-	Age              int       `json:"age,omitempty" validate:"omitempty,gte=14"`
-	DateRegistration time.Time `json:"date_reg,omitempty"`
+	ID               uuid.UUID  `json:"id"`
+	Name             string     `json:"name" validate:"required"`
+	Password         string     `json:"pwd" validate:"required,min=8"`
+	Email            string     `json:"email" validate:"required,email"`
+	Age              int        `json:"age,omitempty" validate:"omitempty,gte=14"`
+	Role             string     `json:"role,omitempty" validate:"omitempty,oneof=patron librarian admin"`
+	DateRegistration time.Time  `json:"date_reg,omitempty"`
+	DeletedAt        *time.Time `json:"deleted_at,omitempty"`    // set while the account is deactivated
+	AnonymizedAt     *time.Time `json:"anonymized_at,omitempty"` // personal data erased, the account can not come back
+	Version          int        `json:"-"`                       // bumped on every update, sent as the ETag
 }
 
+// UserPatchStruct is a merge patch of a user, the nil fields are left as they are.
+type UserPatchStruct struct {
+	Name     *string `json:"name" validate:"omitempty,min=1"`
+	Password *string `json:"pwd" validate:"omitempty,min=8"`
+	Email    *string `json:"email" validate:"omitempty,email"`
+	Age      *int    `json:"age" validate:"omitempty,gte=14"`
+	Role     *string `json:"role" validate:"omitempty,oneof=patron librarian admin"`
+	Version  int     `json:"-"`
+}
+
+const (
+	RolePatron    = "patron"
+	RoleLibrarian = "librarian"
+	RoleAdmin     = "admin"
+)
+
 type UserLoginStruct struct {
 	Email    string `json:"email" validate:"required,email"`
 	Password string `json:"pwd" validate:"required,min=8"`
 }
 
+// BookStruct.Author is the display line of the book. When AuthorIDs are given it may be left
+// empty and is built from the author names.
 type BookStruct struct {
+	ID          uuid.UUID      `json:"id"`
+	Name        string         `json:"name" validate:"required"`
+	Description string         `json:"desc,omitempty"`
+	Author      string         `json:"author" validate:"required_without=AuthorIDs"`
+	ISBN10      string         `json:"isbn10,omitempty" validate:"omitempty,isbn_10"`
+	ISBN13      string         `json:"isbn13,omitempty" validate:"omitempty,isbn_13"`
+	AuthorIDs   []string       `json:"author_ids,omitempty" validate:"omitempty,dive,uuid"`
+	Authors     []AuthorStruct `json:"authors,omitempty"`
+	Genres      []GenreStruct  `json:"genres,omitempty"`
+	Tags        []string       `json:"tags,omitempty"`
+	DateWriting time.Time      `json:"date_wrt,omitempty"`
+	DeletedAt   *time.Time     `json:"deleted_at,omitempty"` // set while the book is in the trash
+	Version     int            `json:"-"`                    // bumped on every update, sent as the ETag
+}
+
+// BookPatchStruct is a merge patch of a book, the nil fields are left as they are.
+type BookPatchStruct struct {
+	Name        *string    `json:"name" validate:"omitempty,min=1"`
+	Description *string    `json:"desc"`
+	Author      *string    `json:"author" validate:"omitempty,min=1"`
+	ISBN10      *string    `json:"isbn10" validate:"omitempty,isbn_10"`
+	ISBN13      *string    `json:"isbn13" validate:"omitempty,isbn_13"`
+	AuthorIDs   []string   `json:"author_ids" validate:"omitempty,dive,uuid"`
+	DateWriting *time.Time `json:"date_wrt"`
+	Version     int        `json:"-"`
+}
+
+// BookRevisionStruct is a saved state of the book. Changes lists what differs from the previous revision.
+type BookRevisionStruct struct {
+	Rev         int                 `json:"rev"`
+	Book        BookStruct          `json:"book"`
+	DateCreated time.Time           `json:"date_created"`
+	Changes     []FieldChangeStruct `json:"changes,omitempty"`
+}
+
+type FieldChangeStruct struct {
+	Field string `json:"field"`
+	From  any    `json:"from"`
+	To    any    `json:"to"`
+}
+
+// GenreStruct is a node of the genre tree, top level genres have no ParentID.
+type GenreStruct struct {
+	ID       uuid.UUID  `json:"id"`
+	Name     string     `json:"name" validate:"required"`
+	ParentID *uuid.UUID `json:"parent_id,omitempty"`
+}
+
+type TagStruct struct {
+	Name  string `json:"name"`
+	Books int    `json:"books"`
+}
+
+const (
+	ImportCreated   = "created"
+	ImportDuplicate = "duplicate"
+	ImportInvalid   = "invalid"
+	ImportFailed    = "failed" // the import stopped at an error before the row was saved
+)
+
+// ImportRowStruct is one line of the import report. Book is the record being imported.
+type ImportRowStruct struct {
+	Row    int        `json:"row"`
+	Status string     `json:"status"`
+	ID     string     `json:"id,omitempty"`
+	Error  string     `json:"error,omitempty"`
+	Book   BookStruct `json:"-"`
+}
+
+type ImportReportStruct struct {
+	Created   int               `json:"created"`
+	Duplicate int               `json:"duplicate"`
+	Invalid   int               `json:"invalid"`
+	Failed    int               `json:"failed"`
+	Rows      []ImportRowStruct `json:"rows"`
+}
+
+type AuthorStruct struct {
+	ID        uuid.UUID  `json:"id"`
+	Name      string     `json:"name" validate:"required"`
+	DateBirth *time.Time `json:"date_birth,omitempty"`
+	DateDeath *time.Time `json:"date_death,omitempty"`
+	Bio       string     `json:"bio,omitempty"`
+}
+
+// ListParamsStruct is the common part of list queries: a page window, sort direction and a date range.
+// DateFrom and DateTo apply to the registration date of users and the writing date of books.
+type ListParamsStruct struct {
+	Limit    int       `form:"limit" validate:"gte=0,lte=100"`
+	Offset   int       `form:"offset" validate:"gte=0"`
+	Order    string    `form:"order" validate:"omitempty,oneof=asc desc"`
+	DateFrom time.Time `form:"from" time_format:"2006-01-02"`
+	DateTo   time.Time `form:"to" time_format:"2006-01-02"`
+}
+
+// PageStruct describes the returned page of a list.
+type PageStruct struct {
+	Total  int `json:"total"`
+	Limit  int `json:"limit"`
+	Offset int `json:"offset"`
+}
+
+// BookFilterStruct.Tags keeps books that have all the given tags,
+// Genre keeps books of the genre and of all its subgenres.
+type BookFilterStruct struct {
+	ListParamsStruct
+	Sort   string   `form:"sort" validate:"omitempty,oneof=name author date"`
+	Author string   `form:"author"`
+	Tags   []string `form:"tag" validate:"omitempty,dive,required,max=64"`
+	Genre  string   `form:"genre" validate:"omitempty,uuid"`
+}
+
+type BookSearchStruct struct {
+	Query  string `form:"q" validate:"required"`
+	Limit  int    `form:"limit" validate:"gte=0,lte=100"`
+	Offset int    `form:"offset" validate:"gte=0"`
+}
+
+// BookHitStruct is one search result. Snippet marks the matched words with <b></b>.
+type BookHitStruct struct {
+	Book    BookStruct `json:"book"`
+	Rank    float32    `json:"rank"`
+	Snippet string     `json:"snippet"`
+}
+
+type UserFilterStruct struct {
+	ListParamsStruct
+	Sort   string `form:"sort" validate:"omitempty,oneof=name email date"`
+	Role   string `form:"role" validate:"omitempty,oneof=patron librarian admin"`
+	Status string `form:"status" validate:"omitempty,oneof=active inactive all"`
+}
+
+const (
+	UserStatusActive   = "active"
+	UserStatusInactive = "inactive"
+	UserStatusAll      = "all"
+)
+
+type LoanStruct struct {
+	ID           uuid.UUID  `json:"id"`
+	UserID       uuid.UUID  `json:"user_id"`
+	BookID       uuid.UUID  `json:"book_id"`
+	CopyID       uuid.UUID  `json:"copy_id"`
+	DateCheckout time.Time  `json:"date_checkout"`
+	DateDue      time.Time  `json:"date_due"`
+	DateReturn   *time.Time `json:"date_return,omitempty"`
+	Renewals     int        `json:"renewals"`
+}
+
+type LoanCheckoutStruct struct {
+	UserID string `json:"user_id" validate:"required,uuid"`
+	BookID string `json:"book_id" validate:"required,uuid"`
+	CopyID string `json:"copy_id,omitempty" validate:"omitempty,uuid"`
+}
+
+type CopyStruct struct {
+	ID              uuid.UUID `json:"id"`
+	BookID          uuid.UUID `json:"book_id"`
+	Barcode         string    `json:"barcode" validate:"required"`
+	Condition       string    `json:"condition,omitempty" validate:"omitempty,oneof=new good fair poor damaged"`
+	Location        string    `json:"location,omitempty"`
+	DateAcquisition time.Time `json:"date_acq,omitempty"`
+	Available       bool      `json:"available"`
+}
+
+type AvailabilityStruct struct {
+	Total     int `json:"total"`
+	Available int `json:"available"`
+}
+
+const (
+	HoldWaiting   = "waiting"
+	HoldReady     = "ready"
+	HoldFulfilled = "fulfilled"
+	HoldCancelled = "cancelled"
+	HoldExpired   = "expired"
+)
+
+type HoldStruct struct {
+	ID         uuid.UUID  `json:"id"`
+	UserID     uuid.UUID  `json:"user_id"`
+	BookID     uuid.UUID  `json:"book_id"`
+	CopyID     *uuid.UUID `json:"copy_id,omitempty"`
+	Status     string     `json:"status"`
+	DatePlaced time.Time  `json:"date_placed"`
+	DateExpire *time.Time `json:"date_expire,omitempty"`
+}
+
+type HoldPlaceStruct struct {
+	UserID string `json:"user_id" validate:"required,uuid"`
+	BookID string `json:"book_id" validate:"required,uuid"`
+}
+
+const (
+	FineCharge  = "charge"
+	FinePayment = "payment"
+	FineWaiver  = "waiver"
+)
+
+type FineStruct struct {
+	ID          uuid.UUID  `json:"id"`
+	UserID      uuid.UUID  `json:"user_id"`
+	LoanID      *uuid.UUID `json:"loan_id,omitempty"`
+	Type        string     `json:"type"`
+	Amount      int64      `json:"amount"`
+	Note        string     `json:"note,omitempty"`
+	DateCreated time.Time  `json:"date_created"`
+}
+
+type FinePaymentStruct struct {
+	Amount int64  `json:"amount" validate:"required,gt=0"`
+	Note   string `json:"note,omitempty"`
+}
+
+type FineBalanceStruct struct {
+	Balance int64        `json:"balance"`
+	Entries []FineStruct `json:"entries"`
+}
+
+type SessionStruct struct {
 	ID          uuid.UUID `json:"id"`
-	Name        string    `json:"name" validate:"required"`
-	Description string    `json:"desc,omitempty"`
-	Author      string    `json:"author" validate:"required"`
-	DateWriting time.Time `json:"date_wrt,omitempty"`
+	UserID      uuid.UUID `json:"user_id"`
+	TokenHash   string    `json:"-"`
+	DateCreated time.Time `json:"date_created"`
+	DateExpire  time.Time `json:"date_expire"`
+	Revoked     bool      `json:"revoked"`
+}
+
+type RefreshStruct struct {
+	RefreshToken string `json:"refresh_token" validate:"required"`
+}
+
+// AuditStruct is one entry of the audit log. Before and After are JSON snapshots of the entity,
+// Before is empty for a creation and After for a deletion. Actor is empty for background jobs.
+type AuditStruct struct {
+	ID          uuid.UUID       `json:"id"`
+	Actor       string          `json:"actor,omitempty"`
+	Action      string          `json:"action"`
+	Entity      string          `json:"entity"`
+	EntityID    string          `json:"entity_id,omitempty"`
+	Before      json.RawMessage `json:"before,omitempty"`
+	After       json.RawMessage `json:"after,omitempty"`
+	DateCreated time.Time       `json:"date_created"`
+}
+
+type AuditFilterStruct struct {
+	ListParamsStruct
+	Actor    string `form:"actor" validate:"omitempty,uuid"`
+	Action   string `form:"action" validate:"omitempty,oneof=create edit delete restore purge reactivate anonymize"`
+	Entity   string `form:"entity" validate:"omitempty,oneof=book user"`
+	EntityID string `form:"entity_id" validate:"omitempty,uuid"`
+}
+
+const (
+	AuditCreate     = "create"
+	AuditEdit       = "edit"
+	AuditDelete     = "delete"
+	AuditRestore    = "restore"
+	AuditPurge      = "purge"
+	AuditReactivate = "reactivate"
+	AuditAnonymize  = "anonymize"
+
+	AuditEntityBook = "book"
+	AuditEntityUser = "user"
+)
+
+// PoolStatsStruct is a snapshot of the database connection pool. The counters grow from the start of the process.
+type PoolStatsStruct struct {
+	TotalConns              int32         `json:"total_conns"`
+	IdleConns               int32         `json:"idle_conns"`
+	AcquiredConns           int32         `json:"acquired_conns"`
+	ConstructingConns       int32         `json:"constructing_conns"`
+	MaxConns                int32         `json:"max_conns"`
+	AcquireCount            int64         `json:"acquire_count"`
+	AcquireDuration         time.Duration `json:"acquire_duration_ns"` // summed over all acquires
+	EmptyAcquireCount       int64         `json:"empty_acquire_count"` // acquires that had to wait for a connection
+	CanceledAcquireCount    int64         `json:"canceled_acquire_count"`
+	NewConnsCount           int64         `json:"new_conns_count"`
+	MaxLifetimeDestroyCount int64         `json:"max_lifetime_destroy_count"`
+	MaxIdleDestroyCount     int64         `json:"max_idle_destroy_count"`
 }
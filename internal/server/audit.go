@@ -0,0 +1,38 @@
+package server
+
+import (
+	"github.com/gin-gonic/gin"
+	"library/internal/domain/models"
+	"library/internal/logger"
+	"net/http"
+)
+
+func (s *ServerStruct) GetAuditHandler(ctx *gin.Context) {
+
+	log := logger.Get()
+
+	var filter models.AuditFilterStruct
+
+	if err := ctx.ShouldBindQuery(&filter); err != nil {
+		log.Error().Err(err).Msg("Bind query error")
+		problem(ctx, badRequest(err))
+		return
+	}
+
+	if err := s.valid.Struct(filter); err != nil {
+		log.Error().Err(err).Msg("Invalid query parameters")
+		problem(ctx, err)
+		return
+	}
+
+	entries, page, err := s.auService.GetAudit(filter)
+
+	if err != nil {
+		log.Error().Err(err).Msg("Get audit log failed")
+		problem(ctx, err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{"result": entries, "total": page.Total, "limit": page.Limit, "offset": page.Offset})
+
+}
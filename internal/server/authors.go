@@ -0,0 +1,141 @@
+package server
+
+import (
+	"fmt"
+	"github.com/gin-gonic/gin"
+	"library/internal/domain/models"
+	"library/internal/logger"
+	"net/http"
+)
+
+func (s *ServerStruct) GetAuthorsHandler(ctx *gin.Context) {
+
+	log := logger.Get()
+
+	authors, err := s.aService.GetAuthors()
+
+	if err != nil {
+		log.Error().Err(err).Msg("Get authors failed")
+		problem(ctx, err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{"result": authors})
+
+}
+
+func (s *ServerStruct) GetAuthorHandler(ctx *gin.Context) {
+
+	log := logger.Get()
+
+	id := ctx.Param("id")
+
+	if id == "" {
+		log.Error().Msg("Author ID is empty")
+		problem(ctx, invalidParam("Author ID is empty"))
+		return
+	}
+
+	author, err := s.aService.GetAuthor(id)
+
+	if err != nil {
+		log.Error().Err(err).Msg("Get author failed")
+		problem(ctx, err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{"result": author})
+
+}
+
+func (s *ServerStruct) AddAuthorHandler(ctx *gin.Context) {
+
+	log := logger.Get()
+
+	var author models.AuthorStruct
+
+	if err := ctx.ShouldBindBodyWithJSON(&author); err != nil {
+		log.Error().Err(err).Msg("Unmarshall body error")
+		problem(ctx, badRequest(err))
+		return
+	}
+
+	if err := s.valid.Struct(author); err != nil {
+		log.Error().Err(err).Msg("Invalid body structure")
+		problem(ctx, err)
+		return
+	}
+
+	id, err := s.aService.AddAuthor(author)
+
+	if err != nil {
+		log.Error().Err(err).Msg("Add author failed")
+		problem(ctx, err)
+		return
+	}
+
+	ctx.JSON(http.StatusCreated, gin.H{"result": fmt.Sprintf("Author added. ID - %s", id)})
+
+}
+
+func (s *ServerStruct) EditAuthorHandler(ctx *gin.Context) {
+
+	log := logger.Get()
+
+	id := ctx.Param("id")
+
+	if id == "" {
+		log.Error().Msg("Author ID is empty")
+		problem(ctx, invalidParam("Author ID is empty"))
+		return
+	}
+
+	var author models.AuthorStruct
+
+	if err := ctx.ShouldBindBodyWithJSON(&author); err != nil {
+		log.Error().Err(err).Msg("Unmarshall body error")
+		problem(ctx, badRequest(err))
+		return
+	}
+
+	if err := s.valid.Struct(author); err != nil {
+		log.Error().Err(err).Msg("Invalid body structure")
+		problem(ctx, err)
+		return
+	}
+
+	err := s.aService.EditAuthor(id, author)
+
+	if err != nil {
+		log.Error().Err(err).Msg("Edit author failed")
+		problem(ctx, err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{"result": "Author edited"})
+
+}
+
+func (s *ServerStruct) DeleteAuthorHandler(ctx *gin.Context) {
+
+	log := logger.Get()
+
+	id := ctx.Param("id")
+
+	if id == "" {
+		log.Error().Msg("Author ID is empty")
+		problem(ctx, invalidParam("Author ID is empty"))
+		return
+	}
+
+	err := s.aService.DeleteAuthor(id)
+
+	if err != nil {
+		log.Error().Err(err).Msg("Delete author failed")
+		problem(ctx, err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{"result": "Author removed"})
+
+}
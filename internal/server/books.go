@@ -2,29 +2,79 @@ package server
 
 import (
 	"context"
-	"errors"
 	"fmt"
 	"github.com/gin-gonic/gin"
+	"io"
+	"library/internal/catalog"
 	"library/internal/domain/models"
 	"library/internal/logger"
-	"library/internal/storage/storageerror"
 	"net/http"
+	"strings"
 	"time"
 )
 
+const (
+	maxImportSize = 64 << 20
+	purgeInterval = time.Hour
+)
+
 func (s *ServerStruct) GetBooksHandler(ctx *gin.Context) {
 
 	log := logger.Get()
 
-	books, err := s.bService.GetBooks()
+	var filter models.BookFilterStruct
+
+	if err := ctx.ShouldBindQuery(&filter); err != nil {
+		log.Error().Err(err).Msg("Bind query error")
+		problem(ctx, badRequest(err))
+		return
+	}
+
+	if err := s.valid.Struct(filter); err != nil {
+		log.Error().Err(err).Msg("Invalid query parameters")
+		problem(ctx, err)
+		return
+	}
+
+	books, page, err := s.bService.GetBooks(ctx.Request.Context(), filter)
 
 	if err != nil {
 		log.Error().Err(err).Msg("Get books failed")
-		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		problem(ctx, err)
 		return
 	}
 
-	ctx.JSON(http.StatusOK, gin.H{"result": books})
+	ctx.JSON(http.StatusOK, gin.H{"result": books, "total": page.Total, "limit": page.Limit, "offset": page.Offset})
+
+}
+
+func (s *ServerStruct) SearchBooksHandler(ctx *gin.Context) {
+
+	log := logger.Get()
+
+	var search models.BookSearchStruct
+
+	if err := ctx.ShouldBindQuery(&search); err != nil {
+		log.Error().Err(err).Msg("Bind query error")
+		problem(ctx, badRequest(err))
+		return
+	}
+
+	if err := s.valid.Struct(search); err != nil {
+		log.Error().Err(err).Msg("Invalid query parameters")
+		problem(ctx, err)
+		return
+	}
+
+	hits, page, err := s.bService.SearchBooks(ctx.Request.Context(), search)
+
+	if err != nil {
+		log.Error().Err(err).Msg("Search books failed")
+		problem(ctx, err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{"result": hits, "total": page.Total, "limit": page.Limit, "offset": page.Offset})
 
 }
 
@@ -36,15 +86,117 @@ func (s *ServerStruct) GetBookHandler(ctx *gin.Context) {
 
 	if id == "" {
 		log.Error().Msg("Book ID is empty")
-		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Book ID is empty"})
+		problem(ctx, invalidParam("Book ID is empty"))
 		return
 	}
 
-	book, err := s.bService.GetBook(id)
+	book, err := s.bService.GetBook(ctx.Request.Context(), id)
 
 	if err != nil {
 		log.Error().Err(err).Msg("Get book failed")
-		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		problem(ctx, err)
+		return
+	}
+
+	// The ETag versions the book record, genres, tags and availability are read fresh on every 200.
+	if notModified(ctx, book.Version) {
+		return
+	}
+
+	if book.Genres, err = s.gService.GetBookGenres(id); err != nil {
+		log.Error().Err(err).Msg("Get book genres failed")
+		problem(ctx, err)
+		return
+	}
+
+	if book.Tags, err = s.tService.GetBookTags(id); err != nil {
+		log.Error().Err(err).Msg("Get book tags failed")
+		problem(ctx, err)
+		return
+	}
+
+	availability, err := s.cService.GetBookAvailability(id)
+
+	if err != nil {
+		log.Error().Err(err).Msg("Get book availability failed")
+		problem(ctx, err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{"result": book, "availability": availability})
+
+}
+
+// ImportBooksHandler takes the file either as the "file" field of a multipart form or as the raw body.
+// The format comes from the "format" query parameter or from the file extension.
+func (s *ServerStruct) ImportBooksHandler(ctx *gin.Context) {
+
+	log := logger.Get()
+
+	format := ctx.Query("format")
+
+	ctx.Request.Body = http.MaxBytesReader(ctx.Writer, ctx.Request.Body, maxImportSize)
+
+	var body io.Reader = ctx.Request.Body
+
+	if strings.HasPrefix(ctx.ContentType(), "multipart/") {
+
+		file, header, err := ctx.Request.FormFile("file")
+
+		if err != nil {
+			log.Error().Err(err).Msg("Read import file failed")
+			problem(ctx, badRequest(err))
+			return
+		}
+
+		defer file.Close()
+
+		body = file
+
+		if format == "" {
+			format = catalog.DetectFormat(header.Filename)
+		}
+
+	}
+
+	records, err := catalog.Read(body, format)
+
+	if err != nil {
+		log.Error().Err(err).Msg("Read import file failed")
+		problem(ctx, badRequest(err))
+		return
+	}
+
+	report, err := s.bService.ImportBooks(ctx.Request.Context(), currentUserID(ctx), catalog.Rows(records, s.valid))
+
+	// The rows committed before the error stay saved, the report tells the client which ones they are.
+	if err != nil {
+		log.Error().Err(err).Msg("Import books failed")
+		partialProblem(ctx, err, report)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{"result": report})
+
+}
+
+func (s *ServerStruct) GetBookByISBNHandler(ctx *gin.Context) {
+
+	log := logger.Get()
+
+	number := ctx.Param("isbn")
+
+	if number == "" {
+		log.Error().Msg("ISBN is empty")
+		problem(ctx, invalidParam("ISBN is empty"))
+		return
+	}
+
+	book, err := s.bService.GetBookByISBN(ctx.Request.Context(), number)
+
+	if err != nil {
+		log.Error().Err(err).Msg("Get book by ISBN failed")
+		problem(ctx, err)
 		return
 	}
 
@@ -60,39 +212,78 @@ func (s *ServerStruct) AddBookHandler(ctx *gin.Context) {
 
 	if err := ctx.ShouldBindBodyWithJSON(&book); err != nil {
 		log.Error().Err(err).Msg("Unmarshall body error")
-		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		problem(ctx, badRequest(err))
 		return
 	}
 
 	if err := s.valid.Struct(book); err != nil {
 		log.Error().Err(err).Msg("Invalid body structure")
-		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		problem(ctx, err)
 		return
 	}
 
-	id, err := s.bService.AddBook(book)
+	id, err := s.bService.AddBook(ctx.Request.Context(), currentUserID(ctx), book)
 
 	if err != nil {
-
 		log.Error().Err(err).Msg("Save book failed")
+		problem(ctx, err)
+		return
+	}
 
-		status := http.StatusInternalServerError
+	ctx.JSON(http.StatusCreated, gin.H{"result": fmt.Sprintf("Book added. ID - %s", id)})
 
-		if errors.Is(err, storageerror.ErrBookAlreadyExist) {
-			status = http.StatusConflict
-		}
+}
 
-		ctx.JSON(status, gin.H{"error": err.Error()})
+func (s *ServerStruct) EditBookHandler(ctx *gin.Context) {
 
+	log := logger.Get()
+
+	id := ctx.Param("id")
+
+	if id == "" {
+		log.Error().Msg("Book ID is empty")
+		problem(ctx, invalidParam("Book ID is empty"))
 		return
+	}
+
+	version, err := ifMatchVersion(ctx)
 
+	if err != nil {
+		log.Error().Err(err).Msg("Invalid If-Match header")
+		problem(ctx, err)
+		return
 	}
 
-	ctx.JSON(http.StatusCreated, gin.H{"result": fmt.Sprintf("Book added. ID - %s", id)})
+	var book models.BookStruct
+
+	if err = ctx.ShouldBindBodyWithJSON(&book); err != nil {
+		log.Error().Err(err).Msg("Unmarshall body error")
+		problem(ctx, badRequest(err))
+		return
+	}
+
+	if err = s.valid.Struct(book); err != nil {
+		log.Error().Err(err).Msg("Invalid body structure")
+		problem(ctx, err)
+		return
+	}
+
+	book.Version = version
+
+	err = s.bService.EditBook(ctx.Request.Context(), currentUserID(ctx), id, book)
+
+	if err != nil {
+		log.Error().Err(err).Msg("Edit book failed")
+		problem(ctx, err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{"result": "Book edited"})
 
 }
 
-func (s *ServerStruct) EditBookHandler(ctx *gin.Context) {
+// PatchBookHandler applies a merge patch, see bindMergePatch. Like EditBookHandler it requires If-Match.
+func (s *ServerStruct) PatchBookHandler(ctx *gin.Context) {
 
 	log := logger.Get()
 
@@ -100,29 +291,47 @@ func (s *ServerStruct) EditBookHandler(ctx *gin.Context) {
 
 	if id == "" {
 		log.Error().Msg("Book ID is empty")
-		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Book ID is empty"})
+		problem(ctx, invalidParam("Book ID is empty"))
 		return
 	}
 
-	var book models.BookStruct
+	version, err := ifMatchVersion(ctx)
 
-	if err := ctx.ShouldBindBodyWithJSON(&book); err != nil {
+	if err != nil {
+		log.Error().Err(err).Msg("Invalid If-Match header")
+		problem(ctx, err)
+		return
+	}
+
+	var patch models.BookPatchStruct
+
+	removed, err := bindMergePatch(ctx, &patch)
+
+	if err != nil {
 		log.Error().Err(err).Msg("Unmarshall body error")
-		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		problem(ctx, err)
 		return
 	}
 
-	if err := s.valid.Struct(book); err != nil {
+	if err = s.valid.Struct(patch); err != nil {
 		log.Error().Err(err).Msg("Invalid body structure")
-		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		problem(ctx, err)
 		return
 	}
 
-	err := s.bService.EditBook(id, book)
+	if err = clearBookFields(&patch, removed); err != nil {
+		log.Error().Err(err).Msg("Invalid body structure")
+		problem(ctx, badRequest(err))
+		return
+	}
+
+	patch.Version = version
+
+	err = s.bService.PatchBook(ctx.Request.Context(), currentUserID(ctx), id, patch)
 
 	if err != nil {
-		log.Error().Err(err).Msg("Edit book failed")
-		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		log.Error().Err(err).Msg("Patch book failed")
+		problem(ctx, err)
 		return
 	}
 
@@ -130,6 +339,30 @@ func (s *ServerStruct) EditBookHandler(ctx *gin.Context) {
 
 }
 
+// clearBookFields applies the members a patch set to null. The name and the authors can not be removed.
+func clearBookFields(patch *models.BookPatchStruct, fields []string) error {
+
+	for _, field := range fields {
+
+		switch field {
+		case "desc":
+			patch.Description = new(string)
+		case "isbn10":
+			patch.ISBN10 = new(string)
+		case "isbn13":
+			patch.ISBN13 = new(string)
+		case "date_wrt":
+			patch.DateWriting = new(time.Time)
+		default:
+			return fmt.Errorf("%s can not be removed", field)
+		}
+
+	}
+
+	return nil
+
+}
+
 func (s *ServerStruct) DeleteBookHandler(ctx *gin.Context) {
 
 	log := logger.Get()
@@ -138,46 +371,95 @@ func (s *ServerStruct) DeleteBookHandler(ctx *gin.Context) {
 
 	if id == "" {
 		log.Error().Msg("Book ID is empty")
-		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Book ID is empty"})
+		problem(ctx, invalidParam("Book ID is empty"))
 		return
 	}
 
-	err := s.bService.DeleteBook(id)
+	err := s.bService.DeleteBook(ctx.Request.Context(), currentUserID(ctx), id)
 
 	if err != nil {
 		log.Error().Err(err).Msg("Delete book failed")
-		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		problem(ctx, err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{"result": "Book moved to trash"})
+
+}
+
+func (s *ServerStruct) GetDeletedBooksHandler(ctx *gin.Context) {
+
+	log := logger.Get()
+
+	var params models.ListParamsStruct
+
+	if err := ctx.ShouldBindQuery(&params); err != nil {
+		log.Error().Err(err).Msg("Bind query error")
+		problem(ctx, badRequest(err))
+		return
+	}
+
+	if err := s.valid.Struct(params); err != nil {
+		log.Error().Err(err).Msg("Invalid query parameters")
+		problem(ctx, err)
+		return
+	}
+
+	books, page, err := s.bService.GetDeletedBooks(ctx.Request.Context(), params)
+
+	if err != nil {
+		log.Error().Err(err).Msg("Get deleted books failed")
+		problem(ctx, err)
 		return
 	}
-	s.chanDel <- struct{}{}
-	ctx.JSON(http.StatusOK, gin.H{"result": "Book removed"})
+
+	ctx.JSON(http.StatusOK, gin.H{"result": books, "total": page.Total, "limit": page.Limit, "offset": page.Offset})
 
 }
 
-func (s *ServerStruct) deleter(ctx context.Context) {
+func (s *ServerStruct) RestoreBookHandler(ctx *gin.Context) {
+
 	log := logger.Get()
-	defer log.Debug().Msg("deleter stopped")
+
+	id := ctx.Param("id")
+
+	if id == "" {
+		log.Error().Msg("Book ID is empty")
+		problem(ctx, invalidParam("Book ID is empty"))
+		return
+	}
+
+	err := s.bService.RestoreBook(ctx.Request.Context(), currentUserID(ctx), id)
+
+	if err != nil {
+		log.Error().Err(err).Msg("Restore book failed")
+		problem(ctx, err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{"result": "Book restored"})
+
+}
+
+// purger removes the books whose retention period in the trash is over.
+func (s *ServerStruct) purger(ctx context.Context) {
+	log := logger.Get()
+	defer log.Debug().Msg("purger stopped")
 	for {
 		select {
 		case <-ctx.Done():
-			log.Debug().Msg("deleter. ctx.Done()")
+			log.Debug().Msg("purger. ctx.Done()")
 			close(s.ChanErr)
 			return
-		case <-time.After(5 * time.Minute):
-			//case <-time.After(5 * time.Second):
-			//log.Debug().Int("len", len(s.chanDel)).Int("cap", cap(s.chanDel)).Msg("deleter. channel check")
-			if len(s.chanDel) == cap(s.chanDel) {
-				log.Debug().Int("len", len(s.chanDel)).Int("cap", cap(s.chanDel)).Msg("deleter. channel full")
-				for i := 0; i < cap(s.chanDel); i++ {
-					<-s.chanDel
-				}
-				if err := s.bService.DeleteBooks(); err != nil {
-					log.Error().Err(err).Msg("Delete books failed")
-					s.ChanErr <- err
-					return
-				}
+		case <-time.After(purgeInterval):
+			purged, err := s.bService.PurgeBooks(ctx)
+			if err != nil {
+				log.Error().Err(err).Msg("Purge books failed")
+				continue
+			}
+			if purged > 0 {
+				log.Info().Int("books", purged).Msg("purged books from trash")
 			}
-
 		}
 	}
 }
@@ -0,0 +1,160 @@
+package server
+
+import (
+	"fmt"
+	"github.com/gin-gonic/gin"
+	"library/internal/domain/models"
+	"library/internal/logger"
+	"net/http"
+)
+
+func (s *ServerStruct) GetCopiesHandler(ctx *gin.Context) {
+
+	log := logger.Get()
+
+	id := ctx.Param("id")
+
+	if id == "" {
+		log.Error().Msg("Book ID is empty")
+		problem(ctx, invalidParam("Book ID is empty"))
+		return
+	}
+
+	copies, err := s.cService.GetCopies(id)
+
+	if err != nil {
+		log.Error().Err(err).Msg("Get copies failed")
+		problem(ctx, err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{"result": copies})
+
+}
+
+func (s *ServerStruct) GetCopyHandler(ctx *gin.Context) {
+
+	log := logger.Get()
+
+	id := ctx.Param("id")
+	copyID := ctx.Param("copyID")
+
+	if id == "" || copyID == "" {
+		log.Error().Msg("Book or copy ID is empty")
+		problem(ctx, invalidParam("Book or copy ID is empty"))
+		return
+	}
+
+	cp, err := s.cService.GetCopy(id, copyID)
+
+	if err != nil {
+		log.Error().Err(err).Msg("Get copy failed")
+		problem(ctx, err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{"result": cp})
+
+}
+
+func (s *ServerStruct) AddCopyHandler(ctx *gin.Context) {
+
+	log := logger.Get()
+
+	id := ctx.Param("id")
+
+	if id == "" {
+		log.Error().Msg("Book ID is empty")
+		problem(ctx, invalidParam("Book ID is empty"))
+		return
+	}
+
+	var cp models.CopyStruct
+
+	if err := ctx.ShouldBindBodyWithJSON(&cp); err != nil {
+		log.Error().Err(err).Msg("Unmarshall body error")
+		problem(ctx, badRequest(err))
+		return
+	}
+
+	if err := s.valid.Struct(cp); err != nil {
+		log.Error().Err(err).Msg("Invalid body structure")
+		problem(ctx, err)
+		return
+	}
+
+	copyID, err := s.cService.AddCopy(id, cp)
+
+	if err != nil {
+		log.Error().Err(err).Msg("Add copy failed")
+		problem(ctx, err)
+		return
+	}
+
+	ctx.JSON(http.StatusCreated, gin.H{"result": fmt.Sprintf("Copy added. ID - %s", copyID)})
+
+}
+
+func (s *ServerStruct) EditCopyHandler(ctx *gin.Context) {
+
+	log := logger.Get()
+
+	id := ctx.Param("id")
+	copyID := ctx.Param("copyID")
+
+	if id == "" || copyID == "" {
+		log.Error().Msg("Book or copy ID is empty")
+		problem(ctx, invalidParam("Book or copy ID is empty"))
+		return
+	}
+
+	var cp models.CopyStruct
+
+	if err := ctx.ShouldBindBodyWithJSON(&cp); err != nil {
+		log.Error().Err(err).Msg("Unmarshall body error")
+		problem(ctx, badRequest(err))
+		return
+	}
+
+	if err := s.valid.Struct(cp); err != nil {
+		log.Error().Err(err).Msg("Invalid body structure")
+		problem(ctx, err)
+		return
+	}
+
+	err := s.cService.EditCopy(id, copyID, cp)
+
+	if err != nil {
+		log.Error().Err(err).Msg("Edit copy failed")
+		problem(ctx, err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{"result": "Copy edited"})
+
+}
+
+func (s *ServerStruct) DeleteCopyHandler(ctx *gin.Context) {
+
+	log := logger.Get()
+
+	id := ctx.Param("id")
+	copyID := ctx.Param("copyID")
+
+	if id == "" || copyID == "" {
+		log.Error().Msg("Book or copy ID is empty")
+		problem(ctx, invalidParam("Book or copy ID is empty"))
+		return
+	}
+
+	err := s.cService.DeleteCopy(id, copyID)
+
+	if err != nil {
+		log.Error().Err(err).Msg("Delete copy failed")
+		problem(ctx, err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{"result": "Copy removed"})
+
+}
@@ -0,0 +1,71 @@
+package server
+
+import (
+	"errors"
+	"github.com/gin-gonic/gin"
+	"library/internal/storage/storageerror"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+var errIfMatchRequired = requestError{
+	status: http.StatusPreconditionRequired,
+	code:   "precondition_required",
+	err:    errors.New("If-Match header is required"),
+}
+
+// etag formats the version of a record as a strong entity tag.
+func etag(version int) string {
+	return `"` + strconv.Itoa(version) + `"`
+}
+
+// notModified sets the ETag of the record and, when If-None-Match already names it, answers 304.
+// The caller must not write anything else once it returns true.
+func notModified(ctx *gin.Context, version int) bool {
+
+	tag := etag(version)
+
+	ctx.Header("ETag", tag)
+
+	for _, candidate := range strings.Split(ctx.GetHeader("If-None-Match"), ",") {
+
+		candidate = strings.TrimPrefix(strings.TrimSpace(candidate), "W/")
+
+		if candidate == tag || candidate == "*" {
+			ctx.Status(http.StatusNotModified)
+			return true
+		}
+
+	}
+
+	return false
+
+}
+
+// ifMatchVersion returns the version the client has edited, taken from If-Match. "*" edits whatever
+// is stored and gives 0. A tag that is not one of ours can never match, so it is a conflict.
+func ifMatchVersion(ctx *gin.Context) (int, error) {
+
+	header := strings.TrimSpace(ctx.GetHeader("If-Match"))
+
+	switch header {
+	case "":
+		return 0, errIfMatchRequired
+	case "*":
+		return 0, nil
+	}
+
+	if len(header) < 2 || header[0] != '"' || header[len(header)-1] != '"' {
+		return 0, storageerror.ErrVersionConflict
+	}
+
+	version, err := strconv.Atoi(header[1 : len(header)-1])
+
+	if err != nil || version < 1 {
+		return 0, storageerror.ErrVersionConflict
+	}
+
+	return version, nil
+
+}
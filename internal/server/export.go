@@ -0,0 +1,78 @@
+package server
+
+import (
+	"fmt"
+	"github.com/gin-gonic/gin"
+	"library/internal/catalog"
+	"library/internal/logger"
+	"net/http"
+	"time"
+)
+
+// ExportBooksHandler streams the catalogue in the format given by the "format" query parameter, CSV by default.
+// Once the first record is written the status is already sent, so a later failure only cuts the response short.
+func (s *ServerStruct) ExportBooksHandler(ctx *gin.Context) {
+
+	log := logger.Get()
+
+	format := ctx.DefaultQuery("format", catalog.FormatCSV)
+
+	writer, err := catalog.NewBookWriter(ctx.Writer, format)
+
+	if err != nil {
+		log.Error().Err(err).Msg("Create export writer failed")
+		problem(ctx, err)
+		return
+	}
+
+	startExport(ctx, "books", format)
+
+	if err = s.bService.ExportBooks(ctx.Request.Context(), writer.Write); err == nil {
+		err = writer.Close()
+	}
+
+	if err != nil {
+		log.Error().Err(err).Msg("Export books failed")
+		ctx.Abort()
+	}
+
+}
+
+// ExportUsersHandler streams users as CSV or JSON Lines. Password hashes are not exported.
+func (s *ServerStruct) ExportUsersHandler(ctx *gin.Context) {
+
+	log := logger.Get()
+
+	format := ctx.DefaultQuery("format", catalog.FormatCSV)
+
+	writer, err := catalog.NewUserWriter(ctx.Writer, format)
+
+	if err != nil {
+		log.Error().Err(err).Msg("Create export writer failed")
+		problem(ctx, err)
+		return
+	}
+
+	startExport(ctx, "users", format)
+
+	if err = s.uService.ExportUsers(ctx.Request.Context(), writer.Write); err == nil {
+		err = writer.Close()
+	}
+
+	if err != nil {
+		log.Error().Err(err).Msg("Export users failed")
+		ctx.Abort()
+	}
+
+}
+
+// startExport sends the headers of a file download named after the entity and today's date.
+func startExport(ctx *gin.Context, entity string, format string) {
+
+	filename := fmt.Sprintf("%s-%s.%s", entity, time.Now().Format("20060102"), catalog.FileExtension(format))
+
+	ctx.Header("Content-Type", catalog.ContentType(format))
+	ctx.Header("Content-Disposition", fmt.Sprintf("attachment; filename=%q", filename))
+	ctx.Status(http.StatusOK)
+
+}
@@ -0,0 +1,160 @@
+package server
+
+import (
+	"github.com/gin-gonic/gin"
+	"io"
+	"library/internal/catalog"
+	"library/internal/config"
+	"library/internal/logger"
+	"library/internal/server/utils"
+	"library/internal/service"
+	"library/internal/storage"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+// newTestServer runs the router on MapStorage behind a real HTTP server and returns it with
+// the access token of an admin.
+func newTestServer(t *testing.T) (*httptest.Server, string) {
+
+	t.Helper()
+
+	gin.SetMode(gin.TestMode)
+	logger.Get(false)
+
+	m := storage.NewMapStorage()
+	cfg := config.ConfigStruct{AccessTTL: time.Minute, JWTKeyID: "test"}
+
+	keys, err := util.NewKeySet(cfg)
+
+	if err != nil {
+		t.Fatalf("NewKeySet: %v", err)
+	}
+
+	audit := service.NewAuditService(m)
+	authors := service.NewAuthorService(m)
+	holds := service.NewHoldService(m, time.Hour)
+	fines := service.NewFineService(m, 0, 0)
+
+	s := New(cfg, keys,
+		service.NewUserService(m, "admin@example.com", audit),
+		service.NewBookService(m, authors, 10, 0, audit),
+		service.NewLoanService(m, holds, fines, 14*24*time.Hour, 2),
+		service.NewCopyService(m, holds),
+		holds,
+		fines,
+		service.NewSessionService(m, time.Hour),
+		authors,
+		service.NewGenreService(m),
+		service.NewTagService(m),
+		audit,
+		service.NewStatsService(m))
+
+	ts := httptest.NewServer(s.configRouting())
+
+	t.Cleanup(ts.Close)
+
+	resp := send(t, ts, "", http.MethodPost, "/users/registration",
+		`{"name":"Admin","email":"admin@example.com","pwd":"password1","age":30}`)
+
+	token := resp.Header.Get("Authorization")
+
+	if resp.StatusCode != http.StatusOK || token == "" {
+		t.Fatalf("registration: got %d and token %q", resp.StatusCode, token)
+	}
+
+	return ts, token
+
+}
+
+func send(t *testing.T, ts *httptest.Server, token string, method string, path string, body string) *http.Response {
+
+	t.Helper()
+
+	req, err := http.NewRequest(method, ts.URL+path, strings.NewReader(body))
+
+	if err != nil {
+		t.Fatalf("NewRequest: %v", err)
+	}
+
+	req.Header.Set("Authorization", token)
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := ts.Client().Do(req)
+
+	if err != nil {
+		t.Fatalf("%s %s: %v", method, path, err)
+	}
+
+	t.Cleanup(func() { _ = resp.Body.Close() })
+
+	return resp
+
+}
+
+// TestExportHeaders goes through a real server, so the headers are checked as the client gets them,
+// after the writer has put the first bytes on the wire.
+func TestExportHeaders(t *testing.T) {
+
+	ts, token := newTestServer(t)
+
+	if resp := send(t, ts, token, http.MethodPost, "/books/", `{"name":"Name","author":"Author"}`); resp.StatusCode != http.StatusCreated {
+		t.Fatalf("add book: got %d", resp.StatusCode)
+	}
+
+	tests := []struct {
+		entity string
+		format string
+	}{
+		{"books", catalog.FormatCSV},
+		{"books", catalog.FormatJSONL},
+		{"books", catalog.FormatMARCXML},
+		{"users", catalog.FormatCSV},
+		{"users", catalog.FormatJSONL},
+	}
+
+	for _, test := range tests {
+
+		t.Run(test.entity+"/"+test.format, func(t *testing.T) {
+
+			resp := send(t, ts, token, http.MethodGet, "/"+test.entity+"/export?format="+test.format, "")
+
+			if resp.StatusCode != http.StatusOK {
+				t.Fatalf("got status %d, want %d", resp.StatusCode, http.StatusOK)
+			}
+
+			if got, want := resp.Header.Get("Content-Type"), catalog.ContentType(test.format); got != want {
+				t.Errorf("got Content-Type %q, want %q", got, want)
+			}
+
+			disposition := resp.Header.Get("Content-Disposition")
+			prefix := `attachment; filename="` + test.entity + "-"
+			suffix := "." + catalog.FileExtension(test.format) + `"`
+
+			if !strings.HasPrefix(disposition, prefix) || !strings.HasSuffix(disposition, suffix) {
+				t.Errorf("got Content-Disposition %q, want %s...%s", disposition, prefix, suffix)
+			}
+
+			if body, err := io.ReadAll(resp.Body); err != nil || len(body) == 0 {
+				t.Errorf("got body %q, %v", body, err)
+			}
+
+		})
+
+	}
+
+	t.Run("unknown format", func(t *testing.T) {
+
+		resp := send(t, ts, token, http.MethodGet, "/books/export?format=pdf", "")
+
+		if resp.StatusCode != http.StatusBadRequest || resp.Header.Get("Content-Disposition") != "" {
+			t.Errorf("got status %d and Content-Disposition %q, want %d and none",
+				resp.StatusCode, resp.Header.Get("Content-Disposition"), http.StatusBadRequest)
+		}
+
+	})
+
+}
@@ -0,0 +1,81 @@
+package server
+
+import (
+	"fmt"
+	"github.com/gin-gonic/gin"
+	"library/internal/domain/models"
+	"library/internal/logger"
+	"net/http"
+)
+
+func (s *ServerStruct) GetUserFinesHandler(ctx *gin.Context) {
+
+	log := logger.Get()
+
+	id := ctx.Param("id")
+
+	if id == "" {
+		log.Error().Msg("User ID is empty")
+		problem(ctx, invalidParam("User ID is empty"))
+		return
+	}
+
+	balance, err := s.fService.GetBalance(id)
+
+	if err != nil {
+		log.Error().Err(err).Msg("Get user fines failed")
+		problem(ctx, err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{"result": balance})
+
+}
+
+func (s *ServerStruct) PayFineHandler(ctx *gin.Context) {
+	s.settleFine(ctx, s.fService.Pay, "Payment")
+}
+
+func (s *ServerStruct) WaiveFineHandler(ctx *gin.Context) {
+	s.settleFine(ctx, s.fService.Waive, "Waiver")
+}
+
+func (s *ServerStruct) settleFine(ctx *gin.Context,
+	settle func(string, models.FinePaymentStruct) (string, error),
+	kind string) {
+
+	log := logger.Get()
+
+	id := ctx.Param("id")
+
+	if id == "" {
+		log.Error().Msg("User ID is empty")
+		problem(ctx, invalidParam("User ID is empty"))
+		return
+	}
+
+	var payment models.FinePaymentStruct
+
+	if err := ctx.ShouldBindBodyWithJSON(&payment); err != nil {
+		log.Error().Err(err).Msg("Unmarshall body error")
+		problem(ctx, badRequest(err))
+		return
+	}
+
+	if err := s.valid.Struct(payment); err != nil {
+		log.Error().Err(err).Msg("Invalid body structure")
+		problem(ctx, err)
+		return
+	}
+
+	fineID, err := settle(id, payment)
+
+	if err != nil {
+		log.Error().Err(err).Str("kind", kind).Msg("Settle fine failed")
+		problem(ctx, err)
+		return
+	}
+
+	ctx.JSON(http.StatusCreated, gin.H{"result": fmt.Sprintf("%s recorded. ID - %s", kind, fineID)})
+
+}
@@ -0,0 +1,183 @@
+package server
+
+import (
+	"fmt"
+	"github.com/gin-gonic/gin"
+	"library/internal/domain/models"
+	"library/internal/logger"
+	"net/http"
+)
+
+func (s *ServerStruct) GetGenresHandler(ctx *gin.Context) {
+
+	log := logger.Get()
+
+	genres, err := s.gService.GetGenres()
+
+	if err != nil {
+		log.Error().Err(err).Msg("Get genres failed")
+		problem(ctx, err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{"result": genres})
+
+}
+
+func (s *ServerStruct) GetGenreHandler(ctx *gin.Context) {
+
+	log := logger.Get()
+
+	id := ctx.Param("id")
+
+	if id == "" {
+		log.Error().Msg("Genre ID is empty")
+		problem(ctx, invalidParam("Genre ID is empty"))
+		return
+	}
+
+	genre, err := s.gService.GetGenre(id)
+
+	if err != nil {
+		log.Error().Err(err).Msg("Get genre failed")
+		problem(ctx, err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{"result": genre})
+
+}
+
+func (s *ServerStruct) AddGenreHandler(ctx *gin.Context) {
+
+	log := logger.Get()
+
+	var genre models.GenreStruct
+
+	if err := ctx.ShouldBindBodyWithJSON(&genre); err != nil {
+		log.Error().Err(err).Msg("Unmarshall body error")
+		problem(ctx, badRequest(err))
+		return
+	}
+
+	if err := s.valid.Struct(genre); err != nil {
+		log.Error().Err(err).Msg("Invalid body structure")
+		problem(ctx, err)
+		return
+	}
+
+	id, err := s.gService.AddGenre(genre)
+
+	if err != nil {
+		log.Error().Err(err).Msg("Add genre failed")
+		problem(ctx, err)
+		return
+	}
+
+	ctx.JSON(http.StatusCreated, gin.H{"result": fmt.Sprintf("Genre added. ID - %s", id)})
+
+}
+
+func (s *ServerStruct) EditGenreHandler(ctx *gin.Context) {
+
+	log := logger.Get()
+
+	id := ctx.Param("id")
+
+	if id == "" {
+		log.Error().Msg("Genre ID is empty")
+		problem(ctx, invalidParam("Genre ID is empty"))
+		return
+	}
+
+	var genre models.GenreStruct
+
+	if err := ctx.ShouldBindBodyWithJSON(&genre); err != nil {
+		log.Error().Err(err).Msg("Unmarshall body error")
+		problem(ctx, badRequest(err))
+		return
+	}
+
+	if err := s.valid.Struct(genre); err != nil {
+		log.Error().Err(err).Msg("Invalid body structure")
+		problem(ctx, err)
+		return
+	}
+
+	if err := s.gService.EditGenre(id, genre); err != nil {
+		log.Error().Err(err).Msg("Edit genre failed")
+		problem(ctx, err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{"result": "Genre edited"})
+
+}
+
+func (s *ServerStruct) DeleteGenreHandler(ctx *gin.Context) {
+
+	log := logger.Get()
+
+	id := ctx.Param("id")
+
+	if id == "" {
+		log.Error().Msg("Genre ID is empty")
+		problem(ctx, invalidParam("Genre ID is empty"))
+		return
+	}
+
+	if err := s.gService.DeleteGenre(id); err != nil {
+		log.Error().Err(err).Msg("Delete genre failed")
+		problem(ctx, err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{"result": "Genre removed"})
+
+}
+
+func (s *ServerStruct) AssignGenreHandler(ctx *gin.Context) {
+
+	log := logger.Get()
+
+	id := ctx.Param("id")
+	genreID := ctx.Param("genreID")
+
+	if id == "" || genreID == "" {
+		log.Error().Msg("Book or genre ID is empty")
+		problem(ctx, invalidParam("Book or genre ID is empty"))
+		return
+	}
+
+	if err := s.gService.AssignGenre(id, genreID); err != nil {
+		log.Error().Err(err).Msg("Assign genre failed")
+		problem(ctx, err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{"result": "Genre assigned"})
+
+}
+
+func (s *ServerStruct) UnassignGenreHandler(ctx *gin.Context) {
+
+	log := logger.Get()
+
+	id := ctx.Param("id")
+	genreID := ctx.Param("genreID")
+
+	if id == "" || genreID == "" {
+		log.Error().Msg("Book or genre ID is empty")
+		problem(ctx, invalidParam("Book or genre ID is empty"))
+		return
+	}
+
+	if err := s.gService.UnassignGenre(id, genreID); err != nil {
+		log.Error().Err(err).Msg("Unassign genre failed")
+		problem(ctx, err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{"result": "Genre unassigned"})
+
+}
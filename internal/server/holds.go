@@ -0,0 +1,146 @@
+package server
+
+import (
+	"context"
+	"fmt"
+	"github.com/gin-gonic/gin"
+	"library/internal/domain/models"
+	"library/internal/logger"
+	"net/http"
+	"time"
+)
+
+func (s *ServerStruct) PlaceHoldHandler(ctx *gin.Context) {
+
+	log := logger.Get()
+
+	var hold models.HoldPlaceStruct
+
+	if err := ctx.ShouldBindBodyWithJSON(&hold); err != nil {
+		log.Error().Err(err).Msg("Unmarshall body error")
+		problem(ctx, badRequest(err))
+		return
+	}
+
+	if err := s.valid.Struct(hold); err != nil {
+		log.Error().Err(err).Msg("Invalid body structure")
+		problem(ctx, err)
+		return
+	}
+
+	if !hasRole(tokenClaims(ctx), models.RoleLibrarian, models.RoleAdmin) && !isOwner(ctx, hold.UserID) {
+		log.Error().Msg("Hold for another user")
+		problem(ctx, forbidden("access denied"))
+		return
+	}
+
+	id, err := s.hService.PlaceHold(hold)
+
+	if err != nil {
+		log.Error().Err(err).Msg("Place hold failed")
+		problem(ctx, err)
+		return
+	}
+
+	ctx.JSON(http.StatusCreated, gin.H{"result": fmt.Sprintf("Hold placed. ID - %s", id)})
+
+}
+
+func (s *ServerStruct) CancelHoldHandler(ctx *gin.Context) {
+
+	log := logger.Get()
+
+	id := ctx.Param("id")
+
+	if id == "" {
+		log.Error().Msg("Hold ID is empty")
+		problem(ctx, invalidParam("Hold ID is empty"))
+		return
+	}
+
+	hold, err := s.hService.GetHold(id)
+
+	if err == nil {
+
+		if !hasRole(tokenClaims(ctx), models.RoleLibrarian, models.RoleAdmin) && !isOwner(ctx, hold.UserID.String()) {
+			log.Error().Msg("Cancel hold of another user")
+			problem(ctx, forbidden("access denied"))
+			return
+		}
+
+		err = s.hService.CancelHold(id)
+
+	}
+
+	if err != nil {
+		log.Error().Err(err).Msg("Cancel hold failed")
+		problem(ctx, err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{"result": "Hold cancelled"})
+
+}
+
+func (s *ServerStruct) GetUserHoldsHandler(ctx *gin.Context) {
+
+	log := logger.Get()
+
+	id := ctx.Param("id")
+
+	if id == "" {
+		log.Error().Msg("User ID is empty")
+		problem(ctx, invalidParam("User ID is empty"))
+		return
+	}
+
+	holds, err := s.hService.GetUserHolds(id)
+
+	if err != nil {
+		log.Error().Err(err).Msg("Get user holds failed")
+		problem(ctx, err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{"result": holds})
+
+}
+
+func (s *ServerStruct) GetBookHoldsHandler(ctx *gin.Context) {
+
+	log := logger.Get()
+
+	id := ctx.Param("id")
+
+	if id == "" {
+		log.Error().Msg("Book ID is empty")
+		problem(ctx, invalidParam("Book ID is empty"))
+		return
+	}
+
+	holds, err := s.hService.GetBookHolds(id)
+
+	if err != nil {
+		log.Error().Err(err).Msg("Get book holds failed")
+		problem(ctx, err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{"result": holds})
+
+}
+
+func (s *ServerStruct) holdExpirer(ctx context.Context) {
+	log := logger.Get()
+	defer log.Debug().Msg("hold expirer stopped")
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(time.Minute):
+			if err := s.hService.ExpireHolds(); err != nil {
+				log.Error().Err(err).Msg("Expire holds failed")
+			}
+		}
+	}
+}
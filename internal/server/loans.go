@@ -0,0 +1,151 @@
+package server
+
+import (
+	"fmt"
+	"github.com/gin-gonic/gin"
+	"library/internal/domain/models"
+	"library/internal/logger"
+	"net/http"
+)
+
+func (s *ServerStruct) CheckoutBookHandler(ctx *gin.Context) {
+
+	log := logger.Get()
+
+	var loan models.LoanCheckoutStruct
+
+	if err := ctx.ShouldBindBodyWithJSON(&loan); err != nil {
+		log.Error().Err(err).Msg("Unmarshall body error")
+		problem(ctx, badRequest(err))
+		return
+	}
+
+	if err := s.valid.Struct(loan); err != nil {
+		log.Error().Err(err).Msg("Invalid body structure")
+		problem(ctx, err)
+		return
+	}
+
+	id, err := s.lService.CheckoutBook(loan)
+
+	if err != nil {
+		log.Error().Err(err).Msg("Checkout book failed")
+		problem(ctx, err)
+		return
+	}
+
+	ctx.JSON(http.StatusCreated, gin.H{"result": fmt.Sprintf("Book checked out. Loan ID - %s", id)})
+
+}
+
+func (s *ServerStruct) ReturnBookHandler(ctx *gin.Context) {
+
+	log := logger.Get()
+
+	id := ctx.Param("id")
+
+	if id == "" {
+		log.Error().Msg("Loan ID is empty")
+		problem(ctx, invalidParam("Loan ID is empty"))
+		return
+	}
+
+	err := s.lService.ReturnBook(id)
+
+	if err != nil {
+		log.Error().Err(err).Msg("Return book failed")
+		problem(ctx, err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{"result": "Book returned"})
+
+}
+
+func (s *ServerStruct) GetUserLoansHandler(ctx *gin.Context) {
+
+	log := logger.Get()
+
+	id := ctx.Param("id")
+
+	if id == "" {
+		log.Error().Msg("User ID is empty")
+		problem(ctx, invalidParam("User ID is empty"))
+		return
+	}
+
+	loans, err := s.lService.GetUserLoans(id)
+
+	if err != nil {
+		log.Error().Err(err).Msg("Get user loans failed")
+		problem(ctx, err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{"result": loans})
+
+}
+
+func (s *ServerStruct) GetBookLoansHandler(ctx *gin.Context) {
+
+	log := logger.Get()
+
+	id := ctx.Param("id")
+
+	if id == "" {
+		log.Error().Msg("Book ID is empty")
+		problem(ctx, invalidParam("Book ID is empty"))
+		return
+	}
+
+	loans, err := s.lService.GetBookLoans(id)
+
+	if err != nil {
+		log.Error().Err(err).Msg("Get book loans failed")
+		problem(ctx, err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{"result": loans})
+
+}
+
+func (s *ServerStruct) RenewLoanHandler(ctx *gin.Context) {
+
+	log := logger.Get()
+
+	id := ctx.Param("id")
+
+	if id == "" {
+		log.Error().Msg("Loan ID is empty")
+		problem(ctx, invalidParam("Loan ID is empty"))
+		return
+	}
+
+	loan, err := s.lService.RenewLoan(id)
+
+	if err != nil {
+		log.Error().Err(err).Msg("Renew loan failed")
+		problem(ctx, err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{"result": loan})
+
+}
+
+func (s *ServerStruct) GetOverdueLoansHandler(ctx *gin.Context) {
+
+	log := logger.Get()
+
+	loans, err := s.lService.GetOverdueLoans()
+
+	if err != nil {
+		log.Error().Err(err).Msg("Get overdue loans failed")
+		problem(ctx, err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{"result": loans})
+
+}
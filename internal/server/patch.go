@@ -0,0 +1,63 @@
+package server
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"github.com/gin-gonic/gin"
+	"io"
+	"net/http"
+	"slices"
+)
+
+const mergePatchType = "application/merge-patch+json"
+
+var errPatchType = requestError{
+	status: http.StatusUnsupportedMediaType,
+	code:   "unsupported_media_type",
+	err:    errors.New("patch must be sent as " + mergePatchType),
+}
+
+// bindMergePatch decodes an RFC 7396 merge patch into patch, whose nil fields stay unchanged. encoding/json
+// can not tell a null from a missing member, so the members set to null are returned for the caller to clear.
+// Plain application/json is taken as a merge patch too.
+func bindMergePatch(ctx *gin.Context, patch any) ([]string, error) {
+
+	if ct := ctx.ContentType(); ct != mergePatchType && ct != gin.MIMEJSON {
+		return nil, errPatchType
+	}
+
+	body, err := io.ReadAll(ctx.Request.Body)
+
+	if err != nil {
+		return nil, badRequest(err)
+	}
+
+	var members map[string]json.RawMessage
+
+	if err = json.Unmarshal(body, &members); err != nil {
+		return nil, badRequest(err)
+	}
+
+	decoder := json.NewDecoder(bytes.NewReader(body))
+	decoder.DisallowUnknownFields()
+
+	if err = decoder.Decode(patch); err != nil {
+		return nil, badRequest(err)
+	}
+
+	var removed []string
+
+	for name, value := range members {
+
+		if string(bytes.TrimSpace(value)) == "null" {
+			removed = append(removed, name)
+		}
+
+	}
+
+	slices.Sort(removed)
+
+	return removed, nil
+
+}
@@ -0,0 +1,183 @@
+package server
+
+import (
+	"errors"
+	"github.com/gin-gonic/gin"
+	"github.com/go-playground/validator"
+	"library/internal/catalog"
+	"library/internal/domain/isbn"
+	"library/internal/storage/storageerror"
+	"net/http"
+)
+
+const problemContentType = "application/problem+json"
+
+// problemStruct is an RFC 7807 problem document. Code is stable for clients to switch on, Detail is for people.
+type problemStruct struct {
+	Type     string             `json:"type"`
+	Title    string             `json:"title"`
+	Status   int                `json:"status"`
+	Code     string             `json:"code"`
+	Detail   string             `json:"detail,omitempty"`
+	Instance string             `json:"instance,omitempty"`
+	Errors   []fieldErrorStruct `json:"errors,omitempty"`
+	Result   any                `json:"result,omitempty"` // what a request that failed halfway had done
+}
+
+// fieldErrorStruct is one failed validation rule, Field is the name the client sent.
+type fieldErrorStruct struct {
+	Field string `json:"field"`
+	Rule  string `json:"rule"`
+	Param string `json:"param,omitempty"`
+}
+
+// requestError is an error found by the handler itself, it carries its own status and code.
+type requestError struct {
+	status int
+	code   string
+	err    error
+}
+
+func (e requestError) Error() string {
+	return e.err.Error()
+}
+
+func (e requestError) Unwrap() error {
+	return e.err
+}
+
+// badRequest marks a request that can not be read, a body or query that does not bind.
+func badRequest(err error) error {
+	return requestError{status: http.StatusBadRequest, code: "invalid_request", err: err}
+}
+
+// invalidParam marks a missing or malformed path parameter.
+func invalidParam(detail string) error {
+	return requestError{status: http.StatusBadRequest, code: "invalid_parameter", err: errors.New(detail)}
+}
+
+func unauthenticated(err error) error {
+	return requestError{status: http.StatusUnauthorized, code: "unauthenticated", err: err}
+}
+
+func forbidden(detail string) error {
+	return requestError{status: http.StatusForbidden, code: "access_denied", err: errors.New(detail)}
+}
+
+// problemCodes translates the errors of the services and the storages. It is searched in order with errors.Is.
+var problemCodes = []struct {
+	err    error
+	status int
+	code   string
+}{
+	{storageerror.ErrBookAlreadyExist, http.StatusConflict, "book_already_exists"},
+	{storageerror.ErrBookStorageEmpty, http.StatusNotFound, "book_storage_empty"},
+	{storageerror.ErrBookNotFound, http.StatusNotFound, "book_not_found"},
+	{storageerror.ErrBookNoCopies, http.StatusConflict, "book_no_copies"},
+	{storageerror.ErrRevisionNotFound, http.StatusNotFound, "revision_not_found"},
+
+	{storageerror.ErrUserAlreadyExist, http.StatusConflict, "user_already_exists"},
+	{storageerror.ErrUserStorageEmpty, http.StatusNotFound, "user_storage_empty"},
+	{storageerror.ErrUserInvalidPassword, http.StatusUnauthorized, "invalid_password"},
+	{storageerror.ErrUserNotFound, http.StatusNotFound, "user_not_found"},
+	{storageerror.ErrUserInactive, http.StatusConflict, "user_inactive"},
+	{storageerror.ErrUserActive, http.StatusConflict, "user_active"},
+	{storageerror.ErrUserAnonymized, http.StatusConflict, "user_anonymized"},
+
+	{storageerror.ErrAuthorNotFound, http.StatusNotFound, "author_not_found"},
+	{storageerror.ErrAuthorHasBooks, http.StatusConflict, "author_has_books"},
+
+	{storageerror.ErrGenreAlreadyExist, http.StatusConflict, "genre_already_exists"},
+	{storageerror.ErrGenreNotFound, http.StatusNotFound, "genre_not_found"},
+	{storageerror.ErrGenreHasChildren, http.StatusConflict, "genre_has_children"},
+	{storageerror.ErrGenreCycle, http.StatusUnprocessableEntity, "genre_cycle"},
+	{storageerror.ErrTagNotFound, http.StatusNotFound, "tag_not_found"},
+
+	{storageerror.ErrLoanNotFound, http.StatusNotFound, "loan_not_found"},
+	{storageerror.ErrLoanAlreadyReturned, http.StatusConflict, "loan_already_returned"},
+	{storageerror.ErrBookAlreadyLoaned, http.StatusConflict, "book_already_loaned"},
+	{storageerror.ErrLoanRenewalLimit, http.StatusConflict, "loan_renewal_limit"},
+	{storageerror.ErrLoanOverdue, http.StatusConflict, "loan_overdue"},
+	{storageerror.ErrLoanHoldPending, http.StatusConflict, "loan_hold_pending"},
+
+	{storageerror.ErrCopyAlreadyExist, http.StatusConflict, "copy_already_exists"},
+	{storageerror.ErrCopyNotFound, http.StatusNotFound, "copy_not_found"},
+	{storageerror.ErrCopyAlreadyLoaned, http.StatusConflict, "copy_already_loaned"},
+	{storageerror.ErrCopyReserved, http.StatusConflict, "copy_reserved"},
+
+	{storageerror.ErrHoldAlreadyExist, http.StatusConflict, "hold_already_exists"},
+	{storageerror.ErrHoldNotFound, http.StatusNotFound, "hold_not_found"},
+	{storageerror.ErrHoldNotActive, http.StatusConflict, "hold_not_active"},
+	{storageerror.ErrBookAvailable, http.StatusConflict, "book_available"},
+
+	{storageerror.ErrFinePaymentExceeds, http.StatusConflict, "fine_payment_exceeds"},
+
+	{storageerror.ErrSessionNotFound, http.StatusUnauthorized, "session_not_found"},
+	{storageerror.ErrSessionRevoked, http.StatusUnauthorized, "session_revoked"},
+	{storageerror.ErrSessionExpired, http.StatusUnauthorized, "session_expired"},
+
+	{storageerror.ErrVersionConflict, http.StatusPreconditionFailed, "version_conflict"},
+	{storageerror.ErrInvalidID, http.StatusBadRequest, "invalid_id"},
+
+	{isbn.ErrInvalid, http.StatusUnprocessableEntity, "invalid_isbn"},
+	{isbn.ErrMismatch, http.StatusUnprocessableEntity, "isbn_mismatch"},
+	{catalog.ErrUnknownFormat, http.StatusBadRequest, "unknown_format"},
+}
+
+// problem answers with the problem document for err. Validation errors list the failed fields. An error
+// nobody translated is a 500 whose text stays in the log, it may tell more about the storage than clients should know.
+func problem(ctx *gin.Context, err error) {
+	partialProblem(ctx, err, nil)
+}
+
+// partialProblem is problem for a request that failed after part of its work was saved, result tells the client which part.
+func partialProblem(ctx *gin.Context, err error, result any) {
+
+	doc := problemStruct{
+		Type:     "about:blank",
+		Status:   http.StatusInternalServerError,
+		Code:     "internal_error",
+		Instance: ctx.Request.URL.Path,
+		Result:   result,
+	}
+
+	var reqErr requestError
+	var valErrs validator.ValidationErrors
+
+	switch {
+	case errors.As(err, &reqErr):
+		doc.Status, doc.Code, doc.Detail = reqErr.status, reqErr.code, err.Error()
+	case errors.As(err, &valErrs):
+		doc.Status, doc.Code, doc.Detail = http.StatusUnprocessableEntity, "validation_failed", "the request has invalid fields"
+		doc.Errors = fieldErrors(valErrs)
+	default:
+
+		for _, known := range problemCodes {
+
+			if errors.Is(err, known.err) {
+				doc.Status, doc.Code, doc.Detail = known.status, known.code, err.Error()
+				break
+			}
+
+		}
+
+	}
+
+	doc.Title = http.StatusText(doc.Status)
+
+	ctx.Header("Content-Type", problemContentType)
+	ctx.JSON(doc.Status, doc)
+
+}
+
+func fieldErrors(valErrs validator.ValidationErrors) []fieldErrorStruct {
+
+	fields := make([]fieldErrorStruct, 0, len(valErrs))
+
+	for _, valErr := range valErrs {
+		fields = append(fields, fieldErrorStruct{Field: valErr.Field(), Rule: valErr.Tag(), Param: valErr.Param()})
+	}
+
+	return fields
+
+}
@@ -0,0 +1,64 @@
+package server
+
+import (
+	"github.com/gin-gonic/gin"
+	"library/internal/logger"
+	"net/http"
+	"strconv"
+)
+
+func (s *ServerStruct) GetBookRevisionsHandler(ctx *gin.Context) {
+
+	log := logger.Get()
+
+	id := ctx.Param("id")
+
+	if id == "" {
+		log.Error().Msg("Book ID is empty")
+		problem(ctx, invalidParam("Book ID is empty"))
+		return
+	}
+
+	revisions, err := s.bService.GetBookRevisions(ctx.Request.Context(), id)
+
+	if err != nil {
+		log.Error().Err(err).Msg("Get book revisions failed")
+		problem(ctx, err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{"result": revisions})
+
+}
+
+func (s *ServerStruct) RestoreBookRevisionHandler(ctx *gin.Context) {
+
+	log := logger.Get()
+
+	id := ctx.Param("id")
+
+	if id == "" {
+		log.Error().Msg("Book ID is empty")
+		problem(ctx, invalidParam("Book ID is empty"))
+		return
+	}
+
+	rev, err := strconv.Atoi(ctx.Param("rev"))
+
+	if err != nil {
+		log.Error().Err(err).Msg("Invalid revision number")
+		problem(ctx, invalidParam("Revision must be a number"))
+		return
+	}
+
+	err = s.bService.RestoreBookRevision(ctx.Request.Context(), currentUserID(ctx), id, rev)
+
+	if err != nil {
+		log.Error().Err(err).Msg("Restore book revision failed")
+		problem(ctx, err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{"result": "Book restored to revision " + strconv.Itoa(rev)})
+
+}
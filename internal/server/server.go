@@ -2,43 +2,82 @@ package server
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"github.com/gin-gonic/gin"
 	"github.com/go-playground/validator"
+	"github.com/google/uuid"
 	"library/internal/config"
+	"library/internal/domain/models"
 	"library/internal/logger"
 	"library/internal/server/utils"
 	"library/internal/service"
+	"library/internal/storage/storageerror"
 	"net/http"
+	"slices"
+	"time"
 )
 
 type ServerStruct struct {
-	server   *http.Server              // Структура Server из пакета http, чтобы мы могли вызывать грейсфул шатдаун
-	valid    *validator.Validate       // Ссылка на оригинальную переменную
-	uService service.UserServiceStruct // Копия
-	bService service.BookServiceStruct // Копия
-	chanDel  chan struct{}
-	ChanErr  chan error
+	server    *http.Server        // Структура Server из пакета http, чтобы мы могли вызывать грейсфул шатдаун
+	valid     *validator.Validate // Ссылка на оригинальную переменную
+	keys      *util.KeySetStruct
+	uService  service.UserServiceStruct // Копия
+	bService  service.BookServiceStruct // Копия
+	lService  service.LoanServiceStruct
+	cService  service.CopyServiceStruct
+	hService  service.HoldServiceStruct
+	fService  service.FineServiceStruct
+	sService  service.SessionServiceStruct
+	aService  service.AuthorServiceStruct
+	gService  service.GenreServiceStruct
+	tService  service.TagServiceStruct
+	auService service.AuditServiceStruct
+	stService service.StatsServiceStruct
+	accessTTL time.Duration
+	ChanErr   chan error
 }
 
 func New(cfg config.ConfigStruct,
+	keys *util.KeySetStruct,
 	uService service.UserServiceStruct,
-	bService service.BookServiceStruct) *ServerStruct {
+	bService service.BookServiceStruct,
+	lService service.LoanServiceStruct,
+	cService service.CopyServiceStruct,
+	hService service.HoldServiceStruct,
+	fService service.FineServiceStruct,
+	sService service.SessionServiceStruct,
+	aService service.AuthorServiceStruct,
+	gService service.GenreServiceStruct,
+	tService service.TagServiceStruct,
+	auService service.AuditServiceStruct,
+	stService service.StatsServiceStruct) *ServerStruct {
 
 	addrStr := fmt.Sprintf("%s:%d", cfg.Host, cfg.Port)
 	server := http.Server{
 		Addr: addrStr,
 	}
 
-	valid := validator.New()
+	valid := NewValidator()
 
 	return &ServerStruct{
-		server:   &server, // ??? Почему тут &server?
-		valid:    valid,   // ??? Почему тут без &?
-		uService: uService,
-		bService: bService,
-		chanDel:  make(chan struct{}, 10),
-		ChanErr:  make(chan error, 10),
+		server:    &server, // ??? Почему тут &server?
+		valid:     valid,   // ??? Почему тут без &?
+		keys:      keys,
+		uService:  uService,
+		bService:  bService,
+		lService:  lService,
+		cService:  cService,
+		hService:  hService,
+		fService:  fService,
+		sService:  sService,
+		aService:  aService,
+		gService:  gService,
+		tService:  tService,
+		auService: auService,
+		stService: stService,
+		accessTTL: cfg.AccessTTL,
+		ChanErr:   make(chan error, 10),
 	}
 
 	// ??? Почему мы валидатор и структуры пользователя и книги запихиваем в структуру сервера?
@@ -52,7 +91,8 @@ func (s *ServerStruct) Run(ctx context.Context) error {
 	router := s.configRouting() // Конфигурацию путей вынесли в отдельную функцию
 	s.server.Handler = router
 
-	go s.deleter(ctx)
+	go s.purger(ctx)
+	go s.holdExpirer(ctx)
 
 	log.Info().Str("addr", s.server.Addr).Msg("starting server")
 
@@ -72,53 +112,241 @@ func (s *ServerStruct) Shutdown(ctx context.Context) error {
 func (s *ServerStruct) configRouting() *gin.Engine {
 
 	router := gin.Default()
+	router.Use(s.IDParamsMiddleware())
 
 	router.GET("/", func(ctx *gin.Context) {
 		ctx.String(http.StatusOK, "hello world")
 	})
 
+	router.GET("/.well-known/jwks.json", func(ctx *gin.Context) {
+		ctx.JSON(http.StatusOK, s.keys.JWKS())
+	})
+
+	auth := s.JWTAuthMiddleware()
+	staff := s.RoleMiddleware(models.RoleLibrarian, models.RoleAdmin)
+	admin := s.RoleMiddleware(models.RoleAdmin)
+	selfOrStaff := s.SelfOrRoleMiddleware(models.RoleLibrarian, models.RoleAdmin)
+	selfOrAdmin := s.SelfOrRoleMiddleware(models.RoleAdmin)
+
 	users := router.Group("/users")
 	{
 		users.POST("/registration", s.RegistrationUserHandler)
 		users.POST("/login", s.LoginUserHandler)
-		users.GET("/", s.JWTAuthMiddleware(), s.GetUsersHandler)
-		users.GET("/:id", s.JWTAuthMiddleware(), s.GetUserHandler)
-		users.POST("/", s.JWTAuthMiddleware(), s.AddUserHandler)
-		users.PUT("/:id", s.JWTAuthMiddleware(), s.EditUserHandler)
-		users.DELETE("/:id", s.JWTAuthMiddleware(), s.DeleteUserHandler)
+		users.POST("/refresh", s.RefreshTokenHandler)
+		users.POST("/logout", auth, s.LogoutUserHandler)
+		users.GET("/", auth, staff, s.GetUsersHandler)
+		users.GET("/me", auth, s.GetMeHandler)
+		users.GET("/export", auth, admin, s.ExportUsersHandler)
+		users.GET("/:id", auth, selfOrStaff, s.GetUserHandler)
+		users.POST("/", auth, admin, s.AddUserHandler)
+		users.PUT("/:id", auth, selfOrAdmin, s.EditUserHandler)
+		users.PATCH("/:id", auth, selfOrAdmin, s.PatchUserHandler)
+		users.DELETE("/:id", auth, selfOrAdmin, s.DeleteUserHandler)
+		users.POST("/:id/reactivate", auth, admin, s.ReactivateUserHandler)
+		users.POST("/:id/anonymize", auth, admin, s.AnonymizeUserHandler)
+		users.DELETE("/:id/sessions", auth, selfOrAdmin, s.RevokeUserSessionsHandler)
+		users.GET("/:id/fines", auth, selfOrStaff, s.GetUserFinesHandler)
+		users.POST("/:id/fines/payments", auth, staff, s.PayFineHandler)
+		users.POST("/:id/fines/waivers", auth, staff, s.WaiveFineHandler)
 	}
 
 	books := router.Group("/books")
 	{
-		books.GET("/", s.JWTAuthMiddleware(), s.GetBooksHandler)
-		books.GET("/:id", s.JWTAuthMiddleware(), s.GetBookHandler)
-		books.POST("/", s.JWTAuthMiddleware(), s.AddBookHandler)
-		books.PUT("/:id", s.JWTAuthMiddleware(), s.EditBookHandler)
-		books.DELETE("/:id", s.JWTAuthMiddleware(), s.DeleteBookHandler)
+		books.GET("/", auth, s.GetBooksHandler)
+		books.GET("/search", auth, s.SearchBooksHandler)
+		books.GET("/isbn/:isbn", auth, s.GetBookByISBNHandler)
+		books.GET("/export", auth, staff, s.ExportBooksHandler)
+		books.GET("/trash", auth, staff, s.GetDeletedBooksHandler)
+		books.GET("/:id", auth, s.GetBookHandler)
+		books.POST("/", auth, staff, s.AddBookHandler)
+		books.POST("/import", auth, staff, s.ImportBooksHandler)
+		books.PUT("/:id", auth, staff, s.EditBookHandler)
+		books.PATCH("/:id", auth, staff, s.PatchBookHandler)
+		books.DELETE("/:id", auth, staff, s.DeleteBookHandler)
+		books.POST("/:id/restore", auth, staff, s.RestoreBookHandler)
+		books.GET("/:id/revisions", auth, staff, s.GetBookRevisionsHandler)
+		books.POST("/:id/revisions/:rev/restore", auth, staff, s.RestoreBookRevisionHandler)
+		books.GET("/:id/copies", auth, s.GetCopiesHandler)
+		books.GET("/:id/copies/:copyID", auth, s.GetCopyHandler)
+		books.POST("/:id/copies", auth, staff, s.AddCopyHandler)
+		books.PUT("/:id/copies/:copyID", auth, staff, s.EditCopyHandler)
+		books.DELETE("/:id/copies/:copyID", auth, staff, s.DeleteCopyHandler)
+		books.PUT("/:id/genres/:genreID", auth, staff, s.AssignGenreHandler)
+		books.DELETE("/:id/genres/:genreID", auth, staff, s.UnassignGenreHandler)
+		books.PUT("/:id/tags/:tag", auth, staff, s.AssignTagHandler)
+		books.DELETE("/:id/tags/:tag", auth, staff, s.UnassignTagHandler)
+	}
+
+	authors := router.Group("/authors")
+	{
+		authors.GET("/", auth, s.GetAuthorsHandler)
+		authors.GET("/:id", auth, s.GetAuthorHandler)
+		authors.POST("/", auth, staff, s.AddAuthorHandler)
+		authors.PUT("/:id", auth, staff, s.EditAuthorHandler)
+		authors.DELETE("/:id", auth, staff, s.DeleteAuthorHandler)
+	}
+
+	genres := router.Group("/genres")
+	{
+		genres.GET("/", auth, s.GetGenresHandler)
+		genres.GET("/:id", auth, s.GetGenreHandler)
+		genres.POST("/", auth, staff, s.AddGenreHandler)
+		genres.PUT("/:id", auth, staff, s.EditGenreHandler)
+		genres.DELETE("/:id", auth, staff, s.DeleteGenreHandler)
+	}
+
+	tags := router.Group("/tags")
+	{
+		tags.GET("/", auth, s.GetTagsHandler)
+	}
+
+	router.GET("/audit", auth, admin, s.GetAuditHandler)
+	router.GET("/stats/db", auth, admin, s.GetPoolStatsHandler)
+
+	loans := router.Group("/loans")
+	{
+		loans.POST("/", auth, staff, s.CheckoutBookHandler)
+		loans.POST("/:id/return", auth, staff, s.ReturnBookHandler)
+		loans.POST("/:id/renew", auth, staff, s.RenewLoanHandler)
+		loans.GET("/overdue", auth, staff, s.GetOverdueLoansHandler)
+		loans.GET("/users/:id", auth, selfOrStaff, s.GetUserLoansHandler)
+		loans.GET("/books/:id", auth, staff, s.GetBookLoansHandler)
+	}
+
+	holds := router.Group("/holds")
+	{
+		holds.POST("/", auth, s.PlaceHoldHandler)
+		holds.DELETE("/:id", auth, s.CancelHoldHandler)
+		holds.GET("/users/:id", auth, selfOrStaff, s.GetUserHoldsHandler)
+		holds.GET("/books/:id", auth, staff, s.GetBookHoldsHandler)
 	}
 
 	return router
 
 }
 
+var errTokenEmpty = errors.New("token is empty")
+
 func (s *ServerStruct) JWTAuthMiddleware() gin.HandlerFunc {
 
 	return func(ctx *gin.Context) {
 		log := logger.Get()
 		token := ctx.GetHeader("Authorization")
 		if token == "" {
-			ctx.JSON(http.StatusUnauthorized, gin.H{"error": "token is empty"})
+			problem(ctx, unauthenticated(errTokenEmpty))
 			ctx.Abort()
 			return
 		}
-		err := util.ValidateToken(token)
+		claims, err := s.keys.ValidateToken(token)
 		if err != nil {
 			log.Error().Err(err).Send()
-			ctx.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+			problem(ctx, unauthenticated(err))
 			ctx.Abort()
 			return
 		}
+		if err = s.sService.CheckSession(claims.ID); err != nil {
+			log.Error().Err(err).Send()
+			problem(ctx, unauthenticated(err))
+			ctx.Abort()
+			return
+		}
+		ctx.Set(claimsKey, claims)
+		ctx.Set(userIDKey, claims.Subject)
 		ctx.Next() // Работает и без него
 	}
 
 }
+
+// RoleMiddleware lets the request through only if the token carries one of the roles.
+// It must run after JWTAuthMiddleware.
+func (s *ServerStruct) RoleMiddleware(roles ...string) gin.HandlerFunc {
+
+	return func(ctx *gin.Context) {
+		if !hasRole(tokenClaims(ctx), roles...) {
+			problem(ctx, forbidden("access denied"))
+			ctx.Abort()
+			return
+		}
+		ctx.Next()
+	}
+
+}
+
+// SelfOrRoleMiddleware is RoleMiddleware that also admits the user whose ID is in the :id path parameter.
+func (s *ServerStruct) SelfOrRoleMiddleware(roles ...string) gin.HandlerFunc {
+
+	return func(ctx *gin.Context) {
+		if !hasRole(tokenClaims(ctx), roles...) && !isOwner(ctx, ctx.Param("id")) {
+			problem(ctx, forbidden("access denied"))
+			ctx.Abort()
+			return
+		}
+		ctx.Next()
+	}
+
+}
+
+// idParams are the path parameters that hold IDs.
+var idParams = []string{"id", "copyID", "genreID"}
+
+// IDParamsMiddleware answers a malformed ID in the path with storageerror.ErrInvalidID before any storage sees it,
+// otherwise DBStorage would fail to parse it while MapStorage would just not find it.
+func (s *ServerStruct) IDParamsMiddleware() gin.HandlerFunc {
+
+	return func(ctx *gin.Context) {
+		for _, param := range idParams {
+			if id := ctx.Param(param); id != "" && uuid.Validate(id) != nil {
+				problem(ctx, fmt.Errorf("%w: %q", storageerror.ErrInvalidID, id))
+				ctx.Abort()
+				return
+			}
+		}
+		ctx.Next()
+	}
+
+}
+
+const (
+	claimsKey = "claims"
+	userIDKey = "userID"
+)
+
+// currentUserID returns the ID of the authenticated caller, or "" outside JWTAuthMiddleware.
+func currentUserID(ctx *gin.Context) string {
+	return ctx.GetString(userIDKey)
+}
+
+// currentSessionID returns the session the caller's access token belongs to.
+func currentSessionID(ctx *gin.Context) string {
+
+	if claims := tokenClaims(ctx); claims != nil {
+		return claims.ID
+	}
+
+	return ""
+
+}
+
+// isOwner reports whether the caller is the user with the given ID.
+func isOwner(ctx *gin.Context, id string) bool {
+	caller := currentUserID(ctx)
+	return caller != "" && caller == id
+}
+
+func tokenClaims(ctx *gin.Context) *util.ClaimsStruct {
+
+	value, ok := ctx.Get(claimsKey)
+
+	if !ok {
+		return nil
+	}
+
+	claims, _ := value.(*util.ClaimsStruct)
+
+	return claims
+
+}
+
+func hasRole(claims *util.ClaimsStruct, roles ...string) bool {
+	return claims != nil && slices.Contains(roles, claims.Role)
+}
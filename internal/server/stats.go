@@ -0,0 +1,11 @@
+package server
+
+import (
+	"github.com/gin-gonic/gin"
+	"net/http"
+)
+
+// GetPoolStatsHandler reports the database connection pool, all zeros when the server runs on the map storage.
+func (s *ServerStruct) GetPoolStatsHandler(ctx *gin.Context) {
+	ctx.JSON(http.StatusOK, gin.H{"result": s.stService.GetPoolStats()})
+}
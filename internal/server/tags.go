@@ -0,0 +1,76 @@
+package server
+
+import (
+	"github.com/gin-gonic/gin"
+	"library/internal/logger"
+	"net/http"
+	"strings"
+)
+
+const maxTagLength = 64
+
+func (s *ServerStruct) GetTagsHandler(ctx *gin.Context) {
+
+	log := logger.Get()
+
+	tags, err := s.tService.GetTags()
+
+	if err != nil {
+		log.Error().Err(err).Msg("Get tags failed")
+		problem(ctx, err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{"result": tags})
+
+}
+
+func (s *ServerStruct) AssignTagHandler(ctx *gin.Context) {
+
+	log := logger.Get()
+
+	id := ctx.Param("id")
+	tag := strings.TrimSpace(ctx.Param("tag"))
+
+	if id == "" || tag == "" || len(tag) > maxTagLength {
+		log.Error().Msg("Book ID or tag is invalid")
+		problem(ctx, invalidParam("Book ID or tag is invalid"))
+		return
+	}
+
+	err := s.tService.AssignTag(id, tag)
+
+	if err != nil {
+		log.Error().Err(err).Msg("Assign tag failed")
+		problem(ctx, err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{"result": "Tag assigned"})
+
+}
+
+func (s *ServerStruct) UnassignTagHandler(ctx *gin.Context) {
+
+	log := logger.Get()
+
+	id := ctx.Param("id")
+	tag := ctx.Param("tag")
+
+	if id == "" || tag == "" {
+		log.Error().Msg("Book ID or tag is empty")
+		problem(ctx, invalidParam("Book ID or tag is empty"))
+		return
+	}
+
+	err := s.tService.UnassignTag(id, tag)
+
+	if err != nil {
+		log.Error().Err(err).Msg("Unassign tag failed")
+		problem(ctx, err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{"result": "Tag unassigned"})
+
+}
@@ -1,56 +1,70 @@
 package server
 
 import (
-	"errors"
 	"fmt"
 	"github.com/gin-gonic/gin"
 	"library/internal/domain/models"
 	"library/internal/logger"
-	"library/internal/server/utils"
-	"library/internal/storage/storageerror"
 	"net/http"
 )
 
+// userResponse is a user as the API returns it. The outer Password shadows the stored hash,
+// so whatever the service hands back the hash is never serialized.
+type userResponse struct {
+	models.UserStruct
+	Password string `json:"pwd,omitempty"`
+}
+
+func userResponses(users []models.UserStruct) []userResponse {
+
+	res := make([]userResponse, 0, len(users))
+
+	for _, user := range users {
+		res = append(res, userResponse{UserStruct: user})
+	}
+
+	return res
+
+}
+
 func (s *ServerStruct) RegistrationUserHandler(ctx *gin.Context) {
 
 	log := logger.Get()
 
 	var user models.UserStruct
 	var err error
-	var ID, token string
+	var ID, role, refresh string
 
 	if err = ctx.ShouldBindBodyWithJSON(&user); err != nil {
 		log.Error().Err(err).Msg("Unmarshall body error")
-		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		problem(ctx, badRequest(err))
 		return
 	}
 
 	if err = s.valid.Struct(user); err != nil {
 		log.Error().Err(err).Msg("Invalid body structure")
-		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		problem(ctx, err)
 		return
 	}
 
-	ID, err = s.uService.RegistrationUser(user)
+	ID, role, err = s.uService.RegistrationUser(ctx.Request.Context(), user)
 
 	if err != nil {
 		log.Error().Err(err).Msg("Registration user fail")
-		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		problem(ctx, err)
 		return
 	}
 
-	token, err = util.CreateToken(ID)
+	refresh, err = s.startSession(ctx, ID, role)
 
 	if err != nil {
 		log.Error().Err(err).Msg("Token creation error")
-		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		problem(ctx, err)
 		return
 	}
 
-	ctx.Header("Authorization", token)
-
 	ctx.JSON(http.StatusOK,
-		gin.H{"result": fmt.Sprintf("User registered. ID - %s", ID)})
+		gin.H{"result": fmt.Sprintf("User registered. ID - %s", ID), "refresh_token": refresh})
 
 }
 
@@ -59,40 +73,149 @@ func (s *ServerStruct) LoginUserHandler(ctx *gin.Context) {
 	log := logger.Get()
 
 	var user models.UserLoginStruct
+	var userDB models.UserStruct
 	var err error
-	var ID, token string
+	var refresh string
 
 	if err := ctx.ShouldBindBodyWithJSON(&user); err != nil {
 		log.Error().Err(err).Msg("Unmarshall body error")
-		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		problem(ctx, badRequest(err))
 		return
 	}
 
 	if err := s.valid.Struct(user); err != nil {
 		log.Error().Err(err).Msg("Invalid body structure")
-		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		problem(ctx, err)
 		return
 	}
 
-	ID, err = s.uService.LoginUser(user)
+	userDB, err = s.uService.LoginUser(ctx.Request.Context(), user)
 
 	if err != nil {
 		log.Error().Err(err).Msg("Login user fail")
-		ctx.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+		problem(ctx, unauthenticated(err))
+		return
+	}
+
+	refresh, err = s.startSession(ctx, userDB.ID.String(), userDB.Role)
+
+	if err != nil {
+		log.Error().Err(err).Msg("Token creation error")
+		problem(ctx, err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK,
+		gin.H{"result": fmt.Sprintf("User logged. ID - %s", userDB.ID), "refresh_token": refresh})
+
+}
+
+func (s *ServerStruct) RefreshTokenHandler(ctx *gin.Context) {
+
+	log := logger.Get()
+
+	var body models.RefreshStruct
+
+	if err := ctx.ShouldBindBodyWithJSON(&body); err != nil {
+		log.Error().Err(err).Msg("Unmarshall body error")
+		problem(ctx, badRequest(err))
+		return
+	}
+
+	if err := s.valid.Struct(body); err != nil {
+		log.Error().Err(err).Msg("Invalid body structure")
+		problem(ctx, err)
+		return
+	}
+
+	session, refresh, err := s.sService.Refresh(body.RefreshToken)
+
+	if err != nil {
+		log.Error().Err(err).Msg("Refresh token failed")
+		problem(ctx, err)
 		return
 	}
 
-	token, err = util.CreateToken(ID)
+	// The role is read again so that changes made by an admin apply from the next refresh.
+	user, err := s.uService.GetUser(ctx.Request.Context(), session.UserID.String())
+
+	if err != nil {
+		log.Error().Err(err).Msg("Get user failed")
+		problem(ctx, unauthenticated(err))
+		return
+	}
+
+	token, err := s.keys.CreateToken(user.ID.String(), user.Role, session.ID.String(), s.accessTTL)
 
 	if err != nil {
 		log.Error().Err(err).Msg("Token creation error")
-		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		problem(ctx, err)
+		return
+	}
+
+	ctx.Header("Authorization", token)
+
+	ctx.JSON(http.StatusOK, gin.H{"result": "Token refreshed", "refresh_token": refresh})
+
+}
+
+func (s *ServerStruct) LogoutUserHandler(ctx *gin.Context) {
+
+	log := logger.Get()
+
+	if err := s.sService.Logout(currentSessionID(ctx)); err != nil {
+		log.Error().Err(err).Msg("Logout failed")
+		problem(ctx, err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{"result": "User logged out"})
+
+}
+
+func (s *ServerStruct) RevokeUserSessionsHandler(ctx *gin.Context) {
+
+	log := logger.Get()
+
+	id := ctx.Param("id")
+
+	if id == "" {
+		log.Error().Msg("User ID is empty")
+		problem(ctx, invalidParam("User ID is empty"))
 		return
 	}
 
+	err := s.sService.RevokeUserSessions(id)
+
+	if err != nil {
+		log.Error().Err(err).Msg("Revoke sessions failed")
+		problem(ctx, err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{"result": "User sessions revoked"})
+
+}
+
+// startSession opens a server-side session, puts a fresh access token into the Authorization
+// header and returns the refresh token.
+func (s *ServerStruct) startSession(ctx *gin.Context, userID string, role string) (string, error) {
+
+	sessionID, refresh, err := s.sService.StartSession(userID)
+
+	if err != nil {
+		return "", err
+	}
+
+	token, err := s.keys.CreateToken(userID, role, sessionID, s.accessTTL)
+
+	if err != nil {
+		return "", err
+	}
+
 	ctx.Header("Authorization", token)
 
-	ctx.JSON(http.StatusOK, gin.H{"result": fmt.Sprintf("User logged. ID - %s", ID)})
+	return refresh, nil
 
 }
 
@@ -100,15 +223,29 @@ func (s *ServerStruct) GetUsersHandler(ctx *gin.Context) {
 
 	log := logger.Get()
 
-	users, err := s.uService.GetUsers()
+	var filter models.UserFilterStruct
+
+	if err := ctx.ShouldBindQuery(&filter); err != nil {
+		log.Error().Err(err).Msg("Bind query error")
+		problem(ctx, badRequest(err))
+		return
+	}
+
+	if err := s.valid.Struct(filter); err != nil {
+		log.Error().Err(err).Msg("Invalid query parameters")
+		problem(ctx, err)
+		return
+	}
+
+	users, page, err := s.uService.GetUsers(ctx.Request.Context(), filter)
 
 	if err != nil {
 		log.Error().Err(err).Msg("get users error")
-		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		problem(ctx, err)
 		return
 	}
 
-	ctx.JSON(http.StatusOK, gin.H{"result": users})
+	ctx.JSON(http.StatusOK, gin.H{"result": userResponses(users), "total": page.Total, "limit": page.Limit, "offset": page.Offset})
 
 }
 
@@ -120,19 +257,39 @@ func (s *ServerStruct) GetUserHandler(ctx *gin.Context) {
 
 	if id == "" {
 		log.Error().Msg("User ID is empty")
-		ctx.JSON(http.StatusBadRequest, gin.H{"error": "User ID is empty"})
+		problem(ctx, invalidParam("User ID is empty"))
 		return
 	}
 
-	user, err := s.uService.GetUser(id)
+	user, err := s.uService.GetUser(ctx.Request.Context(), id)
 
 	if err != nil {
 		log.Error().Err(err).Msg("Get user failed")
-		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		problem(ctx, err)
+		return
+	}
+
+	if notModified(ctx, user.Version) {
 		return
 	}
 
-	ctx.JSON(http.StatusOK, gin.H{"result": user})
+	ctx.JSON(http.StatusOK, gin.H{"result": userResponse{UserStruct: user}})
+
+}
+
+func (s *ServerStruct) GetMeHandler(ctx *gin.Context) {
+
+	log := logger.Get()
+
+	user, err := s.uService.GetUser(ctx.Request.Context(), currentUserID(ctx))
+
+	if err != nil {
+		log.Error().Err(err).Msg("Get current user failed")
+		problem(ctx, err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{"result": userResponse{UserStruct: user}})
 
 }
 
@@ -144,39 +301,85 @@ func (s *ServerStruct) AddUserHandler(ctx *gin.Context) {
 
 	if err := ctx.ShouldBindBodyWithJSON(&user); err != nil {
 		log.Error().Err(err).Msg("Unmarshall body error")
-		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		problem(ctx, badRequest(err))
 		return
 	}
 
 	if err := s.valid.Struct(user); err != nil {
 		log.Error().Err(err).Msg("Invalid body structure")
-		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		problem(ctx, err)
 		return
 	}
 
-	id, err := s.uService.AddUser(user)
+	id, err := s.uService.AddUser(ctx.Request.Context(), currentUserID(ctx), user)
 
 	if err != nil {
-
 		log.Error().Err(err).Msg("Add user failed")
+		problem(ctx, err)
+		return
+	}
 
-		status := http.StatusInternalServerError
+	ctx.JSON(http.StatusCreated, gin.H{"result": fmt.Sprintf("User added. ID - %s", id)})
 
-		if errors.Is(err, storageerror.ErrUserAlreadyExist) {
-			status = http.StatusConflict
-		}
+}
+
+func (s *ServerStruct) EditUserHandler(ctx *gin.Context) {
+
+	log := logger.Get()
 
-		ctx.JSON(status, gin.H{"error": err.Error()})
+	id := ctx.Param("id")
 
+	if id == "" {
+		log.Error().Msg("User ID is empty")
+		problem(ctx, invalidParam("User ID is empty"))
 		return
+	}
+
+	version, err := ifMatchVersion(ctx)
 
+	if err != nil {
+		log.Error().Err(err).Msg("Invalid If-Match header")
+		problem(ctx, err)
+		return
 	}
 
-	ctx.JSON(http.StatusCreated, gin.H{"result": fmt.Sprintf("User added. ID - %s", id)})
+	var user models.UserStruct
+
+	if err = ctx.ShouldBindBodyWithJSON(&user); err != nil {
+		log.Error().Err(err).Msg("Unmarshall body error")
+		problem(ctx, badRequest(err))
+		return
+	}
+
+	if err = s.valid.Struct(user); err != nil {
+		log.Error().Err(err).Msg("Invalid body structure")
+		problem(ctx, err)
+		return
+	}
+
+	user.Version = version
+
+	own := isOwner(ctx, id)
+
+	// Only an admin may change roles, and not their own, so the last admin cannot lock themselves out.
+	if !hasRole(tokenClaims(ctx), models.RoleAdmin) || own {
+		user.Role = ""
+	}
+
+	err = s.uService.EditUser(ctx.Request.Context(), currentUserID(ctx), id, user)
+
+	if err != nil {
+		log.Error().Err(err).Msg("Edit user failed")
+		problem(ctx, err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{"result": "User edited"})
 
 }
 
-func (s *ServerStruct) EditUserHandler(ctx *gin.Context) {
+// PatchUserHandler applies a merge patch, see bindMergePatch. The role rule is the one of EditUserHandler.
+func (s *ServerStruct) PatchUserHandler(ctx *gin.Context) {
 
 	log := logger.Get()
 
@@ -184,29 +387,51 @@ func (s *ServerStruct) EditUserHandler(ctx *gin.Context) {
 
 	if id == "" {
 		log.Error().Msg("User ID is empty")
-		ctx.JSON(http.StatusBadRequest, gin.H{"error": "User ID is empty"})
+		problem(ctx, invalidParam("User ID is empty"))
 		return
 	}
 
-	var user models.UserStruct
+	version, err := ifMatchVersion(ctx)
 
-	if err := ctx.ShouldBindBodyWithJSON(&user); err != nil {
+	if err != nil {
+		log.Error().Err(err).Msg("Invalid If-Match header")
+		problem(ctx, err)
+		return
+	}
+
+	var patch models.UserPatchStruct
+
+	removed, err := bindMergePatch(ctx, &patch)
+
+	if err != nil {
 		log.Error().Err(err).Msg("Unmarshall body error")
-		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		problem(ctx, err)
 		return
 	}
 
-	if err := s.valid.Struct(user); err != nil {
+	if err = s.valid.Struct(patch); err != nil {
 		log.Error().Err(err).Msg("Invalid body structure")
-		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		problem(ctx, err)
 		return
 	}
 
-	err := s.uService.EditUser(id, user)
+	if err = clearUserFields(&patch, removed); err != nil {
+		log.Error().Err(err).Msg("Invalid body structure")
+		problem(ctx, badRequest(err))
+		return
+	}
+
+	if !hasRole(tokenClaims(ctx), models.RoleAdmin) || isOwner(ctx, id) {
+		patch.Role = nil
+	}
+
+	patch.Version = version
+
+	err = s.uService.PatchUser(ctx.Request.Context(), currentUserID(ctx), id, patch)
 
 	if err != nil {
-		log.Error().Err(err).Msg("Edit user failed")
-		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		log.Error().Err(err).Msg("Patch user failed")
+		problem(ctx, err)
 		return
 	}
 
@@ -214,6 +439,24 @@ func (s *ServerStruct) EditUserHandler(ctx *gin.Context) {
 
 }
 
+// clearUserFields applies the members a patch set to null. Only the age is optional.
+func clearUserFields(patch *models.UserPatchStruct, fields []string) error {
+
+	for _, field := range fields {
+
+		if field != "age" {
+			return fmt.Errorf("%s can not be removed", field)
+		}
+
+		patch.Age = new(int)
+
+	}
+
+	return nil
+
+}
+
+// DeleteUserHandler deactivates the account, see ReactivateUserHandler and AnonymizeUserHandler.
 func (s *ServerStruct) DeleteUserHandler(ctx *gin.Context) {
 
 	log := logger.Get()
@@ -222,18 +465,73 @@ func (s *ServerStruct) DeleteUserHandler(ctx *gin.Context) {
 
 	if id == "" {
 		log.Error().Msg("User ID is empty")
-		ctx.JSON(http.StatusBadRequest, gin.H{"error": "User ID is empty"})
+		problem(ctx, invalidParam("User ID is empty"))
+		return
+	}
+
+	if isOwner(ctx, id) && hasRole(tokenClaims(ctx), models.RoleAdmin) {
+		log.Error().Msg("Admin tried to delete own account")
+		problem(ctx, forbidden("admin cannot delete own account"))
+		return
+	}
+
+	err := s.uService.DeactivateUser(ctx.Request.Context(), currentUserID(ctx), id)
+
+	if err != nil {
+		log.Error().Err(err).Msg("Deactivate user failed")
+		problem(ctx, err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{"result": "User deactivated"})
+
+}
+
+func (s *ServerStruct) ReactivateUserHandler(ctx *gin.Context) {
+
+	log := logger.Get()
+
+	id := ctx.Param("id")
+
+	if id == "" {
+		log.Error().Msg("User ID is empty")
+		problem(ctx, invalidParam("User ID is empty"))
+		return
+	}
+
+	err := s.uService.ReactivateUser(ctx.Request.Context(), currentUserID(ctx), id)
+
+	if err != nil {
+		log.Error().Err(err).Msg("Reactivate user failed")
+		problem(ctx, err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{"result": "User reactivated"})
+
+}
+
+// AnonymizeUserHandler erases a deactivated account for good, an active one must be deactivated first.
+func (s *ServerStruct) AnonymizeUserHandler(ctx *gin.Context) {
+
+	log := logger.Get()
+
+	id := ctx.Param("id")
+
+	if id == "" {
+		log.Error().Msg("User ID is empty")
+		problem(ctx, invalidParam("User ID is empty"))
 		return
 	}
 
-	err := s.uService.DeleteUser(id)
+	err := s.uService.AnonymizeUser(ctx.Request.Context(), currentUserID(ctx), id)
 
 	if err != nil {
-		log.Error().Err(err).Msg("Delete user failed")
-		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		log.Error().Err(err).Msg("Anonymize user failed")
+		problem(ctx, err)
 		return
 	}
 
-	ctx.JSON(http.StatusOK, gin.H{"result": "User removed"})
+	ctx.JSON(http.StatusOK, gin.H{"result": "User anonymized"})
 
 }
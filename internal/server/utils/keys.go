@@ -0,0 +1,277 @@
+package util
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"github.com/golang-jwt/jwt/v5"
+	"library/internal/config"
+	"math/big"
+	"os"
+	"sort"
+	"strings"
+)
+
+// KeyStruct is one JWT key. Keys kept only for verification during rotation have no signKey.
+type KeyStruct struct {
+	ID        string
+	Method    jwt.SigningMethod
+	signKey   any
+	verifyKey any
+}
+
+// KeySetStruct signs tokens with one key and accepts tokens signed by any key it holds,
+// so an old key can stay for verification until its tokens expire.
+type KeySetStruct struct {
+	signing *KeyStruct
+	keys    map[string]*KeyStruct
+}
+
+type JWKStruct struct {
+	Kty string `json:"kty"`
+	Kid string `json:"kid"`
+	Alg string `json:"alg"`
+	Use string `json:"use"`
+	N   string `json:"n,omitempty"`
+	E   string `json:"e,omitempty"`
+	Crv string `json:"crv,omitempty"`
+	X   string `json:"x,omitempty"`
+}
+
+type JWKSStruct struct {
+	Keys []JWKStruct `json:"keys"`
+}
+
+// NewKeySet builds the key set from config.
+// JWT_ALG selects HS256 (default), RS256 or EdDSA for the signing key with id JWT_KEY_ID.
+// HS256 takes the secret from JWT_SECRET, the others a PEM private key from JWT_KEY_FILE.
+// Retired keys are listed as "kid=path" in JWT_VERIFY_KEYS and "kid=secret" in JWT_VERIFY_SECRETS.
+func NewKeySet(cfg config.ConfigStruct) (*KeySetStruct, error) {
+
+	ks := &KeySetStruct{keys: make(map[string]*KeyStruct)}
+
+	signing, err := loadSigningKey(cfg)
+
+	if err != nil {
+		return nil, err
+	}
+
+	ks.signing = signing
+	ks.keys[signing.ID] = signing
+
+	for kid, path := range splitPairs(cfg.JWTVerifyKeys) {
+
+		key, err := loadVerifyKey(kid, path)
+
+		if err != nil {
+			return nil, err
+		}
+
+		if err = ks.add(key); err != nil {
+			return nil, err
+		}
+
+	}
+
+	for kid, secret := range splitPairs(cfg.JWTVerifySecrets) {
+
+		key := &KeyStruct{ID: kid, Method: jwt.SigningMethodHS256, verifyKey: []byte(secret)}
+
+		if err = ks.add(key); err != nil {
+			return nil, err
+		}
+
+	}
+
+	return ks, nil
+
+}
+
+func (ks *KeySetStruct) add(key *KeyStruct) error {
+
+	if _, ok := ks.keys[key.ID]; ok {
+		return fmt.Errorf("duplicate jwt key id '%s'", key.ID)
+	}
+
+	ks.keys[key.ID] = key
+
+	return nil
+
+}
+
+func (ks *KeySetStruct) keyFunc(token *jwt.Token) (interface{}, error) {
+
+	kid, _ := token.Header["kid"].(string)
+
+	key, ok := ks.keys[kid]
+
+	if !ok {
+		return nil, fmt.Errorf("unknown jwt key id '%s'", kid)
+	}
+
+	// The algorithm comes from our key, never from the token, so an RSA public key
+	// can not be used as an HMAC secret.
+	if token.Method.Alg() != key.Method.Alg() {
+		return nil, fmt.Errorf("unexpected signing method %s", token.Method.Alg())
+	}
+
+	return key.verifyKey, nil
+
+}
+
+// JWKS returns the public halves of the asymmetric keys. HMAC secrets are never published.
+func (ks *KeySetStruct) JWKS() JWKSStruct {
+
+	jwks := JWKSStruct{Keys: []JWKStruct{}}
+
+	for _, key := range ks.keys {
+
+		switch pub := key.verifyKey.(type) {
+		case *rsa.PublicKey:
+			jwks.Keys = append(jwks.Keys, JWKStruct{
+				Kty: "RSA",
+				Kid: key.ID,
+				Alg: key.Method.Alg(),
+				Use: "sig",
+				N:   base64.RawURLEncoding.EncodeToString(pub.N.Bytes()),
+				E:   base64.RawURLEncoding.EncodeToString(big.NewInt(int64(pub.E)).Bytes()),
+			})
+		case ed25519.PublicKey:
+			jwks.Keys = append(jwks.Keys, JWKStruct{
+				Kty: "OKP",
+				Kid: key.ID,
+				Alg: key.Method.Alg(),
+				Use: "sig",
+				Crv: "Ed25519",
+				X:   base64.RawURLEncoding.EncodeToString(pub),
+			})
+		}
+
+	}
+
+	sort.Slice(jwks.Keys, func(i, j int) bool { return jwks.Keys[i].Kid < jwks.Keys[j].Kid })
+
+	return jwks
+
+}
+
+func loadSigningKey(cfg config.ConfigStruct) (*KeyStruct, error) {
+
+	switch strings.ToUpper(cfg.JWTAlg) {
+	case "", "HS256":
+
+		secret := []byte(cfg.JWTSecret)
+
+		if len(secret) == 0 {
+			// Without a configured secret every restart invalidates all tokens, which is still
+			// better than a secret everybody can read in the sources.
+			secret = make([]byte, 32)
+			if _, err := rand.Read(secret); err != nil {
+				return nil, err
+			}
+		}
+
+		return &KeyStruct{ID: cfg.JWTKeyID, Method: jwt.SigningMethodHS256, signKey: secret, verifyKey: secret}, nil
+
+	case "RS256":
+
+		data, err := os.ReadFile(cfg.JWTKeyFile)
+
+		if err != nil {
+			return nil, err
+		}
+
+		private, err := jwt.ParseRSAPrivateKeyFromPEM(data)
+
+		if err != nil {
+			return nil, err
+		}
+
+		return &KeyStruct{ID: cfg.JWTKeyID, Method: jwt.SigningMethodRS256, signKey: private, verifyKey: &private.PublicKey}, nil
+
+	case "EDDSA":
+
+		data, err := os.ReadFile(cfg.JWTKeyFile)
+
+		if err != nil {
+			return nil, err
+		}
+
+		private, err := jwt.ParseEdPrivateKeyFromPEM(data)
+
+		if err != nil {
+			return nil, err
+		}
+
+		edPrivate, ok := private.(ed25519.PrivateKey)
+
+		if !ok {
+			return nil, errors.New("jwt key file is not an ed25519 key")
+		}
+
+		return &KeyStruct{
+			ID:        cfg.JWTKeyID,
+			Method:    jwt.SigningMethodEdDSA,
+			signKey:   edPrivate,
+			verifyKey: edPrivate.Public(),
+		}, nil
+
+	}
+
+	return nil, fmt.Errorf("unsupported jwt algorithm '%s'", cfg.JWTAlg)
+
+}
+
+// loadVerifyKey reads an RSA or Ed25519 key from a PEM file. Private keys are accepted too,
+// only their public part is kept.
+func loadVerifyKey(kid string, path string) (*KeyStruct, error) {
+
+	data, err := os.ReadFile(path)
+
+	if err != nil {
+		return nil, err
+	}
+
+	if pub, err := jwt.ParseRSAPublicKeyFromPEM(data); err == nil {
+		return &KeyStruct{ID: kid, Method: jwt.SigningMethodRS256, verifyKey: pub}, nil
+	}
+
+	if private, err := jwt.ParseRSAPrivateKeyFromPEM(data); err == nil {
+		return &KeyStruct{ID: kid, Method: jwt.SigningMethodRS256, verifyKey: &private.PublicKey}, nil
+	}
+
+	if pub, err := jwt.ParseEdPublicKeyFromPEM(data); err == nil {
+		return &KeyStruct{ID: kid, Method: jwt.SigningMethodEdDSA, verifyKey: pub}, nil
+	}
+
+	if private, err := jwt.ParseEdPrivateKeyFromPEM(data); err == nil {
+		if edPrivate, ok := private.(ed25519.PrivateKey); ok {
+			return &KeyStruct{ID: kid, Method: jwt.SigningMethodEdDSA, verifyKey: edPrivate.Public()}, nil
+		}
+	}
+
+	return nil, fmt.Errorf("jwt key '%s': %s is not an RSA or Ed25519 PEM key", kid, path)
+
+}
+
+// splitPairs parses "a=1,b=2" into a map.
+func splitPairs(list string) map[string]string {
+
+	pairs := make(map[string]string)
+
+	for _, item := range strings.Split(list, ",") {
+
+		kid, value, ok := strings.Cut(strings.TrimSpace(item), "=")
+
+		if ok && kid != "" && value != "" {
+			pairs[kid] = value
+		}
+
+	}
+
+	return pairs
+
+}
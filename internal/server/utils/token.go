@@ -6,9 +6,12 @@ import (
 	"time"
 )
 
-var key = []byte("SecretKey")
+type ClaimsStruct struct {
+	Role string `json:"role"`
+	jwt.RegisteredClaims
+}
 
-func CreateToken(UID string) (string, error) {
+func (ks *KeySetStruct) CreateToken(UID string, role string, sessionID string, ttl time.Duration) (string, error) {
 
 	//payload := jwt.MapClaims{
 	//	"iss": "Server",
@@ -16,15 +19,20 @@ func CreateToken(UID string) (string, error) {
 	//	"exp": time.Now().Add(5 * time.Minute),
 	//}
 
-	payload := jwt.RegisteredClaims{
-		Issuer:    "Server",
-		Subject:   UID,
-		ExpiresAt: jwt.NewNumericDate(time.Now().Add(time.Minute * 60)),
+	payload := ClaimsStruct{
+		Role: role,
+		RegisteredClaims: jwt.RegisteredClaims{
+			Issuer:    "Server",
+			Subject:   UID,
+			ID:        sessionID,
+			ExpiresAt: jwt.NewNumericDate(time.Now().Add(ttl)),
+		},
 	}
 
-	token := jwt.NewWithClaims(jwt.SigningMethodHS256, payload)
+	token := jwt.NewWithClaims(ks.signing.Method, payload)
+	token.Header["kid"] = ks.signing.ID
 
-	tokenString, err := token.SignedString(key)
+	tokenString, err := token.SignedString(ks.signing.signKey)
 
 	if err != nil {
 		return "", err
@@ -34,27 +42,21 @@ func CreateToken(UID string) (string, error) {
 
 }
 
-func ValidateToken(tokenString string) error {
-
-	//var payload jwt.MapClaims
+func (ks *KeySetStruct) ValidateToken(tokenString string) (*ClaimsStruct, error) {
 
-	//token, err := jwt.ParseWithClaims(tokenString, &payload, func(token *jwt.Token) (interface{}, error) {
-	//	return key, nil
-	//})
+	var payload ClaimsStruct
 
-	token, err := jwt.Parse(tokenString, func(token *jwt.Token) (interface{}, error) {
-		return key, nil
-	})
+	token, err := jwt.ParseWithClaims(tokenString, &payload, ks.keyFunc)
 
 	if err != nil {
-		return err
+		return nil, err
 
 	}
 
 	if !token.Valid {
-		return errors.New("token is invalid")
+		return nil, errors.New("token is invalid")
 	}
 
-	return nil
+	return &payload, nil
 
 }
@@ -0,0 +1,41 @@
+package server
+
+import (
+	"github.com/go-playground/validator"
+	"library/internal/domain/isbn"
+	"reflect"
+	"strings"
+)
+
+// NewValidator returns a validator with the rules the built-in tags do not cover.
+// isbn_10 and isbn_13 accept hyphens and spaces and check the checksum.
+// Fields are reported by their JSON or query name, the one the client sent.
+func NewValidator() *validator.Validate {
+
+	valid := validator.New()
+
+	valid.RegisterTagNameFunc(func(field reflect.StructField) string {
+
+		for _, key := range []string{"json", "form"} {
+
+			if name, _, _ := strings.Cut(field.Tag.Get(key), ","); name != "" && name != "-" {
+				return name
+			}
+
+		}
+
+		return field.Name
+
+	})
+
+	_ = valid.RegisterValidation("isbn_10", func(fl validator.FieldLevel) bool {
+		return isbn.Valid10(fl.Field().String())
+	})
+
+	_ = valid.RegisterValidation("isbn_13", func(fl validator.FieldLevel) bool {
+		return isbn.Valid13(fl.Field().String())
+	})
+
+	return valid
+
+}
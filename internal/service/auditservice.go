@@ -0,0 +1,95 @@
+package service
+
+import (
+	"encoding/json"
+	"github.com/google/uuid"
+	"library/internal/domain/models"
+	"library/internal/logger"
+	"time"
+)
+
+type AuditStorage interface {
+	SaveAudit(models.AuditStruct) error
+	GetAudit(models.AuditFilterStruct) ([]models.AuditStruct, int, error)
+}
+
+type AuditServiceStruct struct {
+	storage AuditStorage
+}
+
+func NewAuditService(storage AuditStorage) AuditServiceStruct {
+	return AuditServiceStruct{storage: storage}
+}
+
+// GetAudit returns one page of the log, the newest entries first unless asked otherwise.
+func (as AuditServiceStruct) GetAudit(filter models.AuditFilterStruct) ([]models.AuditStruct, models.PageStruct, error) {
+
+	if filter.Order == "" {
+		filter.Order = "desc"
+	}
+
+	filter.ListParamsStruct = pageDefaults(filter.ListParamsStruct)
+
+	entries, total, err := as.storage.GetAudit(filter)
+
+	if err != nil {
+		return nil, models.PageStruct{}, err
+	}
+
+	return entries, models.PageStruct{Total: total, Limit: filter.Limit, Offset: filter.Offset}, nil
+
+}
+
+// Record writes an entry, before and after are the entity as it was and as it became, nil when there is none.
+// It runs after the change is made, so a failure is logged rather than returned: the change can not be undone.
+func (as AuditServiceStruct) Record(actor string, action string, entity string, id string, before any, after any) {
+
+	log := logger.Get()
+
+	entry := models.AuditStruct{
+		ID:          uuid.New(),
+		Actor:       actor,
+		Action:      action,
+		Entity:      entity,
+		EntityID:    id,
+		DateCreated: time.Now().UTC(),
+	}
+
+	var err error
+
+	if entry.Before, err = snapshot(before); err == nil {
+		entry.After, err = snapshot(after)
+	}
+
+	if err == nil {
+		err = as.storage.SaveAudit(entry)
+	}
+
+	if err != nil {
+		log.Error().Err(err).Str("action", action).Str("entity", entity).Str("id", id).Msg("Failed record audit entry")
+	}
+
+}
+
+// snapshot encodes the entity for the log. A user is logged without the password hash and without the
+// personal data AnonymizeUser erases, the log is kept for good and could not be anonymized with the account.
+func snapshot(value any) (json.RawMessage, error) {
+
+	if value == nil {
+		return nil, nil
+	}
+
+	if user, ok := value.(models.UserStruct); ok {
+		// The outer fields shadow the embedded ones and, being empty, are omitted.
+		value = struct {
+			models.UserStruct
+			Name     string `json:"name,omitempty"`
+			Password string `json:"pwd,omitempty"`
+			Email    string `json:"email,omitempty"`
+			Age      int    `json:"age,omitempty"`
+		}{UserStruct: user}
+	}
+
+	return json.Marshal(value)
+
+}
@@ -0,0 +1,118 @@
+package service
+
+import (
+	"library/internal/domain/models"
+	"strings"
+)
+
+type AuthorStorage interface {
+	GetAuthors() ([]models.AuthorStruct, error)
+	GetAuthor(string) (models.AuthorStruct, error)
+	SaveAuthor(models.AuthorStruct) (string, error)
+	EditAuthor(string, models.AuthorStruct) error
+	DeleteAuthor(string) error
+	EnsureAuthor(string) (string, error)
+	GetBookAuthors(string) ([]models.AuthorStruct, error)
+	SetBookAuthors(string, []string) error
+}
+
+type AuthorServiceStruct struct {
+	storage AuthorStorage
+}
+
+func NewAuthorService(storage AuthorStorage) AuthorServiceStruct {
+	return AuthorServiceStruct{storage: storage}
+}
+
+func (as AuthorServiceStruct) GetAuthors() ([]models.AuthorStruct, error) {
+	return as.storage.GetAuthors()
+}
+
+func (as AuthorServiceStruct) GetAuthor(id string) (models.AuthorStruct, error) {
+	return as.storage.GetAuthor(id)
+}
+
+func (as AuthorServiceStruct) AddAuthor(author models.AuthorStruct) (string, error) {
+	return as.storage.SaveAuthor(author)
+}
+
+func (as AuthorServiceStruct) EditAuthor(id string, author models.AuthorStruct) error {
+	return as.storage.EditAuthor(id, author)
+}
+
+func (as AuthorServiceStruct) DeleteAuthor(id string) error {
+	return as.storage.DeleteAuthor(id)
+}
+
+func (as AuthorServiceStruct) GetBookAuthors(bookID string) ([]models.AuthorStruct, error) {
+	return as.storage.GetBookAuthors(bookID)
+}
+
+// authorLine checks the authors given by ID and, when the book has no author line,
+// builds it from their names.
+func (as AuthorServiceStruct) authorLine(book models.BookStruct) (models.BookStruct, error) {
+
+	if len(book.AuthorIDs) == 0 {
+		return book, nil
+	}
+
+	names := make([]string, 0, len(book.AuthorIDs))
+
+	for _, id := range book.AuthorIDs {
+
+		author, err := as.storage.GetAuthor(id)
+
+		if err != nil {
+			return book, err
+		}
+
+		names = append(names, author.Name)
+
+	}
+
+	if book.Author == "" {
+		book.Author = strings.Join(names, ", ")
+	}
+
+	return book, nil
+
+}
+
+// linkBook stores the authors of a saved book. Without AuthorIDs the book is linked to the authors
+// with the names from book.Authors (imported records carry them), or else with its author line.
+// The author line is only a fallback for a new book, an edit calls linkBook only when the authors are given.
+func (as AuthorServiceStruct) linkBook(bookID string, book models.BookStruct) error {
+
+	authorIDs := book.AuthorIDs
+
+	if len(authorIDs) == 0 {
+
+		names := []string{book.Author}
+
+		if len(book.Authors) > 0 {
+
+			names = names[:0]
+
+			for _, author := range book.Authors {
+				names = append(names, author.Name)
+			}
+
+		}
+
+		for _, name := range names {
+
+			id, err := as.storage.EnsureAuthor(name)
+
+			if err != nil {
+				return err
+			}
+
+			authorIDs = append(authorIDs, id)
+
+		}
+
+	}
+
+	return as.storage.SetBookAuthors(bookID, authorIDs)
+
+}
@@ -1,44 +1,489 @@
 package service
 
-import "library/internal/domain/models"
+import (
+	"context"
+	"library/internal/domain/isbn"
+	"library/internal/domain/models"
+	"time"
+)
 
 type BookStorage interface {
-	GetBooks() ([]models.BookStruct, error)
-	GetBook(string) (models.BookStruct, error)
-	SaveBook(models.BookStruct) (string, error)
-	EditBook(string, models.BookStruct) error
-	DeleteBook(string) error
-	DeleteBooks() error
+	GetBooks(context.Context, models.BookFilterStruct) ([]models.BookStruct, int, error)
+	GetBook(context.Context, string) (models.BookStruct, error)
+	GetBookByISBN(context.Context, string) (models.BookStruct, error)
+	SearchBooks(context.Context, models.BookSearchStruct) ([]models.BookHitStruct, int, error)
+	SaveBook(context.Context, models.BookStruct) (string, error)
+	EditBook(context.Context, string, models.BookStruct) error
+	PatchBook(context.Context, string, models.BookPatchStruct) error
+	DeleteBook(context.Context, string) error
+	DeleteBooks(context.Context, time.Time) (int, error)
+	GetDeletedBooks(context.Context, models.ListParamsStruct) ([]models.BookStruct, int, error)
+	RestoreBook(context.Context, string) error
+	GetBookRevisions(context.Context, string) ([]models.BookRevisionStruct, error)
+	GetBookRevision(context.Context, string, int) (models.BookRevisionStruct, error)
+	ImportBooks(context.Context, []models.ImportRowStruct, int) error
+	ExportBooks(context.Context, func(models.BookStruct) error) error
 }
 
 type BookServiceStruct struct {
-	storage BookStorage
+	storage     BookStorage
+	authors     AuthorServiceStruct
+	importBatch int
+	retention   time.Duration // how long deleted books stay in the trash, 0 keeps them forever
+	audit       AuditServiceStruct
 }
 
-func NewBookService(storage BookStorage) BookServiceStruct {
-	return BookServiceStruct{storage: storage}
+func NewBookService(storage BookStorage,
+	authors AuthorServiceStruct,
+	importBatch int,
+	retention time.Duration,
+	audit AuditServiceStruct) BookServiceStruct {
+	return BookServiceStruct{storage: storage, authors: authors, importBatch: importBatch, retention: retention, audit: audit}
 }
 
-func (bs BookServiceStruct) GetBooks() ([]models.BookStruct, error) {
-	return bs.storage.GetBooks()
+// GetBooks returns one page of books and the page description with the number of books matching the filter.
+func (bs BookServiceStruct) GetBooks(ctx context.Context, filter models.BookFilterStruct) ([]models.BookStruct, models.PageStruct, error) {
+
+	filter.ListParamsStruct = pageDefaults(filter.ListParamsStruct)
+
+	if filter.Sort == "" {
+		filter.Sort = "name"
+	}
+
+	filter.Tags = normalizeTags(filter.Tags)
+
+	books, total, err := bs.storage.GetBooks(ctx, filter)
+
+	if err != nil {
+		return nil, models.PageStruct{}, err
+	}
+
+	return books, models.PageStruct{Total: total, Limit: filter.Limit, Offset: filter.Offset}, nil
+
+}
+
+// SearchBooks returns one page of books matching the query, the most relevant first.
+func (bs BookServiceStruct) SearchBooks(ctx context.Context, search models.BookSearchStruct) ([]models.BookHitStruct, models.PageStruct, error) {
+
+	page := pageDefaults(models.ListParamsStruct{Limit: search.Limit, Offset: search.Offset})
+
+	search.Limit, search.Offset = page.Limit, page.Offset
+
+	hits, total, err := bs.storage.SearchBooks(ctx, search)
+
+	if err != nil {
+		return nil, models.PageStruct{}, err
+	}
+
+	return hits, models.PageStruct{Total: total, Limit: search.Limit, Offset: search.Offset}, nil
+
+}
+
+// ExportBooks streams every book to fn, stopping at the first error fn returns.
+func (bs BookServiceStruct) ExportBooks(ctx context.Context, fn func(models.BookStruct) error) error {
+	return bs.storage.ExportBooks(ctx, fn)
+}
+
+func (bs BookServiceStruct) GetBook(ctx context.Context, id string) (models.BookStruct, error) {
+
+	book, err := bs.storage.GetBook(ctx, id)
+
+	if err != nil {
+		return book, err
+	}
+
+	return bs.withAuthors(book)
+
+}
+
+// GetBookByISBN accepts either form of the ISBN.
+func (bs BookServiceStruct) GetBookByISBN(ctx context.Context, number string) (models.BookStruct, error) {
+
+	isbn13, err := isbn.To13Any(number)
+
+	if err != nil {
+		return models.BookStruct{}, err
+	}
+
+	book, err := bs.storage.GetBookByISBN(ctx, isbn13)
+
+	if err != nil {
+		return book, err
+	}
+
+	return bs.withAuthors(book)
+
+}
+
+// withAuthors fills the linked authors and their IDs, so that a client can send the IDs back with an edit.
+func (bs BookServiceStruct) withAuthors(book models.BookStruct) (models.BookStruct, error) {
+
+	var err error
+
+	if book.Authors, err = bs.authors.GetBookAuthors(book.ID.String()); err != nil {
+		return book, err
+	}
+
+	for _, author := range book.Authors {
+		book.AuthorIDs = append(book.AuthorIDs, author.ID.String())
+	}
+
+	return book, nil
+
+}
+
+// AddBook and the other mutations take the ID of the user making the change for the audit log.
+func (bs BookServiceStruct) AddBook(ctx context.Context, actor string, book models.BookStruct) (string, error) {
+
+	book, err := prepareBook(book, bs.authors)
+
+	if err != nil {
+		return "", err
+	}
+
+	id, err := bs.storage.SaveBook(ctx, book)
+
+	if err != nil {
+		return "", err
+	}
+
+	if err = bs.authors.linkBook(id, book); err != nil {
+		return id, err
+	}
+
+	bs.audit.Record(actor, models.AuditCreate, models.AuditEntityBook, id, nil, bs.current(ctx, id))
+
+	return id, nil
+
 }
 
-func (bs BookServiceStruct) GetBook(id string) (models.BookStruct, error) {
-	return bs.storage.GetBook(id)
+// EditBook replaces the book. The author links change only when the request names the authors,
+// by author_ids or authors, the author line alone is just the display text.
+func (bs BookServiceStruct) EditBook(ctx context.Context, actor string, id string, book models.BookStruct) error {
+
+	relinked := len(book.AuthorIDs) > 0 || len(book.Authors) > 0
+
+	book, err := prepareBook(book, bs.authors)
+
+	if err != nil {
+		return err
+	}
+
+	return bs.change(ctx, actor, models.AuditEdit, id, func() error {
+
+		if err := bs.storage.EditBook(ctx, id, book); err != nil {
+			return err
+		}
+
+		if !relinked {
+			return nil
+		}
+
+		return bs.authors.linkBook(id, book)
+
+	})
+
 }
 
-func (bs BookServiceStruct) AddBook(book models.BookStruct) (string, error) {
-	return bs.storage.SaveBook(book)
+// PatchBook changes only the fields set in the patch. The ISBNs and the author line are completed against
+// the stored book, the same way EditBook completes them from the request, and written with the patch.
+// Like EditBook, it relinks the authors only for new author_ids.
+func (bs BookServiceStruct) PatchBook(ctx context.Context, actor string, id string, patch models.BookPatchStruct) error {
+
+	book, err := bs.storage.GetBook(ctx, id)
+
+	if err != nil {
+		return err
+	}
+
+	if book, err = prepareBook(mergeBook(book, patch), bs.authors); err != nil {
+		return err
+	}
+
+	if patch.ISBN10 != nil || patch.ISBN13 != nil {
+		patch.ISBN10, patch.ISBN13 = &book.ISBN10, &book.ISBN13
+	}
+
+	relinked := len(patch.AuthorIDs) > 0
+
+	if relinked {
+		patch.Author = &book.Author
+	}
+
+	return bs.change(ctx, actor, models.AuditEdit, id, func() error {
+
+		if err := bs.storage.PatchBook(ctx, id, patch); err != nil {
+			return err
+		}
+
+		if !relinked {
+			return nil
+		}
+
+		return bs.authors.linkBook(id, book)
+
+	})
+
 }
 
-func (bs BookServiceStruct) EditBook(id string, book models.BookStruct) error {
-	return bs.storage.EditBook(id, book)
+// mergeBook applies the patch to the stored book. New AuthorIDs without an author line drop the old
+// line, so that prepareBook builds it from the new authors. The two ISBNs are one number, patching
+// either replaces both and the missing one is derived again.
+func mergeBook(book models.BookStruct, patch models.BookPatchStruct) models.BookStruct {
+
+	if patch.Name != nil {
+		book.Name = *patch.Name
+	}
+
+	if patch.Description != nil {
+		book.Description = *patch.Description
+	}
+
+	if len(patch.AuthorIDs) > 0 {
+		book.AuthorIDs, book.Author = patch.AuthorIDs, ""
+	}
+
+	if patch.Author != nil {
+		book.Author = *patch.Author
+	}
+
+	if patch.ISBN10 != nil || patch.ISBN13 != nil {
+		book.ISBN10, book.ISBN13 = "", ""
+	}
+
+	if patch.ISBN10 != nil {
+		book.ISBN10 = *patch.ISBN10
+	}
+
+	if patch.ISBN13 != nil {
+		book.ISBN13 = *patch.ISBN13
+	}
+
+	if patch.DateWriting != nil {
+		book.DateWriting = *patch.DateWriting
+	}
+
+	return book
+
 }
 
-func (bs BookServiceStruct) DeleteBook(id string) error {
-	return bs.storage.DeleteBook(id)
+// DeleteBook moves the book to the trash, it stays there until RestoreBook or PurgeBooks.
+func (bs BookServiceStruct) DeleteBook(ctx context.Context, actor string, id string) error {
+	return bs.change(ctx, actor, models.AuditDelete, id, func() error { return bs.storage.DeleteBook(ctx, id) })
 }
 
-func (bs BookServiceStruct) DeleteBooks() error {
-	return bs.storage.DeleteBooks()
+// GetDeletedBooks returns one page of the trash, the most recently deleted first unless asked otherwise.
+func (bs BookServiceStruct) GetDeletedBooks(ctx context.Context, params models.ListParamsStruct) ([]models.BookStruct, models.PageStruct, error) {
+
+	if params.Order == "" {
+		params.Order = "desc"
+	}
+
+	params = pageDefaults(params)
+
+	books, total, err := bs.storage.GetDeletedBooks(ctx, params)
+
+	if err != nil {
+		return nil, models.PageStruct{}, err
+	}
+
+	return books, models.PageStruct{Total: total, Limit: params.Limit, Offset: params.Offset}, nil
+
+}
+
+func (bs BookServiceStruct) RestoreBook(ctx context.Context, actor string, id string) error {
+
+	if err := bs.storage.RestoreBook(ctx, id); err != nil {
+		return err
+	}
+
+	bs.audit.Record(actor, models.AuditRestore, models.AuditEntityBook, id, nil, bs.current(ctx, id))
+
+	return nil
+
+}
+
+// GetBookRevisions returns the saved states of the book, the newest first, each with the changes
+// against the revision before it.
+func (bs BookServiceStruct) GetBookRevisions(ctx context.Context, id string) ([]models.BookRevisionStruct, error) {
+
+	revisions, err := bs.storage.GetBookRevisions(ctx, id)
+
+	if err != nil {
+		return nil, err
+	}
+
+	for i := 0; i+1 < len(revisions); i++ {
+		revisions[i].Changes = bookChanges(revisions[i+1].Book, revisions[i].Book)
+	}
+
+	return revisions, nil
+
+}
+
+// RestoreBookRevision edits the book back to the given revision. The rollback is an edit like any
+// other, so it becomes the newest revision and the history is never rewritten.
+func (bs BookServiceStruct) RestoreBookRevision(ctx context.Context, actor string, id string, rev int) error {
+
+	revision, err := bs.storage.GetBookRevision(ctx, id, rev)
+
+	if err != nil {
+		return err
+	}
+
+	revision.Book.Version = 0 // the revision replaces whatever the book holds now
+
+	// A revision keeps no author links, so the rollback leaves the current links as they are.
+	revision.Book.AuthorIDs, revision.Book.Authors = nil, nil
+
+	return bs.EditBook(ctx, actor, id, revision.Book)
+
+}
+
+// PurgeBooks removes the books that have been in the trash longer than the retention period.
+func (bs BookServiceStruct) PurgeBooks(ctx context.Context) (int, error) {
+
+	if bs.retention <= 0 {
+		return 0, nil
+	}
+
+	purged, err := bs.storage.DeleteBooks(ctx, time.Now().UTC().Add(-bs.retention))
+
+	if err != nil || purged == 0 {
+		return purged, err
+	}
+
+	// The purge removes whole batches, the entry records how many books went.
+	bs.audit.Record("", models.AuditPurge, models.AuditEntityBook, "", nil, map[string]int{"books": purged})
+
+	return purged, nil
+
+}
+
+// ImportBooks saves the rows not yet marked invalid and reports what happened to every row. When the storage
+// fails halfway the rows it saved before are reported as usual, the rest are failed, and the report comes with the error.
+func (bs BookServiceStruct) ImportBooks(ctx context.Context, actor string, rows []models.ImportRowStruct) (models.ImportReportStruct, error) {
+
+	for i := range rows {
+
+		if rows[i].Status != "" {
+			continue
+		}
+
+		book, err := prepareBook(rows[i].Book, bs.authors)
+
+		if err != nil {
+			rows[i].Status, rows[i].Error = models.ImportInvalid, err.Error()
+			continue
+		}
+
+		rows[i].Book = book
+
+	}
+
+	importErr := bs.storage.ImportBooks(ctx, rows, max(bs.importBatch, 1))
+
+	report := models.ImportReportStruct{Rows: rows}
+
+	for i := range rows {
+
+		row := &rows[i]
+
+		switch row.Status {
+		case models.ImportCreated:
+
+			if err := bs.authors.linkBook(row.ID, row.Book); err != nil {
+				return report, err
+			}
+
+			bs.audit.Record(actor, models.AuditCreate, models.AuditEntityBook, row.ID, nil, bs.current(ctx, row.ID))
+
+			report.Created++
+
+		case models.ImportDuplicate:
+			report.Duplicate++
+		case models.ImportInvalid:
+			report.Invalid++
+		case "":
+			row.Status, row.Error = models.ImportFailed, "not imported, the import stopped at an error"
+			report.Failed++
+		}
+
+	}
+
+	return report, importErr
+
+}
+
+// change runs a mutation of an existing book and logs the book as it was before and after.
+// A book moved to the trash has no after snapshot.
+func (bs BookServiceStruct) change(ctx context.Context, actor string, action string, id string, mutate func() error) error {
+
+	before, err := bs.GetBook(ctx, id)
+
+	if err != nil {
+		return err
+	}
+
+	if err = mutate(); err != nil {
+		return err
+	}
+
+	bs.audit.Record(actor, action, models.AuditEntityBook, id, before, bs.current(ctx, id))
+
+	return nil
+
+}
+
+// current reads the book back for the log, with its authors.
+func (bs BookServiceStruct) current(ctx context.Context, id string) any {
+
+	book, err := bs.GetBook(ctx, id)
+
+	if err != nil {
+		return nil
+	}
+
+	return book
+
+}
+
+// bookChanges lists the fields kept in a revision that differ, under their JSON names.
+func bookChanges(from models.BookStruct, to models.BookStruct) []models.FieldChangeStruct {
+
+	var changes []models.FieldChangeStruct
+
+	add := func(field string, a any, b any) {
+		if a != b {
+			changes = append(changes, models.FieldChangeStruct{Field: field, From: a, To: b})
+		}
+	}
+
+	add("name", from.Name, to.Name)
+	add("desc", from.Description, to.Description)
+	add("author", from.Author, to.Author)
+	add("isbn10", from.ISBN10, to.ISBN10)
+	add("isbn13", from.ISBN13, to.ISBN13)
+
+	if !from.DateWriting.Equal(to.DateWriting) {
+		add("date_wrt", from.DateWriting, to.DateWriting)
+	}
+
+	return changes
+
+}
+
+// prepareBook fills the ISBN form that was left out and the author line.
+func prepareBook(book models.BookStruct, authors AuthorServiceStruct) (models.BookStruct, error) {
+
+	var err error
+
+	book.ISBN10, book.ISBN13, err = isbn.Complete(book.ISBN10, book.ISBN13)
+
+	if err != nil {
+		return book, err
+	}
+
+	return authors.authorLine(book)
+
 }
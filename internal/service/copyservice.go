@@ -0,0 +1,54 @@
+package service
+
+import "library/internal/domain/models"
+
+type CopyStorage interface {
+	GetCopies(string) ([]models.CopyStruct, error)
+	GetCopy(string, string) (models.CopyStruct, error)
+	SaveCopy(string, models.CopyStruct) (string, error)
+	EditCopy(string, string, models.CopyStruct) error
+	DeleteCopy(string, string) error
+	GetBookAvailability(string) (models.AvailabilityStruct, error)
+}
+
+type CopyServiceStruct struct {
+	storage CopyStorage
+	holds   HoldServiceStruct
+}
+
+func NewCopyService(storage CopyStorage, holds HoldServiceStruct) CopyServiceStruct {
+	return CopyServiceStruct{storage: storage, holds: holds}
+}
+
+func (cs CopyServiceStruct) GetCopies(bookID string) ([]models.CopyStruct, error) {
+	return cs.storage.GetCopies(bookID)
+}
+
+func (cs CopyServiceStruct) GetCopy(bookID string, copyID string) (models.CopyStruct, error) {
+	return cs.storage.GetCopy(bookID, copyID)
+}
+
+// AddCopy registers a new copy and offers it to the hold queue straight away.
+func (cs CopyServiceStruct) AddCopy(bookID string, cp models.CopyStruct) (string, error) {
+
+	id, err := cs.storage.SaveCopy(bookID, cp)
+
+	if err != nil {
+		return "", err
+	}
+
+	return id, cs.holds.AllocateCopy(id)
+
+}
+
+func (cs CopyServiceStruct) EditCopy(bookID string, copyID string, cp models.CopyStruct) error {
+	return cs.storage.EditCopy(bookID, copyID, cp)
+}
+
+func (cs CopyServiceStruct) DeleteCopy(bookID string, copyID string) error {
+	return cs.storage.DeleteCopy(bookID, copyID)
+}
+
+func (cs CopyServiceStruct) GetBookAvailability(bookID string) (models.AvailabilityStruct, error) {
+	return cs.storage.GetBookAvailability(bookID)
+}
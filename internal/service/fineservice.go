@@ -0,0 +1,110 @@
+package service
+
+import (
+	"github.com/google/uuid"
+	"library/internal/domain/models"
+	"library/internal/storage/storageerror"
+	"time"
+)
+
+type FineStorage interface {
+	SaveFine(models.FineStruct) (string, error)
+	GetUserFines(string) ([]models.FineStruct, error)
+}
+
+type FineServiceStruct struct {
+	storage FineStorage
+	rate    int64
+	grace   time.Duration
+}
+
+func NewFineService(storage FineStorage, rate int64, grace time.Duration) FineServiceStruct {
+	return FineServiceStruct{storage: storage, rate: rate, grace: grace}
+}
+
+func (fs FineServiceStruct) GetBalance(userID string) (models.FineBalanceStruct, error) {
+
+	var balance models.FineBalanceStruct
+
+	fines, err := fs.storage.GetUserFines(userID)
+
+	if err != nil {
+		return balance, err
+	}
+
+	balance.Entries = fines
+
+	for _, fine := range fines {
+
+		if fine.Type == models.FineCharge {
+			balance.Balance += fine.Amount
+		} else {
+			balance.Balance -= fine.Amount
+		}
+
+	}
+
+	return balance, nil
+
+}
+
+func (fs FineServiceStruct) Pay(userID string, payment models.FinePaymentStruct) (string, error) {
+	return fs.settle(userID, models.FinePayment, payment)
+}
+
+func (fs FineServiceStruct) Waive(userID string, waiver models.FinePaymentStruct) (string, error) {
+	return fs.settle(userID, models.FineWaiver, waiver)
+}
+
+func (fs FineServiceStruct) settle(userID string, kind string, payment models.FinePaymentStruct) (string, error) {
+
+	balance, err := fs.GetBalance(userID)
+
+	if err != nil {
+		return "", err
+	}
+
+	if payment.Amount > balance.Balance {
+		return "", storageerror.ErrFinePaymentExceeds
+	}
+
+	var fine models.FineStruct
+
+	if fine.UserID, err = uuid.Parse(userID); err != nil {
+		return "", err
+	}
+
+	fine.Type = kind
+	fine.Amount = payment.Amount
+	fine.Note = payment.Note
+
+	return fs.storage.SaveFine(fine)
+
+}
+
+// OverdueFine is the fine for a loan returned after its due date plus the grace period, due is false
+// when nothing is charged. Every started day past the due date is charged once the grace period is exceeded.
+func (fs FineServiceStruct) OverdueFine(loan models.LoanStruct) (models.FineStruct, bool) {
+
+	if loan.DateReturn == nil {
+		return models.FineStruct{}, false
+	}
+
+	late := loan.DateReturn.Sub(loan.DateDue)
+
+	if late <= fs.grace || fs.rate <= 0 {
+		return models.FineStruct{}, false
+	}
+
+	days := int64((late + 24*time.Hour - 1) / (24 * time.Hour))
+
+	fine := models.FineStruct{
+		UserID: loan.UserID,
+		LoanID: &loan.ID,
+		Type:   models.FineCharge,
+		Amount: days * fs.rate,
+	}
+
+	return fine, true
+
+}
@@ -0,0 +1,54 @@
+package service
+
+import "library/internal/domain/models"
+
+type GenreStorage interface {
+	GetGenres() ([]models.GenreStruct, error)
+	GetGenre(string) (models.GenreStruct, error)
+	SaveGenre(models.GenreStruct) (string, error)
+	EditGenre(string, models.GenreStruct) error
+	DeleteGenre(string) error
+	GetBookGenres(string) ([]models.GenreStruct, error)
+	AddBookGenre(string, string) error
+	RemoveBookGenre(string, string) error
+}
+
+type GenreServiceStruct struct {
+	storage GenreStorage
+}
+
+func NewGenreService(storage GenreStorage) GenreServiceStruct {
+	return GenreServiceStruct{storage: storage}
+}
+
+func (gs GenreServiceStruct) GetGenres() ([]models.GenreStruct, error) {
+	return gs.storage.GetGenres()
+}
+
+func (gs GenreServiceStruct) GetGenre(id string) (models.GenreStruct, error) {
+	return gs.storage.GetGenre(id)
+}
+
+func (gs GenreServiceStruct) AddGenre(genre models.GenreStruct) (string, error) {
+	return gs.storage.SaveGenre(genre)
+}
+
+func (gs GenreServiceStruct) EditGenre(id string, genre models.GenreStruct) error {
+	return gs.storage.EditGenre(id, genre)
+}
+
+func (gs GenreServiceStruct) DeleteGenre(id string) error {
+	return gs.storage.DeleteGenre(id)
+}
+
+func (gs GenreServiceStruct) GetBookGenres(bookID string) ([]models.GenreStruct, error) {
+	return gs.storage.GetBookGenres(bookID)
+}
+
+func (gs GenreServiceStruct) AssignGenre(bookID string, genreID string) error {
+	return gs.storage.AddBookGenre(bookID, genreID)
+}
+
+func (gs GenreServiceStruct) UnassignGenre(bookID string, genreID string) error {
+	return gs.storage.RemoveBookGenre(bookID, genreID)
+}
@@ -0,0 +1,85 @@
+package service
+
+import (
+	"library/internal/domain/models"
+	"time"
+)
+
+type HoldStorage interface {
+	PlaceHold(models.HoldPlaceStruct) (string, error)
+	GetHold(string) (models.HoldStruct, error)
+	CancelHold(string) (models.HoldStruct, error)
+	GetUserHolds(string) ([]models.HoldStruct, error)
+	GetBookHolds(string) ([]models.HoldStruct, error)
+	AllocateCopy(string, time.Time) error
+	ExpireHolds(time.Time) ([]string, error)
+}
+
+type HoldServiceStruct struct {
+	storage HoldStorage
+	expiry  time.Duration
+}
+
+func NewHoldService(storage HoldStorage, expiry time.Duration) HoldServiceStruct {
+	return HoldServiceStruct{storage: storage, expiry: expiry}
+}
+
+func (hs HoldServiceStruct) PlaceHold(hold models.HoldPlaceStruct) (string, error) {
+	return hs.storage.PlaceHold(hold)
+}
+
+func (hs HoldServiceStruct) GetHold(id string) (models.HoldStruct, error) {
+	return hs.storage.GetHold(id)
+}
+
+// CancelHold cancels the hold and, if a copy was already waiting for it, passes the copy on.
+func (hs HoldServiceStruct) CancelHold(id string) error {
+
+	hold, err := hs.storage.CancelHold(id)
+
+	if err != nil {
+		return err
+	}
+
+	if hold.Status == models.HoldReady && hold.CopyID != nil {
+		return hs.AllocateCopy(hold.CopyID.String())
+	}
+
+	return nil
+
+}
+
+func (hs HoldServiceStruct) GetUserHolds(userID string) ([]models.HoldStruct, error) {
+	return hs.storage.GetUserHolds(userID)
+}
+
+func (hs HoldServiceStruct) GetBookHolds(bookID string) ([]models.HoldStruct, error) {
+	return hs.storage.GetBookHolds(bookID)
+}
+
+// AllocateCopy gives a free copy to the head of its book's hold queue.
+func (hs HoldServiceStruct) AllocateCopy(copyID string) error {
+	return hs.storage.AllocateCopy(copyID, time.Now().Add(hs.expiry))
+}
+
+// ExpireHolds drops ready holds that were not picked up in time and passes their copies
+// to the next user in the queue.
+func (hs HoldServiceStruct) ExpireHolds() error {
+
+	copies, err := hs.storage.ExpireHolds(time.Now())
+
+	if err != nil {
+		return err
+	}
+
+	for _, copyID := range copies {
+
+		if err = hs.AllocateCopy(copyID); err != nil {
+			return err
+		}
+
+	}
+
+	return nil
+
+}
@@ -0,0 +1,31 @@
+package service
+
+import "library/internal/domain/models"
+
+const (
+	defaultPageLimit = 20
+	maxPageLimit     = 100
+)
+
+// pageDefaults fills the page window and sort direction when the client left them out.
+func pageDefaults(params models.ListParamsStruct) models.ListParamsStruct {
+
+	if params.Limit <= 0 {
+		params.Limit = defaultPageLimit
+	}
+
+	if params.Limit > maxPageLimit {
+		params.Limit = maxPageLimit
+	}
+
+	if params.Offset < 0 {
+		params.Offset = 0
+	}
+
+	if params.Order == "" {
+		params.Order = "asc"
+	}
+
+	return params
+
+}
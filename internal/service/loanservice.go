@@ -0,0 +1,61 @@
+package service
+
+import (
+	"library/internal/domain/models"
+	"time"
+)
+
+type LoanStorage interface {
+	CheckoutBook(models.LoanCheckoutStruct, time.Time) (string, error)
+	ReturnBook(string, func(models.LoanStruct) (models.FineStruct, bool), time.Time) (models.LoanStruct, error)
+	RenewLoan(string, time.Duration, int) (models.LoanStruct, error)
+	GetOverdueLoans(time.Time) ([]models.LoanStruct, error)
+	GetUserLoans(string) ([]models.LoanStruct, error)
+	GetBookLoans(string) ([]models.LoanStruct, error)
+}
+
+type LoanServiceStruct struct {
+	storage     LoanStorage
+	holds       HoldServiceStruct
+	fines       FineServiceStruct
+	period      time.Duration
+	maxRenewals int
+}
+
+func NewLoanService(storage LoanStorage,
+	holds HoldServiceStruct,
+	fines FineServiceStruct,
+	period time.Duration,
+	maxRenewals int) LoanServiceStruct {
+	return LoanServiceStruct{storage: storage, holds: holds, fines: fines, period: period, maxRenewals: maxRenewals}
+}
+
+func (ls LoanServiceStruct) CheckoutBook(loan models.LoanCheckoutStruct) (string, error) {
+	return ls.storage.CheckoutBook(loan, time.Now().Add(ls.period))
+}
+
+// ReturnBook closes the loan, charges a fine if it came back late and hands the returned
+// copy to the hold queue, if any. The storage does the three in one transaction.
+func (ls LoanServiceStruct) ReturnBook(id string) error {
+
+	_, err := ls.storage.ReturnBook(id, ls.fines.OverdueFine, time.Now().Add(ls.holds.expiry))
+
+	return err
+
+}
+
+func (ls LoanServiceStruct) RenewLoan(id string) (models.LoanStruct, error) {
+	return ls.storage.RenewLoan(id, ls.period, ls.maxRenewals)
+}
+
+func (ls LoanServiceStruct) GetOverdueLoans() ([]models.LoanStruct, error) {
+	return ls.storage.GetOverdueLoans(time.Now())
+}
+
+func (ls LoanServiceStruct) GetUserLoans(userID string) ([]models.LoanStruct, error) {
+	return ls.storage.GetUserLoans(userID)
+}
+
+func (ls LoanServiceStruct) GetBookLoans(bookID string) ([]models.LoanStruct, error) {
+	return ls.storage.GetBookLoans(bookID)
+}
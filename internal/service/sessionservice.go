@@ -0,0 +1,142 @@
+package service
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"github.com/google/uuid"
+	"library/internal/domain/models"
+	"library/internal/storage/storageerror"
+	"time"
+)
+
+type SessionStorage interface {
+	SaveSession(models.SessionStruct) error
+	GetSession(string) (models.SessionStruct, error)
+	GetSessionByToken(string) (models.SessionStruct, error)
+	RotateSession(string, string, time.Time) error
+	RevokeSession(string) error
+	RevokeUserSessions(string) error
+}
+
+// SessionServiceStruct keeps refresh tokens. Only their SHA-256 hashes reach the storage.
+type SessionServiceStruct struct {
+	storage    SessionStorage
+	refreshTTL time.Duration
+}
+
+func NewSessionService(storage SessionStorage, refreshTTL time.Duration) SessionServiceStruct {
+	return SessionServiceStruct{storage: storage, refreshTTL: refreshTTL}
+}
+
+// StartSession opens a session for the user and returns its ID and refresh token.
+func (ss SessionServiceStruct) StartSession(userID string) (string, string, error) {
+
+	UID, err := uuid.Parse(userID)
+
+	if err != nil {
+		return "", "", err
+	}
+
+	refresh, hash, err := newRefreshToken()
+
+	if err != nil {
+		return "", "", err
+	}
+
+	session := models.SessionStruct{
+		ID:         uuid.New(),
+		UserID:     UID,
+		TokenHash:  hash,
+		DateExpire: time.Now().Add(ss.refreshTTL),
+	}
+
+	if err = ss.storage.SaveSession(session); err != nil {
+		return "", "", err
+	}
+
+	return session.ID.String(), refresh, nil
+
+}
+
+// Refresh exchanges a refresh token for a new one. The old token stops working.
+func (ss SessionServiceStruct) Refresh(refreshToken string) (models.SessionStruct, string, error) {
+
+	session, err := ss.storage.GetSessionByToken(hashToken(refreshToken))
+
+	if err != nil {
+		return session, "", err
+	}
+
+	if err = checkSession(session); err != nil {
+		return session, "", err
+	}
+
+	refresh, hash, err := newRefreshToken()
+
+	if err != nil {
+		return session, "", err
+	}
+
+	if err = ss.storage.RotateSession(session.ID.String(), hash, time.Now().Add(ss.refreshTTL)); err != nil {
+		return session, "", err
+	}
+
+	return session, refresh, nil
+
+}
+
+// CheckSession fails if the session behind an access token was revoked or has expired.
+func (ss SessionServiceStruct) CheckSession(id string) error {
+
+	session, err := ss.storage.GetSession(id)
+
+	if err != nil {
+		return err
+	}
+
+	return checkSession(session)
+
+}
+
+func (ss SessionServiceStruct) Logout(id string) error {
+	return ss.storage.RevokeSession(id)
+}
+
+func (ss SessionServiceStruct) RevokeUserSessions(userID string) error {
+	return ss.storage.RevokeUserSessions(userID)
+}
+
+func checkSession(session models.SessionStruct) error {
+
+	if session.Revoked {
+		return storageerror.ErrSessionRevoked
+	}
+
+	if session.DateExpire.Before(time.Now()) {
+		return storageerror.ErrSessionExpired
+	}
+
+	return nil
+
+}
+
+func newRefreshToken() (string, string, error) {
+
+	buf := make([]byte, 32)
+
+	if _, err := rand.Read(buf); err != nil {
+		return "", "", err
+	}
+
+	token := base64.RawURLEncoding.EncodeToString(buf)
+
+	return token, hashToken(token), nil
+
+}
+
+func hashToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,19 @@
+package service
+
+import "library/internal/domain/models"
+
+type StatsStorage interface {
+	PoolStats() models.PoolStatsStruct
+}
+
+type StatsServiceStruct struct {
+	storage StatsStorage
+}
+
+func NewStatsService(storage StatsStorage) StatsServiceStruct {
+	return StatsServiceStruct{storage: storage}
+}
+
+func (ss StatsServiceStruct) GetPoolStats() models.PoolStatsStruct {
+	return ss.storage.PoolStats()
+}
@@ -0,0 +1,61 @@
+package service
+
+import (
+	"library/internal/domain/models"
+	"slices"
+	"strings"
+)
+
+type TagStorage interface {
+	GetTags() ([]models.TagStruct, error)
+	GetBookTags(string) ([]string, error)
+	AddBookTag(string, string) error
+	RemoveBookTag(string, string) error
+}
+
+type TagServiceStruct struct {
+	storage TagStorage
+}
+
+func NewTagService(storage TagStorage) TagServiceStruct {
+	return TagServiceStruct{storage: storage}
+}
+
+func (ts TagServiceStruct) GetTags() ([]models.TagStruct, error) {
+	return ts.storage.GetTags()
+}
+
+func (ts TagServiceStruct) GetBookTags(bookID string) ([]string, error) {
+	return ts.storage.GetBookTags(bookID)
+}
+
+func (ts TagServiceStruct) AssignTag(bookID string, tag string) error {
+	return ts.storage.AddBookTag(bookID, normalizeTag(tag))
+}
+
+func (ts TagServiceStruct) UnassignTag(bookID string, tag string) error {
+	return ts.storage.RemoveBookTag(bookID, normalizeTag(tag))
+}
+
+// Tags are free-form, so "Sci-Fi " and "sci-fi" are folded into one.
+func normalizeTag(tag string) string {
+	return strings.ToLower(strings.TrimSpace(tag))
+}
+
+func normalizeTags(tags []string) []string {
+
+	var normalized []string
+
+	for _, tag := range tags {
+
+		tag = normalizeTag(tag)
+
+		if tag != "" && !slices.Contains(normalized, tag) {
+			normalized = append(normalized, tag)
+		}
+
+	}
+
+	return normalized
+
+}
@@ -1,48 +1,159 @@
 package service
 
-import "library/internal/domain/models"
+import (
+	"context"
+	"library/internal/domain/models"
+	"strings"
+)
 
 type UserStorage interface {
-	GetUsers() ([]models.UserStruct, error)
-	GetUser(string) (models.UserStruct, error)
-	SaveUser(models.UserStruct) (string, error)
-	ValidateUser(models.UserLoginStruct) (string, error)
-	EditUser(string, models.UserStruct) error
-	DeleteUser(string) error
+	GetUsers(context.Context, models.UserFilterStruct) ([]models.UserStruct, int, error)
+	GetUser(context.Context, string) (models.UserStruct, error)
+	SaveUser(context.Context, models.UserStruct) (string, error)
+	ValidateUser(context.Context, models.UserLoginStruct) (models.UserStruct, error)
+	EditUser(context.Context, string, models.UserStruct) error
+	PatchUser(context.Context, string, models.UserPatchStruct) error
+	DeleteUser(context.Context, string) error
+	ReactivateUser(context.Context, string) error
+	AnonymizeUser(context.Context, string) error
+	ExportUsers(context.Context, func(models.UserStruct) error) error
 }
 
 type UserServiceStruct struct {
-	storage UserStorage
+	storage    UserStorage
+	adminEmail string
+	audit      AuditServiceStruct
 }
 
-func NewUserService(storage UserStorage) UserServiceStruct {
-	return UserServiceStruct{storage: storage}
+func NewUserService(storage UserStorage, adminEmail string, audit AuditServiceStruct) UserServiceStruct {
+	return UserServiceStruct{storage: storage, adminEmail: adminEmail, audit: audit}
 }
 
-func (us UserServiceStruct) RegistrationUser(user models.UserStruct) (string, error) {
-	return us.storage.SaveUser(user)
+// RegistrationUser always creates a patron, except for the configured admin email,
+// which is how the first administrator gets into the system.
+func (us UserServiceStruct) RegistrationUser(ctx context.Context, user models.UserStruct) (string, string, error) {
+
+	user.Role = models.RolePatron
+
+	if us.adminEmail != "" && strings.EqualFold(user.Email, us.adminEmail) {
+		user.Role = models.RoleAdmin
+	}
+
+	id, err := us.storage.SaveUser(ctx, user)
+
+	if err != nil {
+		return "", "", err
+	}
+
+	// A registration is made by the new user.
+	us.audit.Record(id, models.AuditCreate, models.AuditEntityUser, id, nil, us.current(ctx, id))
+
+	return id, user.Role, nil
+
 }
 
-func (us UserServiceStruct) LoginUser(user models.UserLoginStruct) (string, error) {
-	return us.storage.ValidateUser(user)
+func (us UserServiceStruct) LoginUser(ctx context.Context, user models.UserLoginStruct) (models.UserStruct, error) {
+	return us.storage.ValidateUser(ctx, user)
 }
 
-func (us UserServiceStruct) GetUsers() ([]models.UserStruct, error) {
-	return us.storage.GetUsers()
+// GetUsers returns one page of users and the page description with the number of users matching the filter.
+func (us UserServiceStruct) GetUsers(ctx context.Context, filter models.UserFilterStruct) ([]models.UserStruct, models.PageStruct, error) {
+
+	filter.ListParamsStruct = pageDefaults(filter.ListParamsStruct)
+
+	if filter.Sort == "" {
+		filter.Sort = "name"
+	}
+
+	if filter.Status == "" {
+		filter.Status = models.UserStatusActive
+	}
+
+	users, total, err := us.storage.GetUsers(ctx, filter)
+
+	if err != nil {
+		return nil, models.PageStruct{}, err
+	}
+
+	return users, models.PageStruct{Total: total, Limit: filter.Limit, Offset: filter.Offset}, nil
+
+}
+
+// ExportUsers streams every user to fn. Passwords are never passed.
+func (us UserServiceStruct) ExportUsers(ctx context.Context, fn func(models.UserStruct) error) error {
+	return us.storage.ExportUsers(ctx, fn)
 }
 
-func (us UserServiceStruct) GetUser(id string) (models.UserStruct, error) {
-	return us.storage.GetUser(id)
+func (us UserServiceStruct) GetUser(ctx context.Context, id string) (models.UserStruct, error) {
+	return us.storage.GetUser(ctx, id)
 }
 
-func (us UserServiceStruct) AddUser(user models.UserStruct) (string, error) {
-	return us.storage.SaveUser(user)
+// AddUser, EditUser and the account state changes take the ID of the user making the change for the audit log.
+func (us UserServiceStruct) AddUser(ctx context.Context, actor string, user models.UserStruct) (string, error) {
+
+	id, err := us.storage.SaveUser(ctx, user)
+
+	if err != nil {
+		return "", err
+	}
+
+	us.audit.Record(actor, models.AuditCreate, models.AuditEntityUser, id, nil, us.current(ctx, id))
+
+	return id, nil
+
 }
 
-func (us UserServiceStruct) EditUser(id string, user models.UserStruct) error {
-	return us.storage.EditUser(id, user)
+func (us UserServiceStruct) EditUser(ctx context.Context, actor string, id string, user models.UserStruct) error {
+	return us.change(ctx, actor, models.AuditEdit, id, func() error { return us.storage.EditUser(ctx, id, user) })
 }
 
-func (us UserServiceStruct) DeleteUser(id string) error {
-	return us.storage.DeleteUser(id)
+// PatchUser changes only the fields set in the patch.
+func (us UserServiceStruct) PatchUser(ctx context.Context, actor string, id string, patch models.UserPatchStruct) error {
+	return us.change(ctx, actor, models.AuditEdit, id, func() error { return us.storage.PatchUser(ctx, id, patch) })
+}
+
+// DeactivateUser keeps the account and its history but blocks logins and ends its sessions.
+func (us UserServiceStruct) DeactivateUser(ctx context.Context, actor string, id string) error {
+	return us.change(ctx, actor, models.AuditDelete, id, func() error { return us.storage.DeleteUser(ctx, id) })
+}
+
+func (us UserServiceStruct) ReactivateUser(ctx context.Context, actor string, id string) error {
+	return us.change(ctx, actor, models.AuditReactivate, id, func() error { return us.storage.ReactivateUser(ctx, id) })
+}
+
+// AnonymizeUser erases the personal data of a deactivated account. It can not be undone.
+func (us UserServiceStruct) AnonymizeUser(ctx context.Context, actor string, id string) error {
+	return us.change(ctx, actor, models.AuditAnonymize, id, func() error { return us.storage.AnonymizeUser(ctx, id) })
+}
+
+// change runs a mutation of an existing user and logs the user as it was before and after.
+func (us UserServiceStruct) change(ctx context.Context, actor string, action string, id string, mutate func() error) error {
+
+	before, err := us.storage.GetUser(ctx, id)
+
+	if err != nil {
+		return err
+	}
+
+	if err = mutate(); err != nil {
+		return err
+	}
+
+	us.audit.Record(actor, action, models.AuditEntityUser, id, before, us.current(ctx, id))
+
+	return nil
+
+}
+
+// current reads the user back for the log, so the snapshot shows what the storage filled in.
+func (us UserServiceStruct) current(ctx context.Context, id string) any {
+
+	user, err := us.storage.GetUser(ctx, id)
+
+	if err != nil {
+		return nil
+	}
+
+	return user
+
 }
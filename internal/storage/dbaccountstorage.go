@@ -0,0 +1,160 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"library/internal/logger"
+	"library/internal/storage/storageerror"
+	"time"
+)
+
+const anonymizedName = "Anonymized user"
+
+// anonymizedEmail is unique per account, so the email constraint still holds after the erasure.
+func anonymizedEmail(id uuid.UUID) string {
+	return "anonymized-" + id.String() + "@invalid"
+}
+
+func (db *DBStorage) ReactivateUser(ctx context.Context, id string) error {
+
+	log := logger.Get()
+
+	ctx, cancel := context.WithTimeout(ctx, db.timeouts.Query)
+
+	defer cancel()
+
+	ID, err := parseID(id)
+
+	if err != nil {
+		log.Error().Err(err).Msg("Failed parse ID")
+		return err
+	}
+
+	var deletedAt, anonymizedAt *time.Time
+
+	row := db.pool.QueryRow(ctx, "SELECT DeletedAt, AnonymizedAt FROM Users WHERE ID = $1", ID)
+
+	if err = row.Scan(&deletedAt, &anonymizedAt); err != nil {
+
+		if errors.Is(err, pgx.ErrNoRows) {
+			return storageerror.ErrUserNotFound
+		}
+
+		log.Error().Err(err).Msg("Failed get data from table Users")
+		return err
+
+	}
+
+	switch {
+	case anonymizedAt != nil:
+		return storageerror.ErrUserAnonymized
+	case deletedAt == nil:
+		return storageerror.ErrUserActive
+	}
+
+	if _, err = db.pool.Exec(ctx, "UPDATE Users SET DeletedAt = NULL, Version = Version + 1 WHERE ID = $1", ID); err != nil {
+		log.Error().Err(err).Msg("Failed reactivate user")
+		return err
+	}
+
+	return nil
+
+}
+
+// AnonymizeUser erases the personal data of a deactivated account. The row keeps its ID, so loans
+// and fines stay consistent, but the name, email, age and password are gone for good and the
+// sessions are removed. The email is replaced by a unique placeholder, which frees the old one.
+func (db *DBStorage) AnonymizeUser(ctx context.Context, id string) error {
+
+	log := logger.Get()
+
+	ctx, cancel := context.WithTimeout(ctx, db.timeouts.Query)
+
+	defer cancel()
+
+	ID, err := parseID(id)
+
+	if err != nil {
+		log.Error().Err(err).Msg("Failed parse ID")
+		return err
+	}
+
+	tx, err := db.pool.Begin(ctx)
+
+	if err != nil {
+		log.Error().Err(err).Msg("Failed create transaction")
+		return err
+	}
+
+	defer func() {
+		_ = tx.Rollback(ctx)
+	}()
+
+	var deletedAt, anonymizedAt *time.Time
+
+	row := tx.QueryRow(ctx, "SELECT DeletedAt, AnonymizedAt FROM Users WHERE ID = $1 FOR UPDATE", ID)
+
+	if err = row.Scan(&deletedAt, &anonymizedAt); err != nil {
+
+		if errors.Is(err, pgx.ErrNoRows) {
+			return storageerror.ErrUserNotFound
+		}
+
+		log.Error().Err(err).Msg("Failed get data from table Users")
+		return err
+
+	}
+
+	switch {
+	case anonymizedAt != nil:
+		return storageerror.ErrUserAnonymized
+	case deletedAt == nil:
+		return storageerror.ErrUserActive
+	}
+
+	_, err = tx.Exec(ctx,
+		"UPDATE Users SET Name = $1, Email = $2, Password = '', Age = 0, AnonymizedAt = $3, Version = Version + 1 WHERE ID = $4",
+		anonymizedName, anonymizedEmail(ID), time.Now().UTC(), ID)
+
+	if err != nil {
+		log.Error().Err(err).Msg("Failed anonymize user")
+		return err
+	}
+
+	if _, err = tx.Exec(ctx, "DELETE FROM Sessions WHERE UserID = $1", ID); err != nil {
+		log.Error().Err(err).Msg("Failed delete sessions")
+		return err
+	}
+
+	return tx.Commit(ctx)
+
+}
+
+// activeUser is userExists that also fails with ErrUserInactive for a deactivated account.
+// It guards the operations a deactivated user may not start, such as loans and holds.
+func (db *DBStorage) activeUser(ctx context.Context, ID uuid.UUID) error {
+
+	log := logger.Get()
+
+	var deletedAt *time.Time
+
+	if err := db.pool.QueryRow(ctx, "SELECT DeletedAt FROM Users WHERE ID = $1", ID).Scan(&deletedAt); err != nil {
+
+		if errors.Is(err, pgx.ErrNoRows) {
+			return storageerror.ErrUserNotFound
+		}
+
+		log.Error().Err(err).Msg("Failed get data from table Users")
+		return err
+
+	}
+
+	if deletedAt != nil {
+		return storageerror.ErrUserInactive
+	}
+
+	return nil
+
+}
@@ -0,0 +1,106 @@
+package storage
+
+import (
+	"context"
+	"fmt"
+	"library/internal/domain/models"
+	"library/internal/logger"
+)
+
+func (db *DBStorage) SaveAudit(entry models.AuditStruct) error {
+
+	log := logger.Get()
+
+	ctx, cancel := context.WithTimeout(context.Background(), db.timeouts.Query)
+
+	defer cancel()
+
+	_, err := db.pool.Exec(ctx,
+		`INSERT INTO AuditLog (ID, Actor, Action, Entity, EntityID, Before, After, DateCreated)
+		VALUES ($1, NULLIF($2, ''), $3, $4, NULLIF($5, ''), $6, $7, $8)`,
+		entry.ID, entry.Actor, entry.Action, entry.Entity, entry.EntityID, entry.Before, entry.After, entry.DateCreated)
+
+	if err != nil {
+		log.Error().Err(err).Msg("Failed save audit entry")
+		return err
+	}
+
+	return nil
+
+}
+
+// GetAudit returns one page of the log, the from/to filter applies to the time of the entry.
+func (db *DBStorage) GetAudit(filter models.AuditFilterStruct) ([]models.AuditStruct, int, error) {
+
+	log := logger.Get()
+
+	ctx, cancel := context.WithTimeout(context.Background(), db.timeouts.Query)
+
+	defer cancel()
+
+	var conds []string
+	var args []any
+
+	for column, value := range map[string]string{
+		"Actor":    filter.Actor,
+		"Action":   filter.Action,
+		"Entity":   filter.Entity,
+		"EntityID": filter.EntityID,
+	} {
+
+		if value != "" {
+			args = append(args, value)
+			conds = append(conds, fmt.Sprintf("%s = $%d", column, len(args)))
+		}
+
+	}
+
+	conds, args = dateRange("DateCreated", filter.ListParamsStruct, conds, args)
+
+	where := whereClause(conds)
+
+	var total int
+
+	if err := db.pool.QueryRow(ctx, "SELECT count(*) FROM AuditLog"+where, args...).Scan(&total); err != nil {
+		log.Error().Err(err).Msg("Failed get data from table AuditLog")
+		return nil, 0, err
+	}
+
+	page, args := pageClause("DateCreated", filter.ListParamsStruct, args)
+
+	rows, err := db.pool.Query(ctx,
+		"SELECT ID, coalesce(Actor, ''), Action, Entity, coalesce(EntityID, ''), Before, After, DateCreated FROM AuditLog"+
+			where+page, args...)
+
+	if err != nil {
+		log.Error().Err(err).Msg("Failed get data from table AuditLog")
+		return nil, 0, err
+	}
+
+	defer rows.Close()
+
+	entries := []models.AuditStruct{}
+
+	for rows.Next() {
+
+		var entry models.AuditStruct
+
+		if err = rows.Scan(&entry.ID,
+			&entry.Actor,
+			&entry.Action,
+			&entry.Entity,
+			&entry.EntityID,
+			&entry.Before,
+			&entry.After,
+			&entry.DateCreated); err != nil {
+			log.Error().Err(err).Msg("Failed scan rows data")
+			return nil, 0, err
+		}
+
+		entries = append(entries, entry)
+
+	}
+
+	return entries, total, rows.Err()
+
+}
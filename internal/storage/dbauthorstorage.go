@@ -0,0 +1,326 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"library/internal/domain/models"
+	"library/internal/logger"
+	"library/internal/storage/storageerror"
+)
+
+const authorSelect = "SELECT a.ID, a.Name, a.DateBirth, a.DateDeath, a.Bio FROM Authors a"
+
+func scanAuthor(row pgx.Row, author *models.AuthorStruct) error {
+	return row.Scan(&author.ID, &author.Name, &author.DateBirth, &author.DateDeath, &author.Bio)
+}
+
+func (db *DBStorage) GetAuthors() ([]models.AuthorStruct, error) {
+
+	log := logger.Get()
+
+	ctx, cancel := context.WithTimeout(context.Background(), db.timeouts.Query)
+
+	defer cancel()
+
+	rows, err := db.pool.Query(ctx, authorSelect+" ORDER BY a.Name, a.ID")
+
+	if err != nil {
+		log.Error().Err(err).Msg("Failed get data from table Authors")
+		return nil, err
+	}
+
+	return collectAuthors(rows)
+
+}
+
+func (db *DBStorage) GetAuthor(id string) (models.AuthorStruct, error) {
+
+	log := logger.Get()
+
+	ctx, cancel := context.WithTimeout(context.Background(), db.timeouts.Query)
+
+	defer cancel()
+
+	authorDB := models.AuthorStruct{}
+
+	ID, err := parseID(id)
+
+	if err != nil {
+		log.Error().Err(err).Msg("Failed parse ID")
+		return authorDB, err
+	}
+
+	if err = scanAuthor(db.pool.QueryRow(ctx, authorSelect+" WHERE a.ID = $1", ID), &authorDB); err != nil {
+
+		if errors.Is(err, pgx.ErrNoRows) {
+			return authorDB, storageerror.ErrAuthorNotFound
+		}
+
+		log.Error().Err(err).Msg("Failed get data from table Authors")
+		return authorDB, err
+
+	}
+
+	return authorDB, nil
+
+}
+
+func (db *DBStorage) SaveAuthor(author models.AuthorStruct) (string, error) {
+
+	log := logger.Get()
+
+	ctx, cancel := context.WithTimeout(context.Background(), db.timeouts.Query)
+
+	defer cancel()
+
+	author.ID = uuid.New()
+
+	_, err := db.pool.Exec(ctx,
+		"INSERT INTO Authors (ID, Name, DateBirth, DateDeath, Bio) VALUES ($1, $2, $3, $4, $5)",
+		author.ID, author.Name, author.DateBirth, author.DateDeath, author.Bio)
+
+	if err != nil {
+		log.Error().Err(err).Msg("Failed save author")
+		return "", err
+	}
+
+	return author.ID.String(), nil
+
+}
+
+func (db *DBStorage) EditAuthor(id string, author models.AuthorStruct) error {
+
+	log := logger.Get()
+
+	ctx, cancel := context.WithTimeout(context.Background(), db.timeouts.Query)
+
+	defer cancel()
+
+	ID, err := parseID(id)
+
+	if err != nil {
+		log.Error().Err(err).Msg("Failed parse ID")
+		return err
+	}
+
+	tag, err := db.pool.Exec(ctx,
+		"UPDATE Authors SET Name = $1, DateBirth = $2, DateDeath = $3, Bio = $4 WHERE ID = $5",
+		author.Name, author.DateBirth, author.DateDeath, author.Bio, ID)
+
+	if err != nil {
+		log.Error().Err(err).Msg("Failed edit author")
+		return err
+	}
+
+	if tag.RowsAffected() == 0 {
+		return storageerror.ErrAuthorNotFound
+	}
+
+	return nil
+
+}
+
+func (db *DBStorage) DeleteAuthor(id string) error {
+
+	log := logger.Get()
+
+	ctx, cancel := context.WithTimeout(context.Background(), db.timeouts.Query)
+
+	defer cancel()
+
+	ID, err := parseID(id)
+
+	if err != nil {
+		log.Error().Err(err).Msg("Failed parse ID")
+		return err
+	}
+
+	var hasBooks bool
+
+	row := db.pool.QueryRow(ctx, "SELECT EXISTS(SELECT 1 FROM BookAuthors WHERE AuthorID = $1)", ID)
+
+	if err = row.Scan(&hasBooks); err != nil {
+		log.Error().Err(err).Msg("Failed get data from table BookAuthors")
+		return err
+	}
+
+	if hasBooks {
+		return storageerror.ErrAuthorHasBooks
+	}
+
+	tag, err := db.pool.Exec(ctx, "DELETE FROM Authors WHERE ID = $1", ID)
+
+	if err != nil {
+		log.Error().Err(err).Msg("Failed delete author")
+		return err
+	}
+
+	if tag.RowsAffected() == 0 {
+		return storageerror.ErrAuthorNotFound
+	}
+
+	return nil
+
+}
+
+// EnsureAuthor returns the author with exactly this name, creating it when there is none.
+// Books saved with a plain author line are linked through it.
+func (db *DBStorage) EnsureAuthor(name string) (string, error) {
+
+	log := logger.Get()
+
+	ctx, cancel := context.WithTimeout(context.Background(), db.timeouts.Query)
+
+	defer cancel()
+
+	var ID uuid.UUID
+
+	row := db.pool.QueryRow(ctx, "SELECT ID FROM Authors WHERE Name = $1 ORDER BY ID LIMIT 1", name)
+
+	err := row.Scan(&ID)
+
+	if err == nil {
+		return ID.String(), nil
+	}
+
+	if !errors.Is(err, pgx.ErrNoRows) {
+		log.Error().Err(err).Msg("Failed get data from table Authors")
+		return "", err
+	}
+
+	ID = uuid.New()
+
+	if _, err = db.pool.Exec(ctx, "INSERT INTO Authors (ID, Name) VALUES ($1, $2)", ID, name); err != nil {
+		log.Error().Err(err).Msg("Failed save author")
+		return "", err
+	}
+
+	return ID.String(), nil
+
+}
+
+func (db *DBStorage) GetBookAuthors(bookID string) ([]models.AuthorStruct, error) {
+
+	log := logger.Get()
+
+	ctx, cancel := context.WithTimeout(context.Background(), db.timeouts.Query)
+
+	defer cancel()
+
+	ID, err := parseID(bookID)
+
+	if err != nil {
+		log.Error().Err(err).Msg("Failed parse ID")
+		return nil, err
+	}
+
+	rows, err := db.pool.Query(ctx,
+		authorSelect+" JOIN BookAuthors ba ON ba.AuthorID = a.ID WHERE ba.BookID = $1 ORDER BY ba.Position", ID)
+
+	if err != nil {
+		log.Error().Err(err).Msg("Failed get data from table Authors")
+		return nil, err
+	}
+
+	return collectAuthors(rows)
+
+}
+
+// SetBookAuthors replaces the authors of the book, keeping the given order.
+func (db *DBStorage) SetBookAuthors(bookID string, authorIDs []string) error {
+
+	log := logger.Get()
+
+	ctx, cancel := context.WithTimeout(context.Background(), db.timeouts.Query)
+
+	defer cancel()
+
+	BID, err := parseID(bookID)
+
+	if err != nil {
+		log.Error().Err(err).Msg("Failed parse ID")
+		return err
+	}
+
+	tx, err := db.pool.Begin(ctx)
+
+	if err != nil {
+		log.Error().Err(err).Msg("Failed create transaction")
+		return err
+	}
+
+	defer func() {
+		_ = tx.Rollback(ctx)
+	}()
+
+	if _, err = tx.Exec(ctx, "DELETE FROM BookAuthors WHERE BookID = $1", BID); err != nil {
+		log.Error().Err(err).Msg("Failed delete book authors")
+		return err
+	}
+
+	for i, id := range authorIDs {
+
+		AID, err := parseID(id)
+
+		if err != nil {
+			log.Error().Err(err).Msg("Failed parse author ID")
+			return err
+		}
+
+		tag, err := tx.Exec(ctx,
+			`INSERT INTO BookAuthors (BookID, AuthorID, Position)
+			SELECT $1, ID, $3 FROM Authors WHERE ID = $2 ON CONFLICT DO NOTHING`, BID, AID, i)
+
+		if err != nil {
+			log.Error().Err(err).Msg("Failed save book author")
+			return err
+		}
+
+		if tag.RowsAffected() == 0 {
+
+			var exists bool
+
+			if err = tx.QueryRow(ctx, "SELECT EXISTS(SELECT 1 FROM Authors WHERE ID = $1)", AID).Scan(&exists); err != nil {
+				log.Error().Err(err).Msg("Failed get data from table Authors")
+				return err
+			}
+
+			// A repeated ID in the list is skipped, an unknown one is an error.
+			if !exists {
+				return storageerror.ErrAuthorNotFound
+			}
+
+		}
+
+	}
+
+	return tx.Commit(ctx)
+
+}
+
+func collectAuthors(rows pgx.Rows) ([]models.AuthorStruct, error) {
+
+	log := logger.Get()
+
+	defer rows.Close()
+
+	authors := []models.AuthorStruct{}
+
+	for rows.Next() {
+
+		var author models.AuthorStruct
+
+		if err := scanAuthor(rows, &author); err != nil {
+			log.Error().Err(err).Msg("Failed scan rows data")
+			return nil, err
+		}
+
+		authors = append(authors, author)
+
+	}
+
+	return authors, rows.Err()
+
+}
@@ -0,0 +1,329 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"github.com/google/uuid"
+	"github.com/jackc/pgerrcode"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"library/internal/domain/models"
+	"library/internal/logger"
+	"library/internal/storage/storageerror"
+	"time"
+)
+
+// A copy is free when it is neither on loan nor set aside for a ready hold.
+const (
+	copyLoaned   = `EXISTS(SELECT 1 FROM Loans l WHERE l.CopyID = c.ID AND l.DateReturn IS NULL)`
+	copyReserved = `EXISTS(SELECT 1 FROM Holds h WHERE h.CopyID = c.ID AND h.Status = 'ready')`
+	copyFree     = `NOT ` + copyLoaned + ` AND NOT ` + copyReserved
+
+	copySelect = `SELECT c.ID, c.BookID, c.Barcode, c.Condition, c.Location, c.DateAcquisition, ` +
+		copyFree + ` FROM Copies c`
+)
+
+func (db *DBStorage) GetCopies(bookID string) ([]models.CopyStruct, error) {
+
+	log := logger.Get()
+
+	ctx, cancel := context.WithTimeout(context.Background(), db.timeouts.Query)
+
+	defer cancel()
+
+	ID, err := parseID(bookID)
+
+	if err != nil {
+		log.Error().Err(err).Msg("Failed parse ID")
+		return nil, err
+	}
+
+	if err = db.bookExists(ctx, ID); err != nil {
+		return nil, err
+	}
+
+	rows, err := db.pool.Query(ctx, copySelect+" WHERE c.BookID = $1 ORDER BY c.Barcode", ID)
+
+	if err != nil {
+		log.Error().Err(err).Msg("Failed get data from table Copies")
+		return nil, err
+	}
+
+	defer rows.Close()
+
+	var copies []models.CopyStruct
+
+	for rows.Next() {
+
+		var cp models.CopyStruct
+
+		if err = rows.Scan(&cp.ID,
+			&cp.BookID,
+			&cp.Barcode,
+			&cp.Condition,
+			&cp.Location,
+			&cp.DateAcquisition,
+			&cp.Available); err != nil {
+			log.Error().Err(err).Msg("Failed scan rows data")
+			return nil, err
+		}
+
+		copies = append(copies, cp)
+
+	}
+
+	return copies, rows.Err()
+
+}
+
+func (db *DBStorage) GetCopy(bookID string, copyID string) (models.CopyStruct, error) {
+
+	log := logger.Get()
+
+	ctx, cancel := context.WithTimeout(context.Background(), db.timeouts.Query)
+
+	defer cancel()
+
+	copyDB := models.CopyStruct{}
+
+	BID, err := parseID(bookID)
+
+	if err != nil {
+		log.Error().Err(err).Msg("Failed parse book ID")
+		return copyDB, err
+	}
+
+	CID, err := parseID(copyID)
+
+	if err != nil {
+		log.Error().Err(err).Msg("Failed parse copy ID")
+		return copyDB, err
+	}
+
+	row := db.pool.QueryRow(ctx, copySelect+" WHERE c.ID = $1 AND c.BookID = $2", CID, BID)
+
+	if err = row.Scan(&copyDB.ID,
+		&copyDB.BookID,
+		&copyDB.Barcode,
+		&copyDB.Condition,
+		&copyDB.Location,
+		&copyDB.DateAcquisition,
+		&copyDB.Available); err != nil {
+
+		if errors.Is(err, pgx.ErrNoRows) {
+			return copyDB, storageerror.ErrCopyNotFound
+		}
+
+		log.Error().Err(err).Msg("Failed get data from table Copies")
+		return copyDB, err
+
+	}
+
+	return copyDB, nil
+
+}
+
+func (db *DBStorage) SaveCopy(bookID string, cp models.CopyStruct) (string, error) {
+
+	log := logger.Get()
+
+	ctx, cancel := context.WithTimeout(context.Background(), db.timeouts.Query)
+
+	defer cancel()
+
+	BID, err := parseID(bookID)
+
+	if err != nil {
+		log.Error().Err(err).Msg("Failed parse ID")
+		return "", err
+	}
+
+	if err = db.bookExists(ctx, BID); err != nil {
+		return "", err
+	}
+
+	cp.ID = uuid.New()
+	cp.BookID = BID
+
+	if cp.DateAcquisition.IsZero() {
+		cp.DateAcquisition = time.Now()
+	}
+
+	_, err = db.pool.Exec(ctx,
+		"INSERT INTO Copies (ID, BookID, Barcode, Condition, Location, DateAcquisition) VALUES ($1, $2, $3, $4, $5, $6)",
+		cp.ID, cp.BookID, cp.Barcode, cp.Condition, cp.Location, cp.DateAcquisition)
+
+	if err != nil {
+
+		var pgErr *pgconn.PgError
+
+		if errors.As(err, &pgErr) {
+
+			if pgErr.Code == pgerrcode.UniqueViolation {
+				return "", storageerror.ErrCopyAlreadyExist
+			}
+
+		}
+
+		log.Error().Err(err).Msg("Failed save copy")
+
+		return "", err
+
+	}
+
+	return cp.ID.String(), nil
+
+}
+
+func (db *DBStorage) EditCopy(bookID string, copyID string, cp models.CopyStruct) error {
+
+	log := logger.Get()
+
+	ctx, cancel := context.WithTimeout(context.Background(), db.timeouts.Query)
+
+	defer cancel()
+
+	BID, err := parseID(bookID)
+
+	if err != nil {
+		log.Error().Err(err).Msg("Failed parse book ID")
+		return err
+	}
+
+	CID, err := parseID(copyID)
+
+	if err != nil {
+		log.Error().Err(err).Msg("Failed parse copy ID")
+		return err
+	}
+
+	var dateAcquisition time.Time
+
+	row := db.pool.QueryRow(ctx, "SELECT DateAcquisition FROM Copies WHERE ID = $1 AND BookID = $2", CID, BID)
+
+	if err = row.Scan(&dateAcquisition); err != nil {
+
+		if errors.Is(err, pgx.ErrNoRows) {
+			return storageerror.ErrCopyNotFound
+		}
+
+		log.Error().Err(err).Msg("Failed get data from table Copies")
+		return err
+
+	}
+
+	if cp.DateAcquisition.IsZero() {
+		cp.DateAcquisition = dateAcquisition
+	}
+
+	_, err = db.pool.Exec(ctx,
+		"UPDATE Copies SET Barcode = $1, Condition = $2, Location = $3, DateAcquisition = $4 WHERE ID = $5",
+		cp.Barcode, cp.Condition, cp.Location, cp.DateAcquisition, CID)
+
+	if err != nil {
+
+		var pgErr *pgconn.PgError
+
+		if errors.As(err, &pgErr) {
+
+			if pgErr.Code == pgerrcode.UniqueViolation {
+				return storageerror.ErrCopyAlreadyExist
+			}
+
+		}
+
+		log.Error().Err(err).Msg("Failed edit copy")
+
+		return err
+
+	}
+
+	return nil
+
+}
+
+func (db *DBStorage) DeleteCopy(bookID string, copyID string) error {
+
+	log := logger.Get()
+
+	ctx, cancel := context.WithTimeout(context.Background(), db.timeouts.Query)
+
+	defer cancel()
+
+	BID, err := parseID(bookID)
+
+	if err != nil {
+		log.Error().Err(err).Msg("Failed parse book ID")
+		return err
+	}
+
+	CID, err := parseID(copyID)
+
+	if err != nil {
+		log.Error().Err(err).Msg("Failed parse copy ID")
+		return err
+	}
+
+	var loaned, reserved bool
+
+	row := db.pool.QueryRow(ctx,
+		"SELECT "+copyLoaned+", "+copyReserved+" FROM Copies c WHERE c.ID = $1 AND c.BookID = $2", CID, BID)
+
+	if err = row.Scan(&loaned, &reserved); err != nil {
+
+		if errors.Is(err, pgx.ErrNoRows) {
+			return storageerror.ErrCopyNotFound
+		}
+
+		log.Error().Err(err).Msg("Failed get data from table Copies")
+		return err
+
+	}
+
+	if loaned {
+		return storageerror.ErrCopyAlreadyLoaned
+	}
+
+	if reserved {
+		return storageerror.ErrCopyReserved
+	}
+
+	_, err = db.pool.Exec(ctx, "DELETE FROM Copies WHERE ID = $1", CID)
+
+	if err != nil {
+		log.Error().Err(err).Msg("Failed delete copy")
+		return err
+	}
+
+	return nil
+
+}
+
+func (db *DBStorage) GetBookAvailability(bookID string) (models.AvailabilityStruct, error) {
+
+	log := logger.Get()
+
+	ctx, cancel := context.WithTimeout(context.Background(), db.timeouts.Query)
+
+	defer cancel()
+
+	var availability models.AvailabilityStruct
+
+	ID, err := parseID(bookID)
+
+	if err != nil {
+		log.Error().Err(err).Msg("Failed parse ID")
+		return availability, err
+	}
+
+	row := db.pool.QueryRow(ctx,
+		"SELECT count(*), count(*) FILTER (WHERE "+copyFree+") FROM Copies c WHERE c.BookID = $1", ID)
+
+	if err = row.Scan(&availability.Total, &availability.Available); err != nil {
+		log.Error().Err(err).Msg("Failed get data from table Copies")
+		return availability, err
+	}
+
+	return availability, nil
+
+}
@@ -0,0 +1,117 @@
+package storage
+
+import (
+	"context"
+	"fmt"
+	"github.com/jackc/pgx/v5"
+	"library/internal/domain/models"
+	"library/internal/logger"
+)
+
+const exportFetchSize = 500
+
+// ExportBooks passes every book to fn in name order. Books are read through a server-side cursor,
+// exportFetchSize rows at a time, so the catalogue is never held in memory.
+func (db *DBStorage) ExportBooks(ctx context.Context, fn func(models.BookStruct) error) error {
+
+	return db.exportCursor(ctx, "books_export", bookSelect+" WHERE DeletedAt IS NULL ORDER BY Name, ID", func(rows pgx.Rows) error {
+
+		var book models.BookStruct
+
+		if err := scanBook(rows, &book); err != nil {
+			return err
+		}
+
+		return fn(book)
+
+	})
+
+}
+
+// ExportUsers is ExportBooks for users. The password hash is not read.
+func (db *DBStorage) ExportUsers(ctx context.Context, fn func(models.UserStruct) error) error {
+
+	return db.exportCursor(ctx, "users_export",
+		"SELECT ID, Name, Email, Age, Role, DateRegistration FROM Users ORDER BY Name, ID", func(rows pgx.Rows) error {
+
+			var user models.UserStruct
+
+			if err := rows.Scan(&user.ID,
+				&user.Name,
+				&user.Email,
+				&user.Age,
+				&user.Role,
+				&user.DateRegistration); err != nil {
+				return err
+			}
+
+			return fn(user)
+
+		})
+
+}
+
+// exportCursor declares a cursor for query and calls scan for every row until the cursor is exhausted.
+// A cursor lives only inside a transaction, the transaction is read-only and always rolled back.
+func (db *DBStorage) exportCursor(ctx context.Context, name string, query string, scan func(pgx.Rows) error) error {
+
+	log := logger.Get()
+
+	ctx, cancel := context.WithTimeout(ctx, db.timeouts.Export)
+
+	defer cancel()
+
+	tx, err := db.pool.BeginTx(ctx, pgx.TxOptions{AccessMode: pgx.ReadOnly})
+
+	if err != nil {
+		log.Error().Err(err).Msg("Failed create transaction")
+		return err
+	}
+
+	defer func() {
+		_ = tx.Rollback(ctx)
+	}()
+
+	if _, err = tx.Exec(ctx, "DECLARE "+name+" NO SCROLL CURSOR FOR "+query); err != nil {
+		log.Error().Err(err).Str("cursor", name).Msg("Failed declare cursor")
+		return err
+	}
+
+	fetch := fmt.Sprintf("FETCH FORWARD %d FROM %s", exportFetchSize, name)
+
+	for {
+
+		rows, err := tx.Query(ctx, fetch)
+
+		if err != nil {
+			log.Error().Err(err).Str("cursor", name).Msg("Failed fetch from cursor")
+			return err
+		}
+
+		count := 0
+
+		for rows.Next() {
+
+			count++
+
+			if err = scan(rows); err != nil {
+				rows.Close()
+				return err
+			}
+
+		}
+
+		rows.Close()
+
+		if err = rows.Err(); err != nil {
+			log.Error().Err(err).Str("cursor", name).Msg("Failed fetch from cursor")
+			return err
+		}
+
+		if count < exportFetchSize {
+			return nil
+		}
+
+	}
+
+}
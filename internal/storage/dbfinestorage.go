@@ -0,0 +1,90 @@
+package storage
+
+import (
+	"context"
+	"github.com/google/uuid"
+	"library/internal/domain/models"
+	"library/internal/logger"
+)
+
+const fineInsert = "INSERT INTO Fines (ID, UserID, LoanID, Type, Amount, Note) VALUES ($1, $2, $3, $4, $5, $6)"
+
+func (db *DBStorage) SaveFine(fine models.FineStruct) (string, error) {
+
+	log := logger.Get()
+
+	ctx, cancel := context.WithTimeout(context.Background(), db.timeouts.Query)
+
+	defer cancel()
+
+	if err := db.userExists(ctx, fine.UserID); err != nil {
+		return "", err
+	}
+
+	fine.ID = uuid.New()
+
+	_, err := db.pool.Exec(ctx, fineInsert, fine.ID, fine.UserID, fine.LoanID, fine.Type, fine.Amount, fine.Note)
+
+	if err != nil {
+		log.Error().Err(err).Msg("Failed save fine")
+		return "", err
+	}
+
+	return fine.ID.String(), nil
+
+}
+
+func (db *DBStorage) GetUserFines(userID string) ([]models.FineStruct, error) {
+
+	log := logger.Get()
+
+	ctx, cancel := context.WithTimeout(context.Background(), db.timeouts.Query)
+
+	defer cancel()
+
+	ID, err := parseID(userID)
+
+	if err != nil {
+		log.Error().Err(err).Msg("Failed parse ID")
+		return nil, err
+	}
+
+	if err = db.userExists(ctx, ID); err != nil {
+		return nil, err
+	}
+
+	rows, err := db.pool.Query(ctx,
+		"SELECT ID, UserID, LoanID, Type, Amount, Note, DateCreated FROM Fines WHERE UserID = $1 ORDER BY DateCreated",
+		ID)
+
+	if err != nil {
+		log.Error().Err(err).Msg("Failed get data from table Fines")
+		return nil, err
+	}
+
+	defer rows.Close()
+
+	var fines []models.FineStruct
+
+	for rows.Next() {
+
+		var fine models.FineStruct
+
+		if err = rows.Scan(&fine.ID,
+			&fine.UserID,
+			&fine.LoanID,
+			&fine.Type,
+			&fine.Amount,
+			&fine.Note,
+			&fine.DateCreated); err != nil {
+			log.Error().Err(err).Msg("Failed scan rows data")
+			return nil, err
+		}
+
+		fines = append(fines, fine)
+
+	}
+
+	return fines, rows.Err()
+
+}
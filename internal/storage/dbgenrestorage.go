@@ -0,0 +1,340 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/google/uuid"
+	"github.com/jackc/pgerrcode"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"library/internal/domain/models"
+	"library/internal/logger"
+	"library/internal/storage/storageerror"
+)
+
+const genreSelect = "SELECT g.ID, g.Name, g.ParentID FROM Genres g"
+
+// genreSubtree selects the genre given by the parameter and all genres below it.
+const genreSubtree = `WITH RECURSIVE sub AS (
+	SELECT ID FROM Genres WHERE ID = %s
+	UNION SELECT g.ID FROM Genres g JOIN sub ON g.ParentID = sub.ID
+) SELECT ID FROM sub`
+
+func (db *DBStorage) GetGenres() ([]models.GenreStruct, error) {
+
+	log := logger.Get()
+
+	ctx, cancel := context.WithTimeout(context.Background(), db.timeouts.Query)
+
+	defer cancel()
+
+	rows, err := db.pool.Query(ctx, genreSelect+" ORDER BY g.Name, g.ID")
+
+	if err != nil {
+		log.Error().Err(err).Msg("Failed get data from table Genres")
+		return nil, err
+	}
+
+	return collectGenres(rows)
+
+}
+
+func (db *DBStorage) GetGenre(id string) (models.GenreStruct, error) {
+
+	log := logger.Get()
+
+	ctx, cancel := context.WithTimeout(context.Background(), db.timeouts.Query)
+
+	defer cancel()
+
+	genreDB := models.GenreStruct{}
+
+	ID, err := parseID(id)
+
+	if err != nil {
+		log.Error().Err(err).Msg("Failed parse ID")
+		return genreDB, err
+	}
+
+	row := db.pool.QueryRow(ctx, genreSelect+" WHERE g.ID = $1", ID)
+
+	if err = row.Scan(&genreDB.ID, &genreDB.Name, &genreDB.ParentID); err != nil {
+
+		if errors.Is(err, pgx.ErrNoRows) {
+			return genreDB, storageerror.ErrGenreNotFound
+		}
+
+		log.Error().Err(err).Msg("Failed get data from table Genres")
+		return genreDB, err
+
+	}
+
+	return genreDB, nil
+
+}
+
+func (db *DBStorage) SaveGenre(genre models.GenreStruct) (string, error) {
+
+	ctx, cancel := context.WithTimeout(context.Background(), db.timeouts.Query)
+
+	defer cancel()
+
+	genre.ID = uuid.New()
+
+	_, err := db.pool.Exec(ctx, "INSERT INTO Genres (ID, Name, ParentID) VALUES ($1, $2, $3)",
+		genre.ID, genre.Name, genre.ParentID)
+
+	if err != nil {
+		return "", genreError(err, "Failed save genre")
+	}
+
+	return genre.ID.String(), nil
+
+}
+
+func (db *DBStorage) EditGenre(id string, genre models.GenreStruct) error {
+
+	log := logger.Get()
+
+	ctx, cancel := context.WithTimeout(context.Background(), db.timeouts.Query)
+
+	defer cancel()
+
+	ID, err := parseID(id)
+
+	if err != nil {
+		log.Error().Err(err).Msg("Failed parse ID")
+		return err
+	}
+
+	if genre.ParentID != nil {
+
+		var cycle bool
+
+		row := db.pool.QueryRow(ctx,
+			"SELECT $2 IN ("+fmt.Sprintf(genreSubtree, "$1")+")", ID, *genre.ParentID)
+
+		if err = row.Scan(&cycle); err != nil {
+			log.Error().Err(err).Msg("Failed get data from table Genres")
+			return err
+		}
+
+		if cycle {
+			return storageerror.ErrGenreCycle
+		}
+
+	}
+
+	tag, err := db.pool.Exec(ctx, "UPDATE Genres SET Name = $1, ParentID = $2 WHERE ID = $3",
+		genre.Name, genre.ParentID, ID)
+
+	if err != nil {
+		return genreError(err, "Failed edit genre")
+	}
+
+	if tag.RowsAffected() == 0 {
+		return storageerror.ErrGenreNotFound
+	}
+
+	return nil
+
+}
+
+func (db *DBStorage) DeleteGenre(id string) error {
+
+	log := logger.Get()
+
+	ctx, cancel := context.WithTimeout(context.Background(), db.timeouts.Query)
+
+	defer cancel()
+
+	ID, err := parseID(id)
+
+	if err != nil {
+		log.Error().Err(err).Msg("Failed parse ID")
+		return err
+	}
+
+	tag, err := db.pool.Exec(ctx, "DELETE FROM Genres WHERE ID = $1", ID)
+
+	if err != nil {
+
+		var pgErr *pgconn.PgError
+
+		if errors.As(err, &pgErr) && pgErr.Code == pgerrcode.ForeignKeyViolation {
+			return storageerror.ErrGenreHasChildren
+		}
+
+		log.Error().Err(err).Msg("Failed delete genre")
+
+		return err
+
+	}
+
+	if tag.RowsAffected() == 0 {
+		return storageerror.ErrGenreNotFound
+	}
+
+	return nil
+
+}
+
+func (db *DBStorage) GetBookGenres(bookID string) ([]models.GenreStruct, error) {
+
+	log := logger.Get()
+
+	ctx, cancel := context.WithTimeout(context.Background(), db.timeouts.Query)
+
+	defer cancel()
+
+	ID, err := parseID(bookID)
+
+	if err != nil {
+		log.Error().Err(err).Msg("Failed parse ID")
+		return nil, err
+	}
+
+	rows, err := db.pool.Query(ctx,
+		genreSelect+" JOIN BookGenres bg ON bg.GenreID = g.ID WHERE bg.BookID = $1 ORDER BY g.Name", ID)
+
+	if err != nil {
+		log.Error().Err(err).Msg("Failed get data from table Genres")
+		return nil, err
+	}
+
+	return collectGenres(rows)
+
+}
+
+func (db *DBStorage) AddBookGenre(bookID string, genreID string) error {
+
+	log := logger.Get()
+
+	ctx, cancel := context.WithTimeout(context.Background(), db.timeouts.Query)
+
+	defer cancel()
+
+	BID, err := parseID(bookID)
+
+	if err != nil {
+		log.Error().Err(err).Msg("Failed parse book ID")
+		return err
+	}
+
+	GID, err := parseID(genreID)
+
+	if err != nil {
+		log.Error().Err(err).Msg("Failed parse genre ID")
+		return err
+	}
+
+	if err = db.bookExists(ctx, BID); err != nil {
+		return err
+	}
+
+	tag, err := db.pool.Exec(ctx,
+		"INSERT INTO BookGenres (BookID, GenreID) SELECT $1, ID FROM Genres WHERE ID = $2 ON CONFLICT DO NOTHING",
+		BID, GID)
+
+	if err != nil {
+		log.Error().Err(err).Msg("Failed save book genre")
+		return err
+	}
+
+	if tag.RowsAffected() == 0 {
+
+		if _, err = db.GetGenre(genreID); err != nil {
+			return err
+		}
+
+	}
+
+	return nil
+
+}
+
+func (db *DBStorage) RemoveBookGenre(bookID string, genreID string) error {
+
+	log := logger.Get()
+
+	ctx, cancel := context.WithTimeout(context.Background(), db.timeouts.Query)
+
+	defer cancel()
+
+	BID, err := parseID(bookID)
+
+	if err != nil {
+		log.Error().Err(err).Msg("Failed parse book ID")
+		return err
+	}
+
+	GID, err := parseID(genreID)
+
+	if err != nil {
+		log.Error().Err(err).Msg("Failed parse genre ID")
+		return err
+	}
+
+	tag, err := db.pool.Exec(ctx, "DELETE FROM BookGenres WHERE BookID = $1 AND GenreID = $2", BID, GID)
+
+	if err != nil {
+		log.Error().Err(err).Msg("Failed delete book genre")
+		return err
+	}
+
+	if tag.RowsAffected() == 0 {
+		return storageerror.ErrGenreNotFound
+	}
+
+	return nil
+
+}
+
+func genreError(err error, msg string) error {
+
+	log := logger.Get()
+
+	var pgErr *pgconn.PgError
+
+	if errors.As(err, &pgErr) {
+
+		switch pgErr.Code {
+		case pgerrcode.UniqueViolation:
+			return storageerror.ErrGenreAlreadyExist
+		case pgerrcode.ForeignKeyViolation:
+			return storageerror.ErrGenreNotFound
+		}
+
+	}
+
+	log.Error().Err(err).Msg(msg)
+
+	return err
+
+}
+
+func collectGenres(rows pgx.Rows) ([]models.GenreStruct, error) {
+
+	log := logger.Get()
+
+	defer rows.Close()
+
+	genres := []models.GenreStruct{}
+
+	for rows.Next() {
+
+		var genre models.GenreStruct
+
+		if err := rows.Scan(&genre.ID, &genre.Name, &genre.ParentID); err != nil {
+			log.Error().Err(err).Msg("Failed scan rows data")
+			return nil, err
+		}
+
+		genres = append(genres, genre)
+
+	}
+
+	return genres, rows.Err()
+
+}
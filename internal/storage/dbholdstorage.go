@@ -0,0 +1,343 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"github.com/google/uuid"
+	"github.com/jackc/pgerrcode"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"library/internal/domain/models"
+	"library/internal/logger"
+	"library/internal/storage/storageerror"
+	"time"
+)
+
+const holdSelect = "SELECT ID, UserID, BookID, CopyID, Status, DatePlaced, DateExpire FROM Holds"
+
+func scanHold(row pgx.Row, hold *models.HoldStruct) error {
+	return row.Scan(&hold.ID,
+		&hold.UserID,
+		&hold.BookID,
+		&hold.CopyID,
+		&hold.Status,
+		&hold.DatePlaced,
+		&hold.DateExpire)
+}
+
+func (db *DBStorage) PlaceHold(hold models.HoldPlaceStruct) (string, error) {
+
+	log := logger.Get()
+
+	ctx, cancel := context.WithTimeout(context.Background(), db.timeouts.Query)
+
+	defer cancel()
+
+	userID, err := parseID(hold.UserID)
+
+	if err != nil {
+		log.Error().Err(err).Msg("Failed parse user ID")
+		return "", err
+	}
+
+	bookID, err := parseID(hold.BookID)
+
+	if err != nil {
+		log.Error().Err(err).Msg("Failed parse book ID")
+		return "", err
+	}
+
+	if err = db.activeUser(ctx, userID); err != nil {
+		return "", err
+	}
+
+	if err = db.bookExists(ctx, bookID); err != nil {
+		return "", err
+	}
+
+	var available bool
+
+	row := db.pool.QueryRow(ctx,
+		"SELECT EXISTS(SELECT 1 FROM Copies c WHERE c.BookID = $1 AND "+copyFree+")", bookID)
+
+	if err = row.Scan(&available); err != nil {
+		log.Error().Err(err).Msg("Failed get data from table Copies")
+		return "", err
+	}
+
+	if available {
+		return "", storageerror.ErrBookAvailable
+	}
+
+	ID := uuid.New()
+
+	_, err = db.pool.Exec(ctx, "INSERT INTO Holds (ID, UserID, BookID, Status) VALUES ($1, $2, $3, $4)",
+		ID, userID, bookID, models.HoldWaiting)
+
+	if err != nil {
+
+		var pgErr *pgconn.PgError
+
+		if errors.As(err, &pgErr) {
+
+			if pgErr.Code == pgerrcode.UniqueViolation {
+				return "", storageerror.ErrHoldAlreadyExist
+			}
+
+		}
+
+		log.Error().Err(err).Msg("Failed save hold")
+
+		return "", err
+
+	}
+
+	return ID.String(), nil
+
+}
+
+func (db *DBStorage) GetHold(id string) (models.HoldStruct, error) {
+
+	log := logger.Get()
+
+	ctx, cancel := context.WithTimeout(context.Background(), db.timeouts.Query)
+
+	defer cancel()
+
+	holdDB := models.HoldStruct{}
+
+	ID, err := parseID(id)
+
+	if err != nil {
+		log.Error().Err(err).Msg("Failed parse ID")
+		return holdDB, err
+	}
+
+	if err = scanHold(db.pool.QueryRow(ctx, holdSelect+" WHERE ID = $1", ID), &holdDB); err != nil {
+
+		if errors.Is(err, pgx.ErrNoRows) {
+			return holdDB, storageerror.ErrHoldNotFound
+		}
+
+		log.Error().Err(err).Msg("Failed get data from table Holds")
+		return holdDB, err
+
+	}
+
+	return holdDB, nil
+
+}
+
+func (db *DBStorage) CancelHold(id string) (models.HoldStruct, error) {
+
+	log := logger.Get()
+
+	ctx, cancel := context.WithTimeout(context.Background(), db.timeouts.Query)
+
+	defer cancel()
+
+	holdDB := models.HoldStruct{}
+
+	ID, err := parseID(id)
+
+	if err != nil {
+		log.Error().Err(err).Msg("Failed parse ID")
+		return holdDB, err
+	}
+
+	if err = scanHold(db.pool.QueryRow(ctx, holdSelect+" WHERE ID = $1", ID), &holdDB); err != nil {
+
+		if errors.Is(err, pgx.ErrNoRows) {
+			return holdDB, storageerror.ErrHoldNotFound
+		}
+
+		log.Error().Err(err).Msg("Failed get data from table Holds")
+		return holdDB, err
+
+	}
+
+	if holdDB.Status != models.HoldWaiting && holdDB.Status != models.HoldReady {
+		return holdDB, storageerror.ErrHoldNotActive
+	}
+
+	_, err = db.pool.Exec(ctx, "UPDATE Holds SET Status = $1 WHERE ID = $2", models.HoldCancelled, ID)
+
+	if err != nil {
+		log.Error().Err(err).Msg("Failed cancel hold")
+		return holdDB, err
+	}
+
+	return holdDB, nil
+
+}
+
+func (db *DBStorage) GetUserHolds(userID string) ([]models.HoldStruct, error) {
+	return db.getActiveHolds("UserID", userID)
+}
+
+func (db *DBStorage) GetBookHolds(bookID string) ([]models.HoldStruct, error) {
+	return db.getActiveHolds("BookID", bookID)
+}
+
+// column is never taken from user input, only from the two wrappers above.
+func (db *DBStorage) getActiveHolds(column string, id string) ([]models.HoldStruct, error) {
+
+	log := logger.Get()
+
+	ctx, cancel := context.WithTimeout(context.Background(), db.timeouts.Query)
+
+	defer cancel()
+
+	ID, err := parseID(id)
+
+	if err != nil {
+		log.Error().Err(err).Msg("Failed parse ID")
+		return nil, err
+	}
+
+	rows, err := db.pool.Query(ctx,
+		holdSelect+" WHERE "+column+" = $1 AND Status IN ($2, $3) ORDER BY DatePlaced",
+		ID, models.HoldWaiting, models.HoldReady)
+
+	if err != nil {
+		log.Error().Err(err).Msg("Failed get data from table Holds")
+		return nil, err
+	}
+
+	defer rows.Close()
+
+	var holds []models.HoldStruct
+
+	for rows.Next() {
+
+		var hold models.HoldStruct
+
+		if err = scanHold(rows, &hold); err != nil {
+			log.Error().Err(err).Msg("Failed scan rows data")
+			return nil, err
+		}
+
+		holds = append(holds, hold)
+
+	}
+
+	return holds, rows.Err()
+
+}
+
+// AllocateCopy sets a free copy aside for the oldest waiting hold on its book.
+// Nothing happens if the copy is not free or nobody is waiting.
+func (db *DBStorage) AllocateCopy(copyID string, expire time.Time) error {
+
+	log := logger.Get()
+
+	ctx, cancel := context.WithTimeout(context.Background(), db.timeouts.Query)
+
+	defer cancel()
+
+	CID, err := parseID(copyID)
+
+	if err != nil {
+		log.Error().Err(err).Msg("Failed parse ID")
+		return err
+	}
+
+	tx, err := db.pool.Begin(ctx)
+
+	if err != nil {
+		log.Error().Err(err).Msg("Failed create transaction")
+		return err
+	}
+
+	defer func() {
+		_ = tx.Rollback(ctx)
+	}()
+
+	if err = allocateCopy(ctx, tx, CID, expire); err != nil {
+		return err
+	}
+
+	if err = tx.Commit(ctx); err != nil {
+		log.Error().Err(err).Msg("Failed commit transaction")
+		return err
+	}
+
+	return nil
+
+}
+
+// allocateCopy is AllocateCopy inside the caller's transaction.
+func allocateCopy(ctx context.Context, tx pgx.Tx, CID uuid.UUID, expire time.Time) error {
+
+	log := logger.Get()
+
+	var holdID uuid.UUID
+
+	row := tx.QueryRow(ctx,
+		`SELECT h.ID FROM Holds h JOIN Copies c ON c.BookID = h.BookID
+		WHERE c.ID = $1 AND h.Status = $2 AND `+copyFree+`
+		ORDER BY h.DatePlaced LIMIT 1`, CID, models.HoldWaiting)
+
+	if err := row.Scan(&holdID); err != nil {
+
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil
+		}
+
+		log.Error().Err(err).Msg("Failed get data from table Holds")
+		return err
+
+	}
+
+	_, err := tx.Exec(ctx, "UPDATE Holds SET Status = $1, CopyID = $2, DateExpire = $3 WHERE ID = $4",
+		models.HoldReady, CID, expire, holdID)
+
+	if err != nil {
+		log.Error().Err(err).Msg("Failed allocate copy")
+		return err
+	}
+
+	return nil
+
+}
+
+// ExpireHolds marks ready holds past their expiry as expired and returns the copies they released.
+func (db *DBStorage) ExpireHolds(now time.Time) ([]string, error) {
+
+	log := logger.Get()
+
+	ctx, cancel := context.WithTimeout(context.Background(), db.timeouts.Query)
+
+	defer cancel()
+
+	rows, err := db.pool.Query(ctx,
+		"UPDATE Holds SET Status = $1 WHERE Status = $2 AND DateExpire < $3 RETURNING CopyID",
+		models.HoldExpired, models.HoldReady, now)
+
+	if err != nil {
+		log.Error().Err(err).Msg("Failed expire holds")
+		return nil, err
+	}
+
+	defer rows.Close()
+
+	var copies []string
+
+	for rows.Next() {
+
+		var copyID *uuid.UUID
+
+		if err = rows.Scan(&copyID); err != nil {
+			log.Error().Err(err).Msg("Failed scan rows data")
+			return nil, err
+		}
+
+		if copyID != nil {
+			copies = append(copies, copyID.String())
+		}
+
+	}
+
+	return copies, rows.Err()
+
+}
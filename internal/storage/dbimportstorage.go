@@ -0,0 +1,153 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"github.com/google/uuid"
+	"github.com/jackc/pgerrcode"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"library/internal/domain/models"
+	"library/internal/logger"
+)
+
+// ImportBooks saves the rows that have no status yet, batch rows per transaction, and marks each
+// row created or duplicate. Every row runs in its own savepoint, so a duplicate does not abort the batch.
+// An error stops the import: the batches committed before it keep their marks, the rows of the failed
+// batch and of the ones after it are left without status.
+func (db *DBStorage) ImportBooks(ctx context.Context, rows []models.ImportRowStruct, batch int) error {
+
+	for start := 0; start < len(rows); start += batch {
+
+		batchRows := rows[start:min(start+batch, len(rows))]
+
+		if err := db.importBatch(ctx, batchRows); err != nil {
+
+			// The transaction was rolled back, nothing of the batch was saved.
+			for i := range batchRows {
+
+				if batchRows[i].Status == models.ImportCreated || batchRows[i].Status == models.ImportDuplicate {
+					batchRows[i].Status, batchRows[i].ID = "", ""
+				}
+
+			}
+
+			return err
+
+		}
+
+	}
+
+	return nil
+
+}
+
+func (db *DBStorage) importBatch(ctx context.Context, rows []models.ImportRowStruct) error {
+
+	log := logger.Get()
+
+	ctx, cancel := context.WithTimeout(ctx, db.timeouts.Import)
+
+	defer cancel()
+
+	tx, err := db.pool.Begin(ctx)
+
+	if err != nil {
+		log.Error().Err(err).Msg("Failed create transaction")
+		return err
+	}
+
+	defer func() {
+		_ = tx.Rollback(ctx)
+	}()
+
+	for i := range rows {
+
+		if rows[i].Status != "" {
+			continue
+		}
+
+		if err = importBook(ctx, tx, &rows[i]); err != nil {
+			log.Error().Err(err).Int("row", rows[i].Row).Msg("Failed import book")
+			return err
+		}
+
+	}
+
+	if err = tx.Commit(ctx); err != nil {
+		log.Error().Err(err).Msg("Failed commit transaction")
+		return err
+	}
+
+	return nil
+
+}
+
+// importBook applies the duplicate rule of SaveBook inside the batch transaction.
+func importBook(ctx context.Context, tx pgx.Tx, row *models.ImportRowStruct) error {
+
+	book := row.Book
+
+	var IDTemp uuid.UUID
+
+	var check pgx.Row
+
+	if book.ISBN13 != "" {
+		check = tx.QueryRow(ctx, "SELECT ID FROM Books WHERE ISBN13 = $1 AND DeletedAt IS NULL", book.ISBN13)
+	} else {
+		check = tx.QueryRow(ctx, "SELECT ID FROM Books WHERE Name = $1 and Author = $2 AND DeletedAt IS NULL", book.Name, book.Author)
+	}
+
+	err := check.Scan(&IDTemp)
+
+	if err == nil {
+		row.Status, row.ID = models.ImportDuplicate, IDTemp.String()
+		return nil
+	}
+
+	if !errors.Is(err, pgx.ErrNoRows) {
+		return err
+	}
+
+	savepoint, err := tx.Begin(ctx)
+
+	if err != nil {
+		return err
+	}
+
+	book.ID = uuid.New()
+
+	_, err = savepoint.Exec(ctx,
+		`INSERT INTO Books (ID, Name, Description, Author, ISBN10, ISBN13, DateWriting)
+		VALUES ($1, $2, $3, $4, NULLIF($5, ''), NULLIF($6, ''), $7)`,
+		book.ID, book.Name, book.Description, book.Author, book.ISBN10, book.ISBN13, book.DateWriting)
+
+	if err != nil {
+
+		_ = savepoint.Rollback(ctx)
+
+		var pgErr *pgconn.PgError
+
+		if errors.As(err, &pgErr) && pgErr.Code == pgerrcode.UniqueViolation {
+			row.Status = models.ImportDuplicate
+			return nil
+		}
+
+		return err
+
+	}
+
+	if err = saveRevision(ctx, savepoint, book.ID); err != nil {
+		_ = savepoint.Rollback(ctx)
+		return err
+	}
+
+	if err = savepoint.Commit(ctx); err != nil {
+		return err
+	}
+
+	row.Status, row.ID = models.ImportCreated, book.ID.String()
+
+	return nil
+
+}
@@ -0,0 +1,445 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"github.com/google/uuid"
+	"github.com/jackc/pgerrcode"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"library/internal/domain/models"
+	"library/internal/logger"
+	"library/internal/storage/storageerror"
+	"time"
+)
+
+const loanSelect = "SELECT ID, UserID, BookID, CopyID, DateCheckout, DateDue, DateReturn, Renewals FROM Loans"
+
+func scanLoan(row pgx.Row, loan *models.LoanStruct) error {
+	return row.Scan(&loan.ID,
+		&loan.UserID,
+		&loan.BookID,
+		&loan.CopyID,
+		&loan.DateCheckout,
+		&loan.DateDue,
+		&loan.DateReturn,
+		&loan.Renewals)
+}
+
+func (db *DBStorage) CheckoutBook(loan models.LoanCheckoutStruct, due time.Time) (string, error) {
+
+	log := logger.Get()
+
+	ctx, cancel := context.WithTimeout(context.Background(), db.timeouts.Query)
+
+	defer cancel()
+
+	userID, err := parseID(loan.UserID)
+
+	if err != nil {
+		log.Error().Err(err).Msg("Failed parse user ID")
+		return "", err
+	}
+
+	bookID, err := parseID(loan.BookID)
+
+	if err != nil {
+		log.Error().Err(err).Msg("Failed parse book ID")
+		return "", err
+	}
+
+	if err = db.activeUser(ctx, userID); err != nil {
+		return "", err
+	}
+
+	if err = db.bookExists(ctx, bookID); err != nil {
+		return "", err
+	}
+
+	copyID, err := db.pickCopy(ctx, userID, bookID, loan.CopyID)
+
+	if err != nil {
+		return "", err
+	}
+
+	tx, err := db.pool.Begin(ctx)
+
+	if err != nil {
+		log.Error().Err(err).Msg("Failed create transaction")
+		return "", err
+	}
+
+	defer func() {
+		_ = tx.Rollback(ctx)
+	}()
+
+	ID := uuid.New()
+
+	_, err = tx.Exec(ctx, "INSERT INTO Loans (ID, UserID, BookID, CopyID, DateDue) VALUES ($1, $2, $3, $4, $5)",
+		ID, userID, bookID, copyID, due)
+
+	if err != nil {
+
+		var pgErr *pgconn.PgError
+
+		if errors.As(err, &pgErr) {
+
+			if pgErr.Code == pgerrcode.UniqueViolation {
+				return "", storageerror.ErrCopyAlreadyLoaned
+			}
+
+		}
+
+		log.Error().Err(err).Msg("Failed save loan")
+
+		return "", err
+
+	}
+
+	_, err = tx.Exec(ctx,
+		"UPDATE Holds SET Status = $1 WHERE UserID = $2 AND BookID = $3 AND Status IN ($4, $5)",
+		models.HoldFulfilled, userID, bookID, models.HoldWaiting, models.HoldReady)
+
+	if err != nil {
+		log.Error().Err(err).Msg("Failed fulfill hold")
+		return "", err
+	}
+
+	if err = tx.Commit(ctx); err != nil {
+		log.Error().Err(err).Msg("Failed commit transaction")
+		return "", err
+	}
+
+	return ID.String(), nil
+
+}
+
+// pickCopy returns the requested copy if the user may take it, otherwise the copy set aside
+// for the user's ready hold, otherwise any free copy of the book.
+func (db *DBStorage) pickCopy(ctx context.Context, userID uuid.UUID, bookID uuid.UUID, requested string) (uuid.UUID, error) {
+
+	log := logger.Get()
+
+	var copyID uuid.UUID
+
+	if requested != "" {
+
+		ID, err := parseID(requested)
+
+		if err != nil {
+			log.Error().Err(err).Msg("Failed parse copy ID")
+			return copyID, err
+		}
+
+		var loaned, reservedForOther bool
+
+		row := db.pool.QueryRow(ctx,
+			"SELECT c.ID, "+copyLoaned+`,
+			EXISTS(SELECT 1 FROM Holds h WHERE h.CopyID = c.ID AND h.Status = $3 AND h.UserID <> $4)
+			FROM Copies c WHERE c.ID = $1 AND c.BookID = $2`, ID, bookID, models.HoldReady, userID)
+
+		if err = row.Scan(&copyID, &loaned, &reservedForOther); err != nil {
+
+			if errors.Is(err, pgx.ErrNoRows) {
+				return copyID, storageerror.ErrCopyNotFound
+			}
+
+			log.Error().Err(err).Msg("Failed get data from table Copies")
+			return copyID, err
+
+		}
+
+		if loaned {
+			return copyID, storageerror.ErrCopyAlreadyLoaned
+		}
+
+		if reservedForOther {
+			return copyID, storageerror.ErrCopyReserved
+		}
+
+		return copyID, nil
+
+	}
+
+	row := db.pool.QueryRow(ctx,
+		"SELECT CopyID FROM Holds WHERE UserID = $1 AND BookID = $2 AND Status = $3 AND CopyID IS NOT NULL",
+		userID, bookID, models.HoldReady)
+
+	err := row.Scan(&copyID)
+
+	if err == nil {
+		return copyID, nil
+	}
+
+	if !errors.Is(err, pgx.ErrNoRows) {
+		log.Error().Err(err).Msg("Failed get data from table Holds")
+		return copyID, err
+	}
+
+	row = db.pool.QueryRow(ctx,
+		"SELECT c.ID FROM Copies c WHERE c.BookID = $1 AND "+copyFree+" ORDER BY c.Barcode LIMIT 1", bookID)
+
+	err = row.Scan(&copyID)
+
+	if err == nil {
+		return copyID, nil
+	}
+
+	if !errors.Is(err, pgx.ErrNoRows) {
+		log.Error().Err(err).Msg("Failed get data from table Copies")
+		return copyID, err
+	}
+
+	var total int
+
+	if err = db.pool.QueryRow(ctx, "SELECT count(*) FROM Copies WHERE BookID = $1", bookID).Scan(&total); err != nil {
+		log.Error().Err(err).Msg("Failed get data from table Copies")
+		return copyID, err
+	}
+
+	if total == 0 {
+		return copyID, storageerror.ErrBookNoCopies
+	}
+
+	return copyID, storageerror.ErrBookAlreadyLoaned
+
+}
+
+// ReturnBook closes the loan, saves the fine charged for it, if any, and gives the copy to the head of
+// the hold queue with the given expiry, all in one transaction.
+func (db *DBStorage) ReturnBook(id string, fine func(models.LoanStruct) (models.FineStruct, bool), expire time.Time) (models.LoanStruct, error) {
+
+	log := logger.Get()
+
+	ctx, cancel := context.WithTimeout(context.Background(), db.timeouts.Query)
+
+	defer cancel()
+
+	loanDB := models.LoanStruct{}
+
+	ID, err := parseID(id)
+
+	if err != nil {
+		log.Error().Err(err).Msg("Failed parse ID")
+		return loanDB, err
+	}
+
+	tx, err := db.pool.Begin(ctx)
+
+	if err != nil {
+		log.Error().Err(err).Msg("Failed create transaction")
+		return loanDB, err
+	}
+
+	defer func() {
+		_ = tx.Rollback(ctx)
+	}()
+
+	if err = scanLoan(tx.QueryRow(ctx, loanSelect+" WHERE ID = $1", ID), &loanDB); err != nil {
+
+		if errors.Is(err, pgx.ErrNoRows) {
+			return loanDB, storageerror.ErrLoanNotFound
+		}
+
+		log.Error().Err(err).Msg("Failed get data from table Loans")
+		return loanDB, err
+
+	}
+
+	if loanDB.DateReturn != nil {
+		return loanDB, storageerror.ErrLoanAlreadyReturned
+	}
+
+	// A concurrent return may have come first, the predicate lets only one of them through.
+	row := tx.QueryRow(ctx,
+		"UPDATE Loans SET DateReturn = now() WHERE ID = $1 AND DateReturn IS NULL RETURNING DateReturn", ID)
+
+	if err = row.Scan(&loanDB.DateReturn); err != nil {
+
+		if errors.Is(err, pgx.ErrNoRows) {
+			return loanDB, storageerror.ErrLoanAlreadyReturned
+		}
+
+		log.Error().Err(err).Msg("Failed return book")
+		return loanDB, err
+
+	}
+
+	if charge, due := fine(loanDB); due {
+
+		_, err = tx.Exec(ctx, fineInsert, uuid.New(), charge.UserID, charge.LoanID, charge.Type, charge.Amount, charge.Note)
+
+		if err != nil {
+			log.Error().Err(err).Msg("Failed save fine")
+			return loanDB, err
+		}
+
+	}
+
+	if err = allocateCopy(ctx, tx, loanDB.CopyID, expire); err != nil {
+		return loanDB, err
+	}
+
+	if err = tx.Commit(ctx); err != nil {
+		log.Error().Err(err).Msg("Failed commit transaction")
+		return loanDB, err
+	}
+
+	return loanDB, nil
+
+}
+
+func (db *DBStorage) RenewLoan(id string, extend time.Duration, maxRenewals int) (models.LoanStruct, error) {
+
+	log := logger.Get()
+
+	ctx, cancel := context.WithTimeout(context.Background(), db.timeouts.Query)
+
+	defer cancel()
+
+	loanDB := models.LoanStruct{}
+
+	ID, err := parseID(id)
+
+	if err != nil {
+		log.Error().Err(err).Msg("Failed parse ID")
+		return loanDB, err
+	}
+
+	if err = scanLoan(db.pool.QueryRow(ctx, loanSelect+" WHERE ID = $1", ID), &loanDB); err != nil {
+
+		if errors.Is(err, pgx.ErrNoRows) {
+			return loanDB, storageerror.ErrLoanNotFound
+		}
+
+		log.Error().Err(err).Msg("Failed get data from table Loans")
+		return loanDB, err
+
+	}
+
+	switch {
+	case loanDB.DateReturn != nil:
+		return loanDB, storageerror.ErrLoanAlreadyReturned
+	case loanDB.Renewals >= maxRenewals:
+		return loanDB, storageerror.ErrLoanRenewalLimit
+	case loanDB.DateDue.Before(time.Now()):
+		return loanDB, storageerror.ErrLoanOverdue
+	}
+
+	var held bool
+
+	row := db.pool.QueryRow(ctx, "SELECT EXISTS(SELECT 1 FROM Holds WHERE BookID = $1 AND Status = $2)",
+		loanDB.BookID, models.HoldWaiting)
+
+	if err = row.Scan(&held); err != nil {
+		log.Error().Err(err).Msg("Failed get data from table Holds")
+		return loanDB, err
+	}
+
+	if held {
+		return loanDB, storageerror.ErrLoanHoldPending
+	}
+
+	loanDB.DateDue = loanDB.DateDue.Add(extend)
+	loanDB.Renewals++
+
+	_, err = db.pool.Exec(ctx, "UPDATE Loans SET DateDue = $1, Renewals = $2 WHERE ID = $3",
+		loanDB.DateDue, loanDB.Renewals, ID)
+
+	if err != nil {
+		log.Error().Err(err).Msg("Failed renew loan")
+		return loanDB, err
+	}
+
+	return loanDB, nil
+
+}
+
+func (db *DBStorage) GetOverdueLoans(now time.Time) ([]models.LoanStruct, error) {
+
+	log := logger.Get()
+
+	ctx, cancel := context.WithTimeout(context.Background(), db.timeouts.Query)
+
+	defer cancel()
+
+	rows, err := db.pool.Query(ctx, loanSelect+" WHERE DateReturn IS NULL AND DateDue < $1 ORDER BY DateDue", now)
+
+	if err != nil {
+		log.Error().Err(err).Msg("Failed get data from table Loans")
+		return nil, err
+	}
+
+	defer rows.Close()
+
+	var loans []models.LoanStruct
+
+	for rows.Next() {
+
+		var loan models.LoanStruct
+
+		if err = scanLoan(rows, &loan); err != nil {
+			log.Error().Err(err).Msg("Failed scan rows data")
+			return nil, err
+		}
+
+		loans = append(loans, loan)
+
+	}
+
+	return loans, rows.Err()
+
+}
+
+func (db *DBStorage) GetUserLoans(userID string) ([]models.LoanStruct, error) {
+	return db.getActiveLoans("UserID", userID)
+}
+
+func (db *DBStorage) GetBookLoans(bookID string) ([]models.LoanStruct, error) {
+	return db.getActiveLoans("BookID", bookID)
+}
+
+// column is never taken from user input, only from the two wrappers above.
+func (db *DBStorage) getActiveLoans(column string, id string) ([]models.LoanStruct, error) {
+
+	log := logger.Get()
+
+	ctx, cancel := context.WithTimeout(context.Background(), db.timeouts.Query)
+
+	defer cancel()
+
+	ID, err := parseID(id)
+
+	if err != nil {
+		log.Error().Err(err).Msg("Failed parse ID")
+		return nil, err
+	}
+
+	rows, err := db.pool.Query(ctx,
+		loanSelect+" WHERE "+column+" = $1 AND DateReturn IS NULL ORDER BY DateCheckout", ID)
+
+	if err != nil {
+		log.Error().Err(err).Msg("Failed get data from table Loans")
+		return nil, err
+	}
+
+	defer rows.Close()
+
+	var loans []models.LoanStruct
+
+	for rows.Next() {
+
+		var loan models.LoanStruct
+
+		if err = scanLoan(rows, &loan); err != nil {
+			log.Error().Err(err).Msg("Failed scan rows data")
+			return nil, err
+		}
+
+		loans = append(loans, loan)
+
+	}
+
+	return loans, rows.Err()
+
+}
@@ -0,0 +1,309 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/google/uuid"
+	"github.com/jackc/pgerrcode"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"golang.org/x/crypto/bcrypt"
+	"library/internal/domain/models"
+	"library/internal/logger"
+	"library/internal/storage/storageerror"
+	"strings"
+)
+
+// setClause collects the columns of a partial UPDATE, each expression takes its placeholder number.
+type setClause struct {
+	sets []string
+	args []any
+}
+
+func (sc *setClause) add(expr string, value any) {
+	sc.args = append(sc.args, value)
+	sc.sets = append(sc.sets, fmt.Sprintf(expr, len(sc.args)))
+}
+
+// update builds the statement, which always bumps the version, or returns "" when the patch sets nothing.
+func (sc *setClause) update(table string, ID uuid.UUID) (string, []any) {
+
+	if len(sc.sets) == 0 {
+		return "", nil
+	}
+
+	args := append(sc.args, ID)
+
+	return fmt.Sprintf("UPDATE %s SET %s, Version = Version + 1 WHERE ID = $%d",
+		table, strings.Join(sc.sets, ", "), len(args)), args
+
+}
+
+// PatchBook updates only the columns set in the patch and saves a revision. The version rule is the one of EditBook.
+func (db *DBStorage) PatchBook(ctx context.Context, id string, patch models.BookPatchStruct) error {
+
+	log := logger.Get()
+
+	ctx, cancel := context.WithTimeout(ctx, db.timeouts.Query)
+
+	defer cancel()
+
+	ID, err := parseID(id)
+
+	if err != nil {
+		log.Error().Err(err).Msg("Failed parse ID")
+		return err
+	}
+
+	tx, err := db.pool.Begin(ctx)
+
+	if err != nil {
+		log.Error().Err(err).Msg("Failed create transaction")
+		return err
+	}
+
+	defer func() {
+		_ = tx.Rollback(ctx)
+	}()
+
+	var bookDB models.BookStruct
+
+	row := tx.QueryRow(ctx,
+		"SELECT Name, Author, COALESCE(ISBN13, ''), Version FROM Books WHERE ID = $1 AND DeletedAt IS NULL FOR UPDATE", ID)
+
+	if err = row.Scan(&bookDB.Name, &bookDB.Author, &bookDB.ISBN13, &bookDB.Version); err != nil {
+
+		if errors.Is(err, pgx.ErrNoRows) {
+			return storageerror.ErrBookNotFound
+		}
+
+		log.Error().Err(err).Msg("Failed get data from table Books")
+		return err
+
+	}
+
+	if patch.Version != 0 && patch.Version != bookDB.Version {
+		return storageerror.ErrVersionConflict
+	}
+
+	name, author, isbn13 := bookDB.Name, bookDB.Author, bookDB.ISBN13
+
+	if patch.Name != nil {
+		name = *patch.Name
+	}
+
+	if patch.Author != nil {
+		author = *patch.Author
+	}
+
+	if patch.ISBN13 != nil {
+		isbn13 = *patch.ISBN13
+	}
+
+	// The duplicate rule of SaveBook: the edition is told apart by its ISBN, a book without one by name and author.
+	if isbn13 != "" {
+		row = tx.QueryRow(ctx, "SELECT ID FROM Books WHERE ISBN13 = $1 AND ID <> $2 AND DeletedAt IS NULL", isbn13, ID)
+	} else {
+		row = tx.QueryRow(ctx, "SELECT ID FROM Books WHERE Name = $1 AND Author = $2 AND ID <> $3 AND DeletedAt IS NULL",
+			name, author, ID)
+	}
+
+	var IDTemp uuid.UUID
+
+	err = row.Scan(&IDTemp)
+
+	if err == nil {
+		return storageerror.ErrBookAlreadyExist
+	}
+
+	if !errors.Is(err, pgx.ErrNoRows) {
+		log.Error().Err(err).Msg("Failed get data from table Books")
+		return err
+	}
+
+	var set setClause
+
+	if patch.Name != nil {
+		set.add("Name = $%d", *patch.Name)
+	}
+
+	if patch.Description != nil {
+		set.add("Description = $%d", *patch.Description)
+	}
+
+	if patch.Author != nil {
+		set.add("Author = $%d", *patch.Author)
+	}
+
+	if patch.ISBN10 != nil {
+		set.add("ISBN10 = NULLIF($%d, '')", *patch.ISBN10)
+	}
+
+	if patch.ISBN13 != nil {
+		set.add("ISBN13 = NULLIF($%d, '')", *patch.ISBN13)
+	}
+
+	if patch.DateWriting != nil {
+		set.add("DateWriting = $%d", *patch.DateWriting)
+	}
+
+	query, args := set.update("Books", ID)
+
+	if query == "" {
+		return nil
+	}
+
+	if _, err = tx.Exec(ctx, query, args...); err != nil {
+
+		var pgErr *pgconn.PgError
+
+		if errors.As(err, &pgErr) && pgErr.Code == pgerrcode.UniqueViolation {
+			return storageerror.ErrBookAlreadyExist
+		}
+
+		log.Error().Err(err).Msg("Failed patch book")
+
+		return err
+
+	}
+
+	if err = saveRevision(ctx, tx, ID); err != nil {
+		log.Error().Err(err).Msg("Failed save book revision")
+		return err
+	}
+
+	if err = tx.Commit(ctx); err != nil {
+		log.Error().Err(err).Msg("Failed commit transaction")
+		return err
+	}
+
+	return nil
+
+}
+
+// PatchUser updates only the columns set in the patch. A given password is always hashed anew.
+func (db *DBStorage) PatchUser(ctx context.Context, id string, patch models.UserPatchStruct) error {
+
+	log := logger.Get()
+
+	ctx, cancel := context.WithTimeout(ctx, db.timeouts.Query)
+
+	defer cancel()
+
+	ID, err := parseID(id)
+
+	if err != nil {
+		log.Error().Err(err).Msg("Failed parse ID")
+		return err
+	}
+
+	tx, err := db.pool.Begin(ctx)
+
+	if err != nil {
+		log.Error().Err(err).Msg("Failed create transaction")
+		return err
+	}
+
+	defer func() {
+		_ = tx.Rollback(ctx)
+	}()
+
+	var userDB models.UserStruct
+
+	row := tx.QueryRow(ctx, "SELECT Email, DeletedAt, Version FROM Users WHERE ID = $1 FOR UPDATE", ID)
+
+	if err = row.Scan(&userDB.Email, &userDB.DeletedAt, &userDB.Version); err != nil {
+
+		if errors.Is(err, pgx.ErrNoRows) {
+			return storageerror.ErrUserNotFound
+		}
+
+		log.Error().Err(err).Msg("Failed get data from table Users")
+		return err
+
+	}
+
+	if userDB.DeletedAt != nil {
+		return storageerror.ErrUserInactive
+	}
+
+	if patch.Version != 0 && patch.Version != userDB.Version {
+		return storageerror.ErrVersionConflict
+	}
+
+	if patch.Email != nil && *patch.Email != userDB.Email {
+
+		var IDTemp uuid.UUID
+
+		err = tx.QueryRow(ctx, "SELECT ID FROM Users WHERE Email = $1", *patch.Email).Scan(&IDTemp)
+
+		if err == nil {
+			return storageerror.ErrUserAlreadyExist
+		}
+
+		if !errors.Is(err, pgx.ErrNoRows) {
+			log.Error().Err(err).Msg("Failed get data from table Users")
+			return err
+		}
+
+	}
+
+	var set setClause
+
+	if patch.Name != nil {
+		set.add("Name = $%d", *patch.Name)
+	}
+
+	if patch.Password != nil {
+
+		hash, err := bcrypt.GenerateFromPassword([]byte(*patch.Password), bcrypt.MinCost)
+
+		if err != nil {
+			return err
+		}
+
+		set.add("Password = $%d", string(hash))
+
+	}
+
+	if patch.Email != nil {
+		set.add("Email = $%d", *patch.Email)
+	}
+
+	if patch.Age != nil {
+		set.add("Age = $%d", *patch.Age)
+	}
+
+	if patch.Role != nil {
+		set.add("Role = $%d", *patch.Role)
+	}
+
+	query, args := set.update("Users", ID)
+
+	if query == "" {
+		return nil
+	}
+
+	if _, err = tx.Exec(ctx, query, args...); err != nil {
+
+		var pgErr *pgconn.PgError
+
+		if errors.As(err, &pgErr) && pgErr.Code == pgerrcode.UniqueViolation {
+			return storageerror.ErrUserAlreadyExist
+		}
+
+		log.Error().Err(err).Msg("Failed patch user")
+
+		return err
+
+	}
+
+	if err = tx.Commit(ctx); err != nil {
+		log.Error().Err(err).Msg("Failed commit transaction")
+		return err
+	}
+
+	return nil
+
+}
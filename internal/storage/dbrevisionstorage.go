@@ -0,0 +1,126 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"library/internal/domain/models"
+	"library/internal/logger"
+	"library/internal/storage/storageerror"
+	"time"
+)
+
+const revisionSelect = `SELECT Rev, BookID, Name, Description, Author, coalesce(ISBN10, ''), coalesce(ISBN13, ''),
+	DateWriting, DateCreated FROM BookRevisions`
+
+func scanRevision(row pgx.Row, rev *models.BookRevisionStruct) error {
+	return row.Scan(&rev.Rev,
+		&rev.Book.ID,
+		&rev.Book.Name,
+		&rev.Book.Description,
+		&rev.Book.Author,
+		&rev.Book.ISBN10,
+		&rev.Book.ISBN13,
+		&rev.Book.DateWriting,
+		&rev.DateCreated)
+}
+
+// saveRevision copies the current state of the book into the next revision. It must run in the
+// transaction that changed the book, whose row lock keeps the revision numbers in order.
+func saveRevision(ctx context.Context, tx pgx.Tx, ID uuid.UUID) error {
+
+	_, err := tx.Exec(ctx,
+		`INSERT INTO BookRevisions (BookID, Rev, Name, Description, Author, ISBN10, ISBN13, DateWriting, DateCreated)
+		SELECT ID, coalesce((SELECT max(Rev) FROM BookRevisions WHERE BookID = $1), 0) + 1,
+		Name, Description, Author, ISBN10, ISBN13, DateWriting, $2 FROM Books WHERE ID = $1`, ID, time.Now().UTC())
+
+	return err
+
+}
+
+// GetBookRevisions returns the revisions of the book, the newest first.
+func (db *DBStorage) GetBookRevisions(ctx context.Context, id string) ([]models.BookRevisionStruct, error) {
+
+	log := logger.Get()
+
+	ctx, cancel := context.WithTimeout(ctx, db.timeouts.Query)
+
+	defer cancel()
+
+	ID, err := parseID(id)
+
+	if err != nil {
+		log.Error().Err(err).Msg("Failed parse ID")
+		return nil, err
+	}
+
+	if err = db.bookExists(ctx, ID); err != nil {
+		return nil, err
+	}
+
+	rows, err := db.pool.Query(ctx, revisionSelect+" WHERE BookID = $1 ORDER BY Rev DESC", ID)
+
+	if err != nil {
+		log.Error().Err(err).Msg("Failed get data from table BookRevisions")
+		return nil, err
+	}
+
+	defer rows.Close()
+
+	revisions := []models.BookRevisionStruct{}
+
+	for rows.Next() {
+
+		var rev models.BookRevisionStruct
+
+		if err = scanRevision(rows, &rev); err != nil {
+			log.Error().Err(err).Msg("Failed scan rows data")
+			return nil, err
+		}
+
+		revisions = append(revisions, rev)
+
+	}
+
+	return revisions, rows.Err()
+
+}
+
+func (db *DBStorage) GetBookRevision(ctx context.Context, id string, rev int) (models.BookRevisionStruct, error) {
+
+	log := logger.Get()
+
+	ctx, cancel := context.WithTimeout(ctx, db.timeouts.Query)
+
+	defer cancel()
+
+	var revision models.BookRevisionStruct
+
+	ID, err := parseID(id)
+
+	if err != nil {
+		log.Error().Err(err).Msg("Failed parse ID")
+		return revision, err
+	}
+
+	if err = db.bookExists(ctx, ID); err != nil {
+		return revision, err
+	}
+
+	row := db.pool.QueryRow(ctx, revisionSelect+" WHERE BookID = $1 AND Rev = $2", ID, rev)
+
+	if err = scanRevision(row, &revision); err != nil {
+
+		if errors.Is(err, pgx.ErrNoRows) {
+			return revision, storageerror.ErrRevisionNotFound
+		}
+
+		log.Error().Err(err).Msg("Failed get data from table BookRevisions")
+		return revision, err
+
+	}
+
+	return revision, nil
+
+}
@@ -0,0 +1,73 @@
+package storage
+
+import (
+	"context"
+	"library/internal/domain/models"
+	"library/internal/logger"
+)
+
+// The query goes through websearch_to_tsquery, so clients may use quotes, "or" and "-word".
+// The 'simple' configuration is used because the catalogue mixes languages.
+const bookSearchQuery = `SELECT b.ID, b.Name, b.Description, b.Author,
+	coalesce(b.ISBN10, ''), coalesce(b.ISBN13, ''), b.DateWriting,
+	ts_rank(b.Search, q) AS Rank,
+	ts_headline('simple', concat_ws(' ', b.Name, b.Author, b.Description), q,
+		'StartSel=<b>, StopSel=</b>, MaxWords=30, MinWords=10, MaxFragments=2')
+	FROM Books b, websearch_to_tsquery('simple', $1) q
+	WHERE b.Search @@ q AND b.DeletedAt IS NULL
+	ORDER BY Rank DESC, b.ID
+	LIMIT $2 OFFSET $3`
+
+func (db *DBStorage) SearchBooks(ctx context.Context, search models.BookSearchStruct) ([]models.BookHitStruct, int, error) {
+
+	log := logger.Get()
+
+	ctx, cancel := context.WithTimeout(ctx, db.timeouts.Query)
+
+	defer cancel()
+
+	var total int
+
+	row := db.pool.QueryRow(ctx,
+		"SELECT count(*) FROM Books WHERE Search @@ websearch_to_tsquery('simple', $1) AND DeletedAt IS NULL", search.Query)
+
+	if err := row.Scan(&total); err != nil {
+		log.Error().Err(err).Msg("Failed get data from table Books")
+		return nil, 0, err
+	}
+
+	rows, err := db.pool.Query(ctx, bookSearchQuery, search.Query, search.Limit, search.Offset)
+
+	if err != nil {
+		log.Error().Err(err).Msg("Failed get data from table Books")
+		return nil, 0, err
+	}
+
+	defer rows.Close()
+
+	hits := []models.BookHitStruct{}
+
+	for rows.Next() {
+
+		var hit models.BookHitStruct
+
+		if err = rows.Scan(&hit.Book.ID,
+			&hit.Book.Name,
+			&hit.Book.Description,
+			&hit.Book.Author,
+			&hit.Book.ISBN10,
+			&hit.Book.ISBN13,
+			&hit.Book.DateWriting,
+			&hit.Rank,
+			&hit.Snippet); err != nil {
+			log.Error().Err(err).Msg("Failed scan rows data")
+			return nil, 0, err
+		}
+
+		hits = append(hits, hit)
+
+	}
+
+	return hits, total, rows.Err()
+
+}
@@ -0,0 +1,192 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"github.com/jackc/pgx/v5"
+	"library/internal/domain/models"
+	"library/internal/logger"
+	"library/internal/storage/storageerror"
+	"time"
+)
+
+const sessionSelect = "SELECT ID, UserID, TokenHash, DateCreated, DateExpire, Revoked FROM Sessions"
+
+func scanSession(row pgx.Row, session *models.SessionStruct) error {
+	return row.Scan(&session.ID,
+		&session.UserID,
+		&session.TokenHash,
+		&session.DateCreated,
+		&session.DateExpire,
+		&session.Revoked)
+}
+
+func (db *DBStorage) SaveSession(session models.SessionStruct) error {
+
+	log := logger.Get()
+
+	ctx, cancel := context.WithTimeout(context.Background(), db.timeouts.Query)
+
+	defer cancel()
+
+	_, err := db.pool.Exec(ctx,
+		"INSERT INTO Sessions (ID, UserID, TokenHash, DateExpire) VALUES ($1, $2, $3, $4)",
+		session.ID, session.UserID, session.TokenHash, session.DateExpire)
+
+	if err != nil {
+		log.Error().Err(err).Msg("Failed save session")
+		return err
+	}
+
+	return nil
+
+}
+
+func (db *DBStorage) GetSession(id string) (models.SessionStruct, error) {
+
+	log := logger.Get()
+
+	ctx, cancel := context.WithTimeout(context.Background(), db.timeouts.Query)
+
+	defer cancel()
+
+	sessionDB := models.SessionStruct{}
+
+	ID, err := parseID(id)
+
+	if err != nil {
+		log.Error().Err(err).Msg("Failed parse ID")
+		return sessionDB, err
+	}
+
+	if err = scanSession(db.pool.QueryRow(ctx, sessionSelect+" WHERE ID = $1", ID), &sessionDB); err != nil {
+
+		if errors.Is(err, pgx.ErrNoRows) {
+			return sessionDB, storageerror.ErrSessionNotFound
+		}
+
+		log.Error().Err(err).Msg("Failed get data from table Sessions")
+		return sessionDB, err
+
+	}
+
+	return sessionDB, nil
+
+}
+
+func (db *DBStorage) GetSessionByToken(tokenHash string) (models.SessionStruct, error) {
+
+	log := logger.Get()
+
+	ctx, cancel := context.WithTimeout(context.Background(), db.timeouts.Query)
+
+	defer cancel()
+
+	sessionDB := models.SessionStruct{}
+
+	if err := scanSession(db.pool.QueryRow(ctx, sessionSelect+" WHERE TokenHash = $1", tokenHash), &sessionDB); err != nil {
+
+		if errors.Is(err, pgx.ErrNoRows) {
+			return sessionDB, storageerror.ErrSessionNotFound
+		}
+
+		log.Error().Err(err).Msg("Failed get data from table Sessions")
+		return sessionDB, err
+
+	}
+
+	return sessionDB, nil
+
+}
+
+func (db *DBStorage) RotateSession(id string, tokenHash string, expire time.Time) error {
+
+	log := logger.Get()
+
+	ctx, cancel := context.WithTimeout(context.Background(), db.timeouts.Query)
+
+	defer cancel()
+
+	ID, err := parseID(id)
+
+	if err != nil {
+		log.Error().Err(err).Msg("Failed parse ID")
+		return err
+	}
+
+	tag, err := db.pool.Exec(ctx,
+		"UPDATE Sessions SET TokenHash = $1, DateExpire = $2 WHERE ID = $3 AND NOT Revoked",
+		tokenHash, expire, ID)
+
+	if err != nil {
+		log.Error().Err(err).Msg("Failed rotate session")
+		return err
+	}
+
+	if tag.RowsAffected() == 0 {
+		return storageerror.ErrSessionRevoked
+	}
+
+	return nil
+
+}
+
+func (db *DBStorage) RevokeSession(id string) error {
+
+	log := logger.Get()
+
+	ctx, cancel := context.WithTimeout(context.Background(), db.timeouts.Query)
+
+	defer cancel()
+
+	ID, err := parseID(id)
+
+	if err != nil {
+		log.Error().Err(err).Msg("Failed parse ID")
+		return err
+	}
+
+	tag, err := db.pool.Exec(ctx, "UPDATE Sessions SET Revoked = true WHERE ID = $1", ID)
+
+	if err != nil {
+		log.Error().Err(err).Msg("Failed revoke session")
+		return err
+	}
+
+	if tag.RowsAffected() == 0 {
+		return storageerror.ErrSessionNotFound
+	}
+
+	return nil
+
+}
+
+func (db *DBStorage) RevokeUserSessions(userID string) error {
+
+	log := logger.Get()
+
+	ctx, cancel := context.WithTimeout(context.Background(), db.timeouts.Query)
+
+	defer cancel()
+
+	ID, err := parseID(userID)
+
+	if err != nil {
+		log.Error().Err(err).Msg("Failed parse ID")
+		return err
+	}
+
+	if err = db.userExists(ctx, ID); err != nil {
+		return err
+	}
+
+	_, err = db.pool.Exec(ctx, "UPDATE Sessions SET Revoked = true WHERE UserID = $1", ID)
+
+	if err != nil {
+		log.Error().Err(err).Msg("Failed revoke sessions")
+		return err
+	}
+
+	return nil
+
+}
@@ -9,26 +9,83 @@ import (
 	"github.com/jackc/pgerrcode"
 	"github.com/jackc/pgx/v5"
 	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/jackc/pgx/v5/pgxpool"
 	"golang.org/x/crypto/bcrypt"
 	"library/internal/domain/models"
 	"library/internal/logger"
 	"library/internal/storage/storageerror"
+	"strings"
 	"time"
 )
 
 type DBStorage struct {
-	conn *pgx.Conn
+	pool     *pgxpool.Pool
+	timeouts TimeoutsStruct
 }
 
-func NewDBStorage(ctx context.Context, addr string) (*DBStorage, error) {
+// TimeoutsStruct bounds the statements of DBStorage. A method gets Query, except the import batches and the
+// export cursors, which run for longer. The caller's context still cancels a statement earlier.
+type TimeoutsStruct struct {
+	Query  time.Duration
+	Import time.Duration
+	Export time.Duration
+}
+
+// PoolConfigStruct sizes the connection pool. A zero field keeps the pgxpool default.
+type PoolConfigStruct struct {
+	MinConns          int32
+	MaxConns          int32
+	MaxConnLifetime   time.Duration
+	MaxConnIdleTime   time.Duration
+	HealthCheckPeriod time.Duration
+}
 
-	conn, err := pgx.Connect(ctx, addr)
+// NewDBStorage opens the pool and pings the database, the pool itself connects lazily
+// and would not tell that the database is unreachable.
+func NewDBStorage(ctx context.Context, addr string, poolCfg PoolConfigStruct, timeouts TimeoutsStruct) (*DBStorage, error) {
+
+	cfg, err := pgxpool.ParseConfig(addr)
 
 	if err != nil {
 		return nil, err
 	}
 
-	return &DBStorage{conn: conn}, nil
+	if poolCfg.MinConns > 0 {
+		cfg.MinConns = poolCfg.MinConns
+	}
+
+	if poolCfg.MaxConns > 0 {
+		cfg.MaxConns = poolCfg.MaxConns
+	}
+
+	if poolCfg.MaxConnLifetime > 0 {
+		cfg.MaxConnLifetime = poolCfg.MaxConnLifetime
+	}
+
+	if poolCfg.MaxConnIdleTime > 0 {
+		cfg.MaxConnIdleTime = poolCfg.MaxConnIdleTime
+	}
+
+	if poolCfg.HealthCheckPeriod > 0 {
+		cfg.HealthCheckPeriod = poolCfg.HealthCheckPeriod
+	}
+
+	pool, err := pgxpool.NewWithConfig(ctx, cfg)
+
+	if err != nil {
+		return nil, err
+	}
+
+	pingCtx, cancel := context.WithTimeout(ctx, timeouts.Query)
+
+	defer cancel()
+
+	if err = pool.Ping(pingCtx); err != nil {
+		pool.Close()
+		return nil, err
+	}
+
+	return &DBStorage{pool: pool, timeouts: timeouts}, nil
 
 }
 
@@ -63,75 +120,114 @@ func Migrations(dbDSN string, migratePath string) error {
 }
 
 func (db *DBStorage) Close() error {
-	return db.conn.Close(context.Background())
+	db.pool.Close()
+	return nil
+}
+
+// PoolStats reports the state of the connection pool for monitoring.
+func (db *DBStorage) PoolStats() models.PoolStatsStruct {
+
+	stat := db.pool.Stat()
+
+	return models.PoolStatsStruct{
+		TotalConns:              stat.TotalConns(),
+		IdleConns:               stat.IdleConns(),
+		AcquiredConns:           stat.AcquiredConns(),
+		ConstructingConns:       stat.ConstructingConns(),
+		MaxConns:                stat.MaxConns(),
+		AcquireCount:            stat.AcquireCount(),
+		AcquireDuration:         stat.AcquireDuration(),
+		EmptyAcquireCount:       stat.EmptyAcquireCount(),
+		CanceledAcquireCount:    stat.CanceledAcquireCount(),
+		NewConnsCount:           stat.NewConnsCount(),
+		MaxLifetimeDestroyCount: stat.MaxLifetimeDestroyCount(),
+		MaxIdleDestroyCount:     stat.MaxIdleDestroyCount(),
+	}
 
 }
 
-func (db *DBStorage) GetUsers() ([]models.UserStruct, error) {
+func (db *DBStorage) GetUsers(ctx context.Context, filter models.UserFilterStruct) ([]models.UserStruct, int, error) {
 
 	log := logger.Get()
 
-	ctx, cancel := context.WithTimeout(context.Background(), time.Second*10)
+	ctx, cancel := context.WithTimeout(ctx, db.timeouts.Query)
 
 	defer cancel()
 
-	rows, err := db.conn.Query(ctx, "SELECT ID, Name, Password, Email, Age, DateRegistration FROM Users")
+	var conds []string
+	var args []any
+
+	if filter.Role != "" {
+		args = append(args, filter.Role)
+		conds = append(conds, fmt.Sprintf("Role = $%d", len(args)))
+	}
+
+	switch filter.Status {
+	case models.UserStatusActive:
+		conds = append(conds, "DeletedAt IS NULL")
+	case models.UserStatusInactive:
+		conds = append(conds, "DeletedAt IS NOT NULL")
+	}
+
+	conds, args = dateRange("DateRegistration", filter.ListParamsStruct, conds, args)
+
+	where := whereClause(conds)
+
+	var total int
+
+	if err := db.pool.QueryRow(ctx, "SELECT count(*) FROM Users"+where, args...).Scan(&total); err != nil {
+		log.Error().Err(err).Msg("Failed get data from table Users")
+		return nil, 0, err
+	}
+
+	page, args := pageClause(userSortColumns[filter.Sort], filter.ListParamsStruct, args)
+
+	rows, err := db.pool.Query(ctx, userSelect+where+page, args...)
 
 	if err != nil {
 		log.Error().Err(err).Msg("Failed get data from table Users")
-		return nil, err
+		return nil, 0, err
 	}
 
-	var users []models.UserStruct
+	defer rows.Close()
+
+	users := []models.UserStruct{}
 
 	for rows.Next() {
 
 		var user models.UserStruct
 
-		if err = rows.Scan(&user.ID,
-			&user.Name,
-			&user.Password,
-			&user.Email,
-			&user.Age,
-			&user.DateRegistration); err != nil {
+		if err = scanUser(rows, &user); err != nil {
 			log.Error().Err(err).Msg("Failed scan rows data")
-			return nil, err
+			return nil, 0, err
 		}
 
 		users = append(users, user)
 
 	}
 
-	return users, nil
+	return users, total, rows.Err()
 
 }
 
-func (db *DBStorage) GetUser(id string) (models.UserStruct, error) {
+func (db *DBStorage) GetUser(ctx context.Context, id string) (models.UserStruct, error) {
 
 	log := logger.Get()
 
-	ctx, cancel := context.WithTimeout(context.Background(), time.Second*10)
+	ctx, cancel := context.WithTimeout(ctx, db.timeouts.Query)
 
 	defer cancel()
 
 	userDB := models.UserStruct{}
 
-	ID, err := uuid.Parse(id)
+	ID, err := parseID(id)
 
 	if err != nil {
 		log.Error().Err(err).Msg("Failed parse ID")
 		return userDB, err
 	}
 
-	row := db.conn.QueryRow(ctx,
-		"SELECT ID, Name, Password, Email, Age, DateRegistration FROM Users WHERE ID = $1", ID)
-
-	if err = row.Scan(&userDB.ID,
-		&userDB.Name,
-		&userDB.Password,
-		&userDB.Email,
-		&userDB.Age,
-		&userDB.DateRegistration); err != nil {
+	if err = scanUser(db.pool.QueryRow(ctx, userSelect+" WHERE ID = $1", ID), &userDB); err != nil {
 
 		if errors.Is(err, pgx.ErrNoRows) {
 			return userDB, storageerror.ErrUserNotFound
@@ -146,15 +242,15 @@ func (db *DBStorage) GetUser(id string) (models.UserStruct, error) {
 
 }
 
-func (db *DBStorage) SaveUser(user models.UserStruct) (string, error) {
+func (db *DBStorage) SaveUser(ctx context.Context, user models.UserStruct) (string, error) {
 
 	log := logger.Get()
 
-	ctx, cancel := context.WithTimeout(context.Background(), time.Second*10)
+	ctx, cancel := context.WithTimeout(ctx, db.timeouts.Query)
 
 	defer cancel()
 
-	row := db.conn.QueryRow(ctx,
+	row := db.pool.QueryRow(ctx,
 		"SELECT ID FROM Users WHERE Email = $1", user.Email)
 
 	var IDTemp uuid.UUID
@@ -180,8 +276,13 @@ func (db *DBStorage) SaveUser(user models.UserStruct) (string, error) {
 
 	user.Password = string(hash)
 
-	_, err = db.conn.Exec(ctx, "INSERT INTO Users (ID, Name, Password, Email, Age) VALUES ($1, $2, $3, $4, $5)",
-		user.ID, user.Name, user.Password, user.Email, user.Age)
+	if user.Role == "" {
+		user.Role = models.RolePatron
+	}
+
+	_, err = db.pool.Exec(ctx,
+		"INSERT INTO Users (ID, Name, Password, Email, Age, Role) VALUES ($1, $2, $3, $4, $5, $6)",
+		user.ID, user.Name, user.Password, user.Email, user.Age, user.Role)
 
 	if err != nil {
 
@@ -205,46 +306,54 @@ func (db *DBStorage) SaveUser(user models.UserStruct) (string, error) {
 
 }
 
-func (db *DBStorage) ValidateUser(user models.UserLoginStruct) (string, error) {
+func (db *DBStorage) ValidateUser(ctx context.Context, user models.UserLoginStruct) (models.UserStruct, error) {
 
 	log := logger.Get()
 
-	ctx, cancel := context.WithTimeout(context.Background(), time.Second*10)
+	ctx, cancel := context.WithTimeout(ctx, db.timeouts.Query)
 
 	defer cancel()
 
-	row := db.conn.QueryRow(ctx, "SELECT ID, Password FROM Users WHERE email = $1", user.Email)
+	row := db.pool.QueryRow(ctx, "SELECT ID, Password, Role, DeletedAt FROM Users WHERE email = $1", user.Email)
 
 	var userDB models.UserStruct
 
-	if err := row.Scan(&userDB.ID, &userDB.Password); err != nil {
+	if err := row.Scan(&userDB.ID, &userDB.Password, &userDB.Role, &userDB.DeletedAt); err != nil {
 
 		if errors.Is(err, pgx.ErrNoRows) {
-			return "", storageerror.ErrUserNotFound
+			return models.UserStruct{}, storageerror.ErrUserNotFound
 		}
 
 		log.Error().Err(err).Msg("Failed validate user")
-		return "", err
+		return models.UserStruct{}, err
 
 	}
 
 	if err := bcrypt.CompareHashAndPassword([]byte(userDB.Password), []byte(user.Password)); err != nil {
-		return "", storageerror.ErrUserInvalidPassword
+		return models.UserStruct{}, storageerror.ErrUserInvalidPassword
+	}
+
+	// The password is checked first, so a deactivated account is not revealed to someone guessing emails.
+	if userDB.DeletedAt != nil {
+		return models.UserStruct{}, storageerror.ErrUserInactive
 	}
 
-	return userDB.ID.String(), nil
+	userDB.Password = ""
+
+	return userDB, nil
 
 }
 
-func (db *DBStorage) EditUser(id string, user models.UserStruct) error {
+// EditUser updates the account. A non-zero user.Version must match the stored one, as in EditBook.
+func (db *DBStorage) EditUser(ctx context.Context, id string, user models.UserStruct) error {
 
 	log := logger.Get()
 
-	ctx, cancel := context.WithTimeout(context.Background(), time.Second*10)
+	ctx, cancel := context.WithTimeout(ctx, db.timeouts.Query)
 
 	defer cancel()
 
-	ID, err := uuid.Parse(id)
+	ID, err := parseID(id)
 
 	if err != nil {
 		log.Error().Err(err).Msg("Failed parse ID")
@@ -253,12 +362,15 @@ func (db *DBStorage) EditUser(id string, user models.UserStruct) error {
 
 	var userDB models.UserStruct
 
-	row := db.conn.QueryRow(ctx,
-		"SELECT ID, Password, Email FROM Users WHERE ID = $1", ID)
+	row := db.pool.QueryRow(ctx,
+		"SELECT ID, Password, Email, Role, DeletedAt, Version FROM Users WHERE ID = $1", ID)
 
 	if err = row.Scan(&userDB.ID,
 		&userDB.Password,
-		&userDB.Email); err != nil {
+		&userDB.Email,
+		&userDB.Role,
+		&userDB.DeletedAt,
+		&userDB.Version); err != nil {
 
 		if errors.Is(err, pgx.ErrNoRows) {
 			return storageerror.ErrUserNotFound
@@ -269,9 +381,17 @@ func (db *DBStorage) EditUser(id string, user models.UserStruct) error {
 
 	}
 
+	if userDB.DeletedAt != nil {
+		return storageerror.ErrUserInactive
+	}
+
+	if user.Version != 0 && user.Version != userDB.Version {
+		return storageerror.ErrVersionConflict
+	}
+
 	if userDB.Email != user.Email {
 
-		row = db.conn.QueryRow(ctx,
+		row = db.pool.QueryRow(ctx,
 			"SELECT ID FROM Users WHERE Email = $1", user.Email)
 
 		var IDTemp uuid.UUID
@@ -284,7 +404,7 @@ func (db *DBStorage) EditUser(id string, user models.UserStruct) error {
 			}
 
 		} else {
-			return fmt.Errorf("user with email '%s' already exists", user.Email)
+			return storageerror.ErrUserAlreadyExist
 		}
 
 	}
@@ -303,38 +423,57 @@ func (db *DBStorage) EditUser(id string, user models.UserStruct) error {
 		user.Password = userDB.Password
 	}
 
-	_, err = db.conn.Exec(ctx, "UPDATE Users SET Name = $1, Password = $2, Email = $3, Age = $4 WHERE ID = $5",
-		user.Name, user.Password, user.Email, user.Age, userDB.ID)
+	if user.Role == "" {
+		user.Role = userDB.Role
+	}
+
+	tag, err := db.pool.Exec(ctx,
+		"UPDATE Users SET Name = $1, Password = $2, Email = $3, Age = $4, Role = $5, Version = Version + 1 WHERE ID = $6 AND Version = $7",
+		user.Name, user.Password, user.Email, user.Age, user.Role, userDB.ID, userDB.Version)
 
 	if err != nil {
 		log.Error().Err(err).Msg("Failed edit user")
 		return err
 	}
 
+	if tag.RowsAffected() == 0 {
+		return storageerror.ErrVersionConflict
+	}
+
 	return nil
 
 }
 
-func (db *DBStorage) DeleteUser(id string) error {
+// DeleteUser deactivates the account and revokes its sessions. The row stays, loans and fines refer to it.
+func (db *DBStorage) DeleteUser(ctx context.Context, id string) error {
 
 	log := logger.Get()
 
-	ctx, cancel := context.WithTimeout(context.Background(), time.Second*10)
+	ctx, cancel := context.WithTimeout(ctx, db.timeouts.Query)
 
 	defer cancel()
 
-	ID, err := uuid.Parse(id)
+	ID, err := parseID(id)
 
 	if err != nil {
 		log.Error().Err(err).Msg("Failed parse ID")
 		return err
 	}
 
-	row := db.conn.QueryRow(ctx, "SELECT ID FROM Users WHERE ID = $1", ID)
+	tx, err := db.pool.Begin(ctx)
 
-	var IDTemp uuid.UUID
+	if err != nil {
+		log.Error().Err(err).Msg("Failed create transaction")
+		return err
+	}
 
-	if err = row.Scan(&IDTemp); err != nil {
+	defer func() {
+		_ = tx.Rollback(ctx)
+	}()
+
+	var deletedAt *time.Time
+
+	if err = tx.QueryRow(ctx, "SELECT DeletedAt FROM Users WHERE ID = $1 FOR UPDATE", ID).Scan(&deletedAt); err != nil {
 
 		if errors.Is(err, pgx.ErrNoRows) {
 			return storageerror.ErrUserNotFound
@@ -345,76 +484,167 @@ func (db *DBStorage) DeleteUser(id string) error {
 
 	}
 
-	_, err = db.conn.Exec(ctx, "DELETE FROM Users WHERE ID = $1", ID)
+	if deletedAt != nil {
+		return storageerror.ErrUserInactive
+	}
 
-	if err != nil {
-		log.Error().Err(err).Msg("Failed delete user")
+	if _, err = tx.Exec(ctx, "UPDATE Users SET DeletedAt = $1, Version = Version + 1 WHERE ID = $2", time.Now().UTC(), ID); err != nil {
+		log.Error().Err(err).Msg("Failed deactivate user")
 		return err
 	}
 
-	return nil
+	if _, err = tx.Exec(ctx, "UPDATE Sessions SET Revoked = true WHERE UserID = $1", ID); err != nil {
+		log.Error().Err(err).Msg("Failed revoke sessions")
+		return err
+	}
+
+	return tx.Commit(ctx)
+
+}
+
+const userSelect = "SELECT ID, Name, Password, Email, Age, Role, DateRegistration, DeletedAt, AnonymizedAt, Version FROM Users"
+
+func scanUser(row pgx.Row, user *models.UserStruct) error {
+	return row.Scan(&user.ID,
+		&user.Name,
+		&user.Password,
+		&user.Email,
+		&user.Age,
+		&user.Role,
+		&user.DateRegistration,
+		&user.DeletedAt,
+		&user.AnonymizedAt,
+		&user.Version)
+}
 
+// bookSelect reads books including the trash, queries add "DeletedAt IS NULL" unless they want deleted ones.
+const bookSelect = "SELECT ID, Name, Description, Author, coalesce(ISBN10, ''), coalesce(ISBN13, ''), DateWriting, DeletedAt, Version FROM Books"
+
+func scanBook(row pgx.Row, book *models.BookStruct) error {
+	return row.Scan(&book.ID,
+		&book.Name,
+		&book.Description,
+		&book.Author,
+		&book.ISBN10,
+		&book.ISBN13,
+		&book.DateWriting,
+		&book.DeletedAt,
+		&book.Version)
 }
 
-func (db *DBStorage) GetBooks() ([]models.BookStruct, error) {
+func (db *DBStorage) GetBooks(ctx context.Context, filter models.BookFilterStruct) ([]models.BookStruct, int, error) {
 
 	log := logger.Get()
 
-	ctx, cancel := context.WithTimeout(context.Background(), time.Second*10)
+	ctx, cancel := context.WithTimeout(ctx, db.timeouts.Query)
 
 	defer cancel()
 
-	rows, err := db.conn.Query(ctx, "SELECT ID, Name, Description, Author, DateWriting FROM Books")
+	conds := []string{"DeletedAt IS NULL"}
+	var args []any
+
+	if filter.Author != "" {
+		args = append(args, "%"+filter.Author+"%")
+		conds = append(conds, fmt.Sprintf("Author ILIKE $%d", len(args)))
+	}
+
+	if len(filter.Tags) > 0 {
+		args = append(args, filter.Tags, len(filter.Tags))
+		conds = append(conds, fmt.Sprintf(
+			"ID IN (SELECT BookID FROM BookTags WHERE Tag = ANY($%d) GROUP BY BookID HAVING count(*) = $%d)",
+			len(args)-1, len(args)))
+	}
+
+	if filter.Genre != "" {
+		args = append(args, filter.Genre)
+		conds = append(conds, "ID IN (SELECT BookID FROM BookGenres WHERE GenreID IN ("+
+			fmt.Sprintf(genreSubtree, fmt.Sprintf("$%d", len(args)))+"))")
+	}
+
+	conds, args = dateRange("DateWriting", filter.ListParamsStruct, conds, args)
+
+	where := whereClause(conds)
+
+	var total int
+
+	if err := db.pool.QueryRow(ctx, "SELECT count(*) FROM Books"+where, args...).Scan(&total); err != nil {
+		log.Error().Err(err).Msg("failed get data from table Books")
+		return nil, 0, err
+	}
+
+	page, args := pageClause(bookSortColumns[filter.Sort], filter.ListParamsStruct, args)
+
+	rows, err := db.pool.Query(ctx, bookSelect+where+page, args...)
 
 	if err != nil {
 		log.Error().Err(err).Msg("failed get data from table Books")
-		return nil, err
+		return nil, 0, err
 	}
 
-	var books []models.BookStruct
+	defer rows.Close()
+
+	books := []models.BookStruct{}
 
 	for rows.Next() {
 
 		var book models.BookStruct
 
-		if err = rows.Scan(&book.ID, &book.Name, &book.Description, &book.Author, &book.DateWriting); err != nil {
+		if err = scanBook(rows, &book); err != nil {
 			log.Error().Err(err).Msg("failed scan rows data")
-			return nil, err
+			return nil, 0, err
 		}
 
 		books = append(books, book)
 
 	}
 
-	return books, nil
+	return books, total, rows.Err()
 
 }
 
-func (db *DBStorage) GetBook(id string) (models.BookStruct, error) {
+func (db *DBStorage) GetBookByISBN(ctx context.Context, isbn13 string) (models.BookStruct, error) {
 
 	log := logger.Get()
 
-	ctx, cancel := context.WithTimeout(context.Background(), time.Second*10)
+	ctx, cancel := context.WithTimeout(ctx, db.timeouts.Query)
 
 	defer cancel()
 
 	bookDB := models.BookStruct{}
 
-	ID, err := uuid.Parse(id)
+	if err := scanBook(db.pool.QueryRow(ctx, bookSelect+" WHERE ISBN13 = $1 AND DeletedAt IS NULL", isbn13), &bookDB); err != nil {
+
+		if errors.Is(err, pgx.ErrNoRows) {
+			return bookDB, storageerror.ErrBookNotFound
+		}
+
+		log.Error().Err(err).Msg("Failed get data from table Books")
+		return bookDB, err
+
+	}
+
+	return bookDB, nil
+
+}
+
+func (db *DBStorage) GetBook(ctx context.Context, id string) (models.BookStruct, error) {
+
+	log := logger.Get()
+
+	ctx, cancel := context.WithTimeout(ctx, db.timeouts.Query)
+
+	defer cancel()
+
+	bookDB := models.BookStruct{}
+
+	ID, err := parseID(id)
 
 	if err != nil {
 		log.Error().Err(err).Msg("Failed parse ID")
 		return bookDB, err
 	}
 
-	row := db.conn.QueryRow(ctx,
-		"SELECT ID, Name, Description, Author, DateWriting FROM Books WHERE ID = $1", ID)
-
-	if err = row.Scan(&bookDB.ID,
-		&bookDB.Name,
-		&bookDB.Description,
-		&bookDB.Author,
-		&bookDB.DateWriting); err != nil {
+	if err = scanBook(db.pool.QueryRow(ctx, bookSelect+" WHERE ID = $1 AND DeletedAt IS NULL", ID), &bookDB); err != nil {
 
 		if errors.Is(err, pgx.ErrNoRows) {
 			return bookDB, storageerror.ErrBookNotFound
@@ -429,16 +659,23 @@ func (db *DBStorage) GetBook(id string) (models.BookStruct, error) {
 
 }
 
-func (db *DBStorage) SaveBook(book models.BookStruct) (string, error) {
+func (db *DBStorage) SaveBook(ctx context.Context, book models.BookStruct) (string, error) {
 
 	log := logger.Get()
 
-	ctx, cancel := context.WithTimeout(context.Background(), time.Second*10)
+	ctx, cancel := context.WithTimeout(ctx, db.timeouts.Query)
 
 	defer cancel()
 
-	row := db.conn.QueryRow(ctx,
-		"SELECT ID FROM Books WHERE Name = $1 and Author = $2", book.Name, book.Author)
+	// Editions with an ISBN are told apart by it, books without one by name and author.
+	var row pgx.Row
+
+	if book.ISBN13 != "" {
+		row = db.pool.QueryRow(ctx, "SELECT ID FROM Books WHERE ISBN13 = $1 AND DeletedAt IS NULL", book.ISBN13)
+	} else {
+		row = db.pool.QueryRow(ctx,
+			"SELECT ID FROM Books WHERE Name = $1 and Author = $2 AND DeletedAt IS NULL", book.Name, book.Author)
+	}
 
 	var IDTemp uuid.UUID
 
@@ -455,12 +692,43 @@ func (db *DBStorage) SaveBook(book models.BookStruct) (string, error) {
 
 	book.ID = uuid.New()
 
-	_, err := db.conn.Exec(ctx,
-		"INSERT INTO Books (ID, Name, Description, Author, DateWriting) VALUES ($1, $2, $3, $4, $5)",
-		book.ID, book.Name, book.Description, book.Author, book.DateWriting)
+	tx, err := db.pool.Begin(ctx)
+
+	if err != nil {
+		log.Error().Err(err).Msg("Failed create transaction")
+		return "", err
+	}
+
+	defer func() {
+		_ = tx.Rollback(ctx)
+	}()
+
+	_, err = tx.Exec(ctx,
+		`INSERT INTO Books (ID, Name, Description, Author, ISBN10, ISBN13, DateWriting)
+		VALUES ($1, $2, $3, $4, NULLIF($5, ''), NULLIF($6, ''), $7)`,
+		book.ID, book.Name, book.Description, book.Author, book.ISBN10, book.ISBN13, book.DateWriting)
 
 	if err != nil {
+
+		var pgErr *pgconn.PgError
+
+		if errors.As(err, &pgErr) && pgErr.Code == pgerrcode.UniqueViolation {
+			return "", storageerror.ErrBookAlreadyExist
+		}
+
 		log.Error().Err(err).Msg("Failed save book")
+
+		return "", err
+
+	}
+
+	if err = saveRevision(ctx, tx, book.ID); err != nil {
+		log.Error().Err(err).Msg("Failed save book revision")
+		return "", err
+	}
+
+	if err = tx.Commit(ctx); err != nil {
+		log.Error().Err(err).Msg("Failed commit transaction")
 		return "", err
 	}
 
@@ -468,15 +736,17 @@ func (db *DBStorage) SaveBook(book models.BookStruct) (string, error) {
 
 }
 
-func (db *DBStorage) EditBook(id string, book models.BookStruct) error {
+// EditBook updates the book and saves a revision. A non-zero book.Version must match the stored one,
+// otherwise the book was changed by someone else and ErrVersionConflict is returned.
+func (db *DBStorage) EditBook(ctx context.Context, id string, book models.BookStruct) error {
 
 	log := logger.Get()
 
-	ctx, cancel := context.WithTimeout(context.Background(), time.Second*10)
+	ctx, cancel := context.WithTimeout(ctx, db.timeouts.Query)
 
 	defer cancel()
 
-	ID, err := uuid.Parse(id)
+	ID, err := parseID(id)
 
 	if err != nil {
 		log.Error().Err(err).Msg("Failed parse ID")
@@ -485,12 +755,13 @@ func (db *DBStorage) EditBook(id string, book models.BookStruct) error {
 
 	var bookDB models.BookStruct
 
-	row := db.conn.QueryRow(ctx,
-		"SELECT ID, Name, Author FROM Books WHERE ID = $1", ID)
+	row := db.pool.QueryRow(ctx,
+		"SELECT ID, Name, Author, Version FROM Books WHERE ID = $1 AND DeletedAt IS NULL", ID)
 
 	if err = row.Scan(&bookDB.ID,
 		&bookDB.Name,
-		&bookDB.Author); err != nil {
+		&bookDB.Author,
+		&bookDB.Version); err != nil {
 
 		if errors.Is(err, pgx.ErrNoRows) {
 			return storageerror.ErrBookNotFound
@@ -501,32 +772,73 @@ func (db *DBStorage) EditBook(id string, book models.BookStruct) error {
 
 	}
 
-	if bookDB.Name != book.Name || bookDB.Author != book.Author {
-
-		row = db.conn.QueryRow(ctx,
-			"SELECT ID FROM Books WHERE Name = $1 AND Author=$2", book.Name, bookDB.Author)
+	if book.Version != 0 && book.Version != bookDB.Version {
+		return storageerror.ErrVersionConflict
+	}
 
-		var IDTemp uuid.UUID
+	// The duplicate rule of SaveBook: the edition is told apart by its ISBN, a book without one by name and author.
+	if book.ISBN13 != "" {
+		row = db.pool.QueryRow(ctx,
+			"SELECT ID FROM Books WHERE ISBN13 = $1 AND ID <> $2 AND DeletedAt IS NULL", book.ISBN13, ID)
+	} else {
+		row = db.pool.QueryRow(ctx,
+			"SELECT ID FROM Books WHERE Name = $1 AND Author = $2 AND ID <> $3 AND DeletedAt IS NULL", book.Name, book.Author, ID)
+	}
 
-		if err = row.Scan(&IDTemp); err != nil {
+	var IDTemp uuid.UUID
 
-			if !errors.Is(err, pgx.ErrNoRows) {
-				log.Error().Err(err).Msg("Failed get data from table Books")
-				return err
-			}
+	if err = row.Scan(&IDTemp); err != nil {
 
-		} else {
-			return fmt.Errorf("book with name '%s' and author '%s' already exists", book.Name, bookDB.Author)
+		if !errors.Is(err, pgx.ErrNoRows) {
+			log.Error().Err(err).Msg("Failed get data from table Books")
+			return err
 		}
 
+	} else {
+		return storageerror.ErrBookAlreadyExist
 	}
 
-	_, err = db.conn.Exec(ctx,
-		"UPDATE Books SET Name = $1, Description = $2, Author = $3,  DateWriting =$4 WHERE ID = $5",
-		book.Name, book.Description, book.Author, book.DateWriting, bookDB.ID)
+	tx, err := db.pool.Begin(ctx)
 
 	if err != nil {
+		log.Error().Err(err).Msg("Failed create transaction")
+		return err
+	}
+
+	defer func() {
+		_ = tx.Rollback(ctx)
+	}()
+
+	tag, err := tx.Exec(ctx,
+		`UPDATE Books SET Name = $1, Description = $2, Author = $3, ISBN10 = NULLIF($4, ''), ISBN13 = NULLIF($5, ''),
+		DateWriting = $6, Version = Version + 1 WHERE ID = $7 AND Version = $8`,
+		book.Name, book.Description, book.Author, book.ISBN10, book.ISBN13, book.DateWriting, bookDB.ID, bookDB.Version)
+
+	if err != nil {
+
+		var pgErr *pgconn.PgError
+
+		if errors.As(err, &pgErr) && pgErr.Code == pgerrcode.UniqueViolation {
+			return storageerror.ErrBookAlreadyExist
+		}
+
 		log.Error().Err(err).Msg("Failed edit book")
+
+		return err
+
+	}
+
+	if tag.RowsAffected() == 0 {
+		return storageerror.ErrVersionConflict
+	}
+
+	if err = saveRevision(ctx, tx, bookDB.ID); err != nil {
+		log.Error().Err(err).Msg("Failed save book revision")
+		return err
+	}
+
+	if err = tx.Commit(ctx); err != nil {
+		log.Error().Err(err).Msg("Failed commit transaction")
 		return err
 	}
 
@@ -534,22 +846,22 @@ func (db *DBStorage) EditBook(id string, book models.BookStruct) error {
 
 }
 
-func (db *DBStorage) DeleteBook(id string) error {
+func (db *DBStorage) DeleteBook(ctx context.Context, id string) error {
 
 	log := logger.Get()
 
-	ctx, cancel := context.WithTimeout(context.Background(), time.Second*10)
+	ctx, cancel := context.WithTimeout(ctx, db.timeouts.Query)
 
 	defer cancel()
 
-	ID, err := uuid.Parse(id)
+	ID, err := parseID(id)
 
 	if err != nil {
 		log.Error().Err(err).Msg("Failed parse ID")
 		return err
 	}
 
-	row := db.conn.QueryRow(ctx, "SELECT ID FROM Books WHERE ID = $1", ID)
+	row := db.pool.QueryRow(ctx, "SELECT ID FROM Books WHERE ID = $1 AND DeletedAt IS NULL", ID)
 
 	var IDTemp uuid.UUID
 
@@ -564,7 +876,7 @@ func (db *DBStorage) DeleteBook(id string) error {
 
 	}
 
-	_, err = db.conn.Exec(ctx, "UPDATE Books SET Deleted = true WHERE ID = $1", ID)
+	_, err = db.pool.Exec(ctx, "UPDATE Books SET DeletedAt = $1, Version = Version + 1 WHERE ID = $2", time.Now().UTC(), ID)
 
 	if err != nil {
 		log.Error().Err(err).Msg("Failed delete book")
@@ -575,35 +887,139 @@ func (db *DBStorage) DeleteBook(id string) error {
 
 }
 
-func (db *DBStorage) DeleteBooks() error {
+// DeleteBooks purges the books moved to the trash before the given time and returns how many were removed.
+// Books with loans or holds stay in the trash, the loan history and the fines refer to them.
+func (db *DBStorage) DeleteBooks(ctx context.Context, before time.Time) (int, error) {
 
 	log := logger.Get()
 
-	ctx, cancel := context.WithTimeout(context.Background(), time.Second*10)
+	ctx, cancel := context.WithTimeout(ctx, db.timeouts.Query)
 
 	defer cancel()
 
-	tx, err := db.conn.Begin(ctx)
+	tag, err := db.pool.Exec(ctx,
+		`DELETE FROM Books WHERE DeletedAt < $1
+		AND NOT EXISTS (SELECT 1 FROM Loans WHERE Loans.BookID = Books.ID)
+		AND NOT EXISTS (SELECT 1 FROM Holds WHERE Holds.BookID = Books.ID)`, before)
 
 	if err != nil {
-		log.Error().Err(err).Msg("Failed create transaction")
+		log.Error().Err(err).Msg("Failed delete book")
+		return 0, err
+	}
+
+	return int(tag.RowsAffected()), nil
+
+}
+
+func (db *DBStorage) userExists(ctx context.Context, ID uuid.UUID) error {
+
+	log := logger.Get()
+
+	var IDTemp uuid.UUID
+
+	row := db.pool.QueryRow(ctx, "SELECT ID FROM Users WHERE ID = $1", ID)
+
+	if err := row.Scan(&IDTemp); err != nil {
+
+		if errors.Is(err, pgx.ErrNoRows) {
+			return storageerror.ErrUserNotFound
+		}
+
+		log.Error().Err(err).Msg("Failed get data from table Users")
 		return err
+
 	}
 
-	defer func() {
-		err = tx.Rollback(ctx)
-		if err != nil {
-			log.Error().Err(err).Msg("Failed rollback transaction")
+	return nil
+
+}
+
+func (db *DBStorage) bookExists(ctx context.Context, ID uuid.UUID) error {
+
+	log := logger.Get()
+
+	var IDTemp uuid.UUID
+
+	row := db.pool.QueryRow(ctx, "SELECT ID FROM Books WHERE ID = $1 AND DeletedAt IS NULL", ID)
+
+	if err := row.Scan(&IDTemp); err != nil {
+
+		if errors.Is(err, pgx.ErrNoRows) {
+			return storageerror.ErrBookNotFound
 		}
-	}()
 
-	_, err = db.conn.Exec(ctx, "DELETE FROM Books WHERE Deleted = true")
+		log.Error().Err(err).Msg("Failed get data from table Books")
+		return err
+
+	}
+
+	return nil
+
+}
+
+// parseID parses an ID that came from a client. A malformed one is storageerror.ErrInvalidID,
+// MapStorage and DBStorage answer it the same way.
+func parseID(id string) (uuid.UUID, error) {
+
+	ID, err := uuid.Parse(id)
 
 	if err != nil {
-		log.Error().Err(err).Msg("Failed delete book")
-		return err
+		return uuid.Nil, fmt.Errorf("%w: %q", storageerror.ErrInvalidID, id)
 	}
 
-	return tx.Commit(ctx)
+	return ID, nil
+
+}
+
+// Sort keys accepted from the client mapped to columns, so that nothing from the query string
+// ends up in the SQL text.
+var (
+	userSortColumns = map[string]string{"name": "Name", "email": "Email", "date": "DateRegistration"}
+	bookSortColumns = map[string]string{"name": "Name", "author": "Author", "date": "DateWriting"}
+)
+
+// dateRange adds the from/to conditions on column. The "to" day is included.
+func dateRange(column string, params models.ListParamsStruct, conds []string, args []any) ([]string, []any) {
+
+	if !params.DateFrom.IsZero() {
+		args = append(args, params.DateFrom)
+		conds = append(conds, fmt.Sprintf("%s >= $%d", column, len(args)))
+	}
+
+	if !params.DateTo.IsZero() {
+		args = append(args, params.DateTo.AddDate(0, 0, 1))
+		conds = append(conds, fmt.Sprintf("%s < $%d", column, len(args)))
+	}
+
+	return conds, args
+
+}
+
+func whereClause(conds []string) string {
+
+	if len(conds) == 0 {
+		return ""
+	}
+
+	return " WHERE " + strings.Join(conds, " AND ")
+
+}
+
+// pageClause orders by column and ID, so that pages stay stable between requests, and cuts the page.
+func pageClause(column string, params models.ListParamsStruct, args []any) (string, []any) {
+
+	if column == "" {
+		column = "Name"
+	}
+
+	order := " ASC"
+
+	if params.Order == "desc" {
+		order = " DESC"
+	}
+
+	args = append(args, params.Limit, params.Offset)
+
+	return fmt.Sprintf(" ORDER BY %s%s, ID LIMIT $%d OFFSET $%d", column, order, len(args)-1, len(args)), args
 
 }
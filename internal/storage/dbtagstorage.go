@@ -0,0 +1,148 @@
+package storage
+
+import (
+	"context"
+	"library/internal/domain/models"
+	"library/internal/logger"
+	"library/internal/storage/storageerror"
+)
+
+func (db *DBStorage) GetTags() ([]models.TagStruct, error) {
+
+	log := logger.Get()
+
+	ctx, cancel := context.WithTimeout(context.Background(), db.timeouts.Query)
+
+	defer cancel()
+
+	rows, err := db.pool.Query(ctx, `SELECT t.Tag, count(*) FROM BookTags t JOIN Books b ON b.ID = t.BookID
+		WHERE b.DeletedAt IS NULL GROUP BY t.Tag ORDER BY t.Tag`)
+
+	if err != nil {
+		log.Error().Err(err).Msg("Failed get data from table BookTags")
+		return nil, err
+	}
+
+	defer rows.Close()
+
+	tags := []models.TagStruct{}
+
+	for rows.Next() {
+
+		var tag models.TagStruct
+
+		if err = rows.Scan(&tag.Name, &tag.Books); err != nil {
+			log.Error().Err(err).Msg("Failed scan rows data")
+			return nil, err
+		}
+
+		tags = append(tags, tag)
+
+	}
+
+	return tags, rows.Err()
+
+}
+
+func (db *DBStorage) GetBookTags(bookID string) ([]string, error) {
+
+	log := logger.Get()
+
+	ctx, cancel := context.WithTimeout(context.Background(), db.timeouts.Query)
+
+	defer cancel()
+
+	ID, err := parseID(bookID)
+
+	if err != nil {
+		log.Error().Err(err).Msg("Failed parse ID")
+		return nil, err
+	}
+
+	rows, err := db.pool.Query(ctx, "SELECT Tag FROM BookTags WHERE BookID = $1 ORDER BY Tag", ID)
+
+	if err != nil {
+		log.Error().Err(err).Msg("Failed get data from table BookTags")
+		return nil, err
+	}
+
+	defer rows.Close()
+
+	tags := []string{}
+
+	for rows.Next() {
+
+		var tag string
+
+		if err = rows.Scan(&tag); err != nil {
+			log.Error().Err(err).Msg("Failed scan rows data")
+			return nil, err
+		}
+
+		tags = append(tags, tag)
+
+	}
+
+	return tags, rows.Err()
+
+}
+
+func (db *DBStorage) AddBookTag(bookID string, tag string) error {
+
+	log := logger.Get()
+
+	ctx, cancel := context.WithTimeout(context.Background(), db.timeouts.Query)
+
+	defer cancel()
+
+	ID, err := parseID(bookID)
+
+	if err != nil {
+		log.Error().Err(err).Msg("Failed parse ID")
+		return err
+	}
+
+	if err = db.bookExists(ctx, ID); err != nil {
+		return err
+	}
+
+	_, err = db.pool.Exec(ctx, "INSERT INTO BookTags (BookID, Tag) VALUES ($1, $2) ON CONFLICT DO NOTHING", ID, tag)
+
+	if err != nil {
+		log.Error().Err(err).Msg("Failed save book tag")
+		return err
+	}
+
+	return nil
+
+}
+
+func (db *DBStorage) RemoveBookTag(bookID string, tag string) error {
+
+	log := logger.Get()
+
+	ctx, cancel := context.WithTimeout(context.Background(), db.timeouts.Query)
+
+	defer cancel()
+
+	ID, err := parseID(bookID)
+
+	if err != nil {
+		log.Error().Err(err).Msg("Failed parse ID")
+		return err
+	}
+
+	result, err := db.pool.Exec(ctx, "DELETE FROM BookTags WHERE BookID = $1 AND Tag = $2", ID, tag)
+
+	if err != nil {
+		log.Error().Err(err).Msg("Failed delete book tag")
+		return err
+	}
+
+	if result.RowsAffected() == 0 {
+		return storageerror.ErrTagNotFound
+	}
+
+	return nil
+
+}
@@ -0,0 +1,134 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"github.com/google/uuid"
+	"github.com/jackc/pgerrcode"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"library/internal/domain/models"
+	"library/internal/logger"
+	"library/internal/storage/storageerror"
+)
+
+// GetDeletedBooks returns one page of the trash ordered by the time of deletion.
+// The from/to filter applies to that time too.
+func (db *DBStorage) GetDeletedBooks(ctx context.Context, params models.ListParamsStruct) ([]models.BookStruct, int, error) {
+
+	log := logger.Get()
+
+	ctx, cancel := context.WithTimeout(ctx, db.timeouts.Query)
+
+	defer cancel()
+
+	conds, args := dateRange("DeletedAt", params, []string{"DeletedAt IS NOT NULL"}, nil)
+
+	where := whereClause(conds)
+
+	var total int
+
+	if err := db.pool.QueryRow(ctx, "SELECT count(*) FROM Books"+where, args...).Scan(&total); err != nil {
+		log.Error().Err(err).Msg("Failed get data from table Books")
+		return nil, 0, err
+	}
+
+	page, args := pageClause("DeletedAt", params, args)
+
+	rows, err := db.pool.Query(ctx, bookSelect+where+page, args...)
+
+	if err != nil {
+		log.Error().Err(err).Msg("Failed get data from table Books")
+		return nil, 0, err
+	}
+
+	defer rows.Close()
+
+	books := []models.BookStruct{}
+
+	for rows.Next() {
+
+		var book models.BookStruct
+
+		if err = scanBook(rows, &book); err != nil {
+			log.Error().Err(err).Msg("Failed scan rows data")
+			return nil, 0, err
+		}
+
+		books = append(books, book)
+
+	}
+
+	return books, total, rows.Err()
+
+}
+
+// RestoreBook takes the book out of the trash. It fails with ErrBookAlreadyExist when a book
+// with the same ISBN, or the same name and author, was added since.
+func (db *DBStorage) RestoreBook(ctx context.Context, id string) error {
+
+	log := logger.Get()
+
+	ctx, cancel := context.WithTimeout(ctx, db.timeouts.Query)
+
+	defer cancel()
+
+	ID, err := parseID(id)
+
+	if err != nil {
+		log.Error().Err(err).Msg("Failed parse ID")
+		return err
+	}
+
+	var bookDB models.BookStruct
+
+	if err = scanBook(db.pool.QueryRow(ctx, bookSelect+" WHERE ID = $1 AND DeletedAt IS NOT NULL", ID), &bookDB); err != nil {
+
+		if errors.Is(err, pgx.ErrNoRows) {
+			return storageerror.ErrBookNotFound
+		}
+
+		log.Error().Err(err).Msg("Failed get data from table Books")
+		return err
+
+	}
+
+	var row pgx.Row
+
+	if bookDB.ISBN13 != "" {
+		row = db.pool.QueryRow(ctx, "SELECT ID FROM Books WHERE ISBN13 = $1 AND DeletedAt IS NULL", bookDB.ISBN13)
+	} else {
+		row = db.pool.QueryRow(ctx,
+			"SELECT ID FROM Books WHERE Name = $1 AND Author = $2 AND DeletedAt IS NULL", bookDB.Name, bookDB.Author)
+	}
+
+	var IDTemp uuid.UUID
+
+	if err = row.Scan(&IDTemp); err != nil {
+
+		if !errors.Is(err, pgx.ErrNoRows) {
+			log.Error().Err(err).Msg("Failed get data from table Books")
+			return err
+		}
+
+	} else {
+		return storageerror.ErrBookAlreadyExist
+	}
+
+	if _, err = db.pool.Exec(ctx, "UPDATE Books SET DeletedAt = NULL, Version = Version + 1 WHERE ID = $1", ID); err != nil {
+
+		var pgErr *pgconn.PgError
+
+		if errors.As(err, &pgErr) && pgErr.Code == pgerrcode.UniqueViolation {
+			return storageerror.ErrBookAlreadyExist
+		}
+
+		log.Error().Err(err).Msg("Failed restore book")
+
+		return err
+
+	}
+
+	return nil
+
+}
@@ -0,0 +1,74 @@
+package storage
+
+import (
+	"context"
+	"library/internal/domain/models"
+	"os"
+	"testing"
+	"time"
+)
+
+// TestImportBooksISBN runs on MapStorage and, when TEST_DB_DSN points to a scratch database, on DBStorage.
+// A row with an ISBN is told apart by it, a row without one by name and author.
+func TestImportBooksISBN(t *testing.T) {
+
+	storages := map[string]interface {
+		ImportBooks(context.Context, []models.ImportRowStruct, int) error
+		DeleteBook(context.Context, string) error
+	}{"map": NewMapStorage()}
+
+	if dsn := os.Getenv("TEST_DB_DSN"); dsn != "" {
+
+		if err := Migrations(dsn, "../../migrations"); err != nil {
+			t.Fatalf("Migrations: %v", err)
+		}
+
+		db, err := NewDBStorage(context.Background(), dsn, PoolConfigStruct{},
+			TimeoutsStruct{Query: 10 * time.Second, Import: time.Minute, Export: time.Minute})
+
+		if err != nil {
+			t.Fatalf("NewDBStorage: %v", err)
+		}
+
+		t.Cleanup(func() { _ = db.Close() })
+
+		storages["db"] = db
+
+	}
+
+	for name, st := range storages {
+
+		t.Run(name, func(t *testing.T) {
+
+			ctx := context.Background()
+			isbn := "979" + time.Now().Format("0405") + "000000"
+
+			rows := []models.ImportRowStruct{
+				{Row: 1, Book: models.BookStruct{Name: "With ISBN", Author: "Author", ISBN13: isbn}},
+				{Row: 2, Book: models.BookStruct{Name: "Other name", Author: "Author", ISBN13: isbn}},
+				{Row: 3, Book: models.BookStruct{Name: "Without ISBN " + isbn, Author: "Author"}},
+			}
+
+			if err := st.ImportBooks(ctx, rows, 2); err != nil {
+				t.Fatalf("ImportBooks: %v", err)
+			}
+
+			want := []string{models.ImportCreated, models.ImportDuplicate, models.ImportCreated}
+
+			for i, row := range rows {
+
+				if row.Status == models.ImportCreated {
+					t.Cleanup(func() { _ = st.DeleteBook(ctx, row.ID) })
+				}
+
+				if row.Status != want[i] {
+					t.Errorf("row %d: got status %q, want %q", row.Row, row.Status, want[i])
+				}
+
+			}
+
+		})
+
+	}
+
+}
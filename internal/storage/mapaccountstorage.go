@@ -0,0 +1,89 @@
+package storage
+
+import (
+	"context"
+	"library/internal/storage/storageerror"
+	"time"
+)
+
+func (ms *MapStorage) ReactivateUser(_ context.Context, id string) error {
+
+	ms.mu.Lock()
+	defer ms.mu.Unlock()
+
+	user, ok := ms.userStorage[id]
+
+	switch {
+	case !ok:
+		return storageerror.ErrUserNotFound
+	case user.AnonymizedAt != nil:
+		return storageerror.ErrUserAnonymized
+	case user.DeletedAt == nil:
+		return storageerror.ErrUserActive
+	}
+
+	user.DeletedAt = nil
+	user.Version++
+
+	ms.userStorage[id] = user
+
+	return nil
+
+}
+
+func (ms *MapStorage) AnonymizeUser(_ context.Context, id string) error {
+
+	ms.mu.Lock()
+	defer ms.mu.Unlock()
+
+	user, ok := ms.userStorage[id]
+
+	switch {
+	case !ok:
+		return storageerror.ErrUserNotFound
+	case user.AnonymizedAt != nil:
+		return storageerror.ErrUserAnonymized
+	case user.DeletedAt == nil:
+		return storageerror.ErrUserActive
+	}
+
+	now := time.Now().UTC()
+	email := user.Email
+
+	user.Name = anonymizedName
+	user.Email = anonymizedEmail(user.ID)
+	user.Password = ""
+	user.Age = 0
+	user.AnonymizedAt = &now
+	user.Version++
+
+	ms.userStorage[id] = user
+	ms.indexUserEmail(id, email, user.Email)
+
+	for key, session := range ms.sessionStorage {
+
+		if session.UserID == user.ID {
+			delete(ms.sessionStorage, key)
+		}
+
+	}
+
+	return nil
+
+}
+
+// activeUser mirrors the DBStorage check of the same name.
+func (ms *MapStorage) activeUser(id string) error {
+
+	user, ok := ms.userStorage[id]
+
+	switch {
+	case !ok:
+		return storageerror.ErrUserNotFound
+	case user.DeletedAt != nil:
+		return storageerror.ErrUserInactive
+	}
+
+	return nil
+
+}
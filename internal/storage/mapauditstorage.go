@@ -0,0 +1,57 @@
+package storage
+
+import (
+	"library/internal/domain/models"
+	"sort"
+)
+
+func (ms *MapStorage) SaveAudit(entry models.AuditStruct) error {
+
+	ms.mu.Lock()
+	defer ms.mu.Unlock()
+
+	ms.auditStorage = append(ms.auditStorage, entry)
+
+	return nil
+
+}
+
+func (ms *MapStorage) GetAudit(filter models.AuditFilterStruct) ([]models.AuditStruct, int, error) {
+
+	ms.mu.RLock()
+	defer ms.mu.RUnlock()
+
+	entries := []models.AuditStruct{}
+
+	for _, entry := range ms.auditStorage {
+
+		if filter.Actor != "" && entry.Actor != filter.Actor ||
+			filter.Action != "" && entry.Action != filter.Action ||
+			filter.Entity != "" && entry.Entity != filter.Entity ||
+			filter.EntityID != "" && entry.EntityID != filter.EntityID {
+			continue
+		}
+
+		if !inDateRange(entry.DateCreated, filter.ListParamsStruct) {
+			continue
+		}
+
+		entries = append(entries, entry)
+
+	}
+
+	sort.SliceStable(entries, func(i, j int) bool {
+
+		if !entries[i].DateCreated.Equal(entries[j].DateCreated) {
+			return entries[i].DateCreated.Before(entries[j].DateCreated) != (filter.Order == "desc")
+		}
+
+		return lessByKey("", "", entries[i].ID.String(), entries[j].ID.String(), filter.Order)
+
+	})
+
+	start, end := pageBounds(len(entries), filter.ListParamsStruct)
+
+	return entries[start:end], len(entries), nil
+
+}
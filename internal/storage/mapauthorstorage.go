@@ -0,0 +1,167 @@
+package storage
+
+import (
+	"github.com/google/uuid"
+	"library/internal/domain/models"
+	"library/internal/storage/storageerror"
+	"slices"
+	"sort"
+)
+
+func (ms *MapStorage) GetAuthors() ([]models.AuthorStruct, error) {
+
+	ms.mu.RLock()
+	defer ms.mu.RUnlock()
+
+	authors := []models.AuthorStruct{}
+
+	for _, author := range ms.authorStorage {
+		authors = append(authors, author)
+	}
+
+	sort.Slice(authors, func(i, j int) bool {
+		return lessByKey(authors[i].Name, authors[j].Name, authors[i].ID.String(), authors[j].ID.String(), "asc")
+	})
+
+	return authors, nil
+
+}
+
+func (ms *MapStorage) GetAuthor(id string) (models.AuthorStruct, error) {
+
+	ms.mu.RLock()
+	defer ms.mu.RUnlock()
+
+	author, ok := ms.authorStorage[id]
+
+	if !ok {
+		return models.AuthorStruct{}, storageerror.ErrAuthorNotFound
+	}
+
+	return author, nil
+
+}
+
+func (ms *MapStorage) SaveAuthor(author models.AuthorStruct) (string, error) {
+
+	ms.mu.Lock()
+	defer ms.mu.Unlock()
+
+	return ms.saveAuthor(author)
+
+}
+
+func (ms *MapStorage) saveAuthor(author models.AuthorStruct) (string, error) {
+
+	author.ID = uuid.New()
+
+	ms.authorStorage[author.ID.String()] = author
+
+	return author.ID.String(), nil
+
+}
+
+func (ms *MapStorage) EditAuthor(id string, author models.AuthorStruct) error {
+
+	ms.mu.Lock()
+	defer ms.mu.Unlock()
+
+	authorMS, ok := ms.authorStorage[id]
+
+	if !ok {
+		return storageerror.ErrAuthorNotFound
+	}
+
+	author.ID = authorMS.ID
+
+	ms.authorStorage[id] = author
+
+	return nil
+
+}
+
+func (ms *MapStorage) DeleteAuthor(id string) error {
+
+	ms.mu.Lock()
+	defer ms.mu.Unlock()
+
+	if _, ok := ms.authorStorage[id]; !ok {
+		return storageerror.ErrAuthorNotFound
+	}
+
+	for _, authorIDs := range ms.bookAuthorStorage {
+
+		if slices.Contains(authorIDs, id) {
+			return storageerror.ErrAuthorHasBooks
+		}
+
+	}
+
+	delete(ms.authorStorage, id)
+
+	return nil
+
+}
+
+func (ms *MapStorage) EnsureAuthor(name string) (string, error) {
+
+	ms.mu.Lock()
+	defer ms.mu.Unlock()
+
+	var found string
+
+	for id, author := range ms.authorStorage {
+
+		if author.Name == name && (found == "" || id < found) {
+			found = id
+		}
+
+	}
+
+	if found != "" {
+		return found, nil
+	}
+
+	return ms.saveAuthor(models.AuthorStruct{Name: name})
+
+}
+
+func (ms *MapStorage) GetBookAuthors(bookID string) ([]models.AuthorStruct, error) {
+
+	ms.mu.RLock()
+	defer ms.mu.RUnlock()
+
+	authors := []models.AuthorStruct{}
+
+	for _, id := range ms.bookAuthorStorage[bookID] {
+		authors = append(authors, ms.authorStorage[id])
+	}
+
+	return authors, nil
+
+}
+
+func (ms *MapStorage) SetBookAuthors(bookID string, authorIDs []string) error {
+
+	ms.mu.Lock()
+	defer ms.mu.Unlock()
+
+	var linked []string
+
+	for _, id := range authorIDs {
+
+		if _, ok := ms.authorStorage[id]; !ok {
+			return storageerror.ErrAuthorNotFound
+		}
+
+		if !slices.Contains(linked, id) {
+			linked = append(linked, id)
+		}
+
+	}
+
+	ms.bookAuthorStorage[bookID] = linked
+
+	return nil
+
+}
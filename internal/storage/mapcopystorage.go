@@ -0,0 +1,175 @@
+package storage
+
+import (
+	"github.com/google/uuid"
+	"library/internal/domain/models"
+	"library/internal/storage/storageerror"
+	"sort"
+	"time"
+)
+
+func (ms *MapStorage) GetCopies(bookID string) ([]models.CopyStruct, error) {
+
+	ms.mu.RLock()
+	defer ms.mu.RUnlock()
+
+	if _, ok := ms.activeBook(bookID); !ok {
+		return nil, storageerror.ErrBookNotFound
+	}
+
+	var copies []models.CopyStruct
+
+	for _, cp := range ms.copyStorage {
+
+		if cp.BookID.String() == bookID {
+			cp.Available = ms.copyFree(cp.ID)
+			copies = append(copies, cp)
+		}
+
+	}
+
+	sort.Slice(copies, func(i, j int) bool { return copies[i].Barcode < copies[j].Barcode })
+
+	return copies, nil
+
+}
+
+func (ms *MapStorage) GetCopy(bookID string, copyID string) (models.CopyStruct, error) {
+
+	ms.mu.RLock()
+	defer ms.mu.RUnlock()
+
+	cp, ok := ms.copyStorage[copyID]
+
+	if !ok || cp.BookID.String() != bookID {
+		return models.CopyStruct{}, storageerror.ErrCopyNotFound
+	}
+
+	cp.Available = ms.copyFree(cp.ID)
+
+	return cp, nil
+
+}
+
+func (ms *MapStorage) SaveCopy(bookID string, cp models.CopyStruct) (string, error) {
+
+	ms.mu.Lock()
+	defer ms.mu.Unlock()
+
+	book, ok := ms.activeBook(bookID)
+
+	if !ok {
+		return "", storageerror.ErrBookNotFound
+	}
+
+	for _, cpMS := range ms.copyStorage {
+
+		if cpMS.Barcode == cp.Barcode {
+			return "", storageerror.ErrCopyAlreadyExist
+		}
+
+	}
+
+	ID := uuid.New()
+	IDStr := ID.String()
+
+	cp.ID = ID
+	cp.BookID = book.ID
+	cp.Available = false
+
+	if cp.DateAcquisition.IsZero() {
+		cp.DateAcquisition = time.Now()
+	}
+
+	ms.copyStorage[IDStr] = cp
+
+	return IDStr, nil
+
+}
+
+func (ms *MapStorage) EditCopy(bookID string, copyID string, cp models.CopyStruct) error {
+
+	ms.mu.Lock()
+	defer ms.mu.Unlock()
+
+	cpMS, ok := ms.copyStorage[copyID]
+
+	if !ok || cpMS.BookID.String() != bookID {
+		return storageerror.ErrCopyNotFound
+	}
+
+	if cpMS.Barcode != cp.Barcode {
+
+		for _, cpCheck := range ms.copyStorage {
+
+			if cpCheck.Barcode == cp.Barcode {
+				return storageerror.ErrCopyAlreadyExist
+			}
+
+		}
+
+	}
+
+	if cp.DateAcquisition.IsZero() {
+		cp.DateAcquisition = cpMS.DateAcquisition
+	}
+
+	cp.ID = cpMS.ID
+	cp.BookID = cpMS.BookID
+	cp.Available = false
+
+	ms.copyStorage[copyID] = cp
+
+	return nil
+
+}
+
+func (ms *MapStorage) DeleteCopy(bookID string, copyID string) error {
+
+	ms.mu.Lock()
+	defer ms.mu.Unlock()
+
+	cp, ok := ms.copyStorage[copyID]
+
+	if !ok || cp.BookID.String() != bookID {
+		return storageerror.ErrCopyNotFound
+	}
+
+	if ms.copyLoaned(cp.ID) {
+		return storageerror.ErrCopyAlreadyLoaned
+	}
+
+	if _, reserved := ms.copyReserved(cp.ID); reserved {
+		return storageerror.ErrCopyReserved
+	}
+
+	delete(ms.copyStorage, copyID)
+
+	return nil
+
+}
+
+func (ms *MapStorage) GetBookAvailability(bookID string) (models.AvailabilityStruct, error) {
+
+	ms.mu.RLock()
+	defer ms.mu.RUnlock()
+
+	var availability models.AvailabilityStruct
+
+	for _, cp := range ms.copyStorage {
+
+		if cp.BookID.String() != bookID {
+			continue
+		}
+
+		availability.Total++
+
+		if ms.copyFree(cp.ID) {
+			availability.Available++
+		}
+
+	}
+
+	return availability, nil
+
+}
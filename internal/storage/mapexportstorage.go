@@ -0,0 +1,70 @@
+package storage
+
+import (
+	"context"
+	"library/internal/domain/models"
+	"sort"
+)
+
+// ExportBooks copies the books under the lock and calls fn without it, a slow reader of the export
+// does not hold up the writers.
+func (ms *MapStorage) ExportBooks(_ context.Context, fn func(models.BookStruct) error) error {
+
+	ms.mu.RLock()
+
+	books := make([]models.BookStruct, 0, len(ms.bookStorage))
+
+	for _, book := range ms.bookStorage {
+
+		if book.DeletedAt == nil {
+			books = append(books, book)
+		}
+
+	}
+
+	ms.mu.RUnlock()
+
+	sort.Slice(books, func(i, j int) bool {
+		return lessByKey(books[i].Name, books[j].Name, books[i].ID.String(), books[j].ID.String(), "asc")
+	})
+
+	for _, book := range books {
+
+		if err := fn(book); err != nil {
+			return err
+		}
+
+	}
+
+	return nil
+
+}
+
+func (ms *MapStorage) ExportUsers(_ context.Context, fn func(models.UserStruct) error) error {
+
+	ms.mu.RLock()
+
+	users := make([]models.UserStruct, 0, len(ms.userStorage))
+
+	for _, user := range ms.userStorage {
+		user.Password = ""
+		users = append(users, user)
+	}
+
+	ms.mu.RUnlock()
+
+	sort.Slice(users, func(i, j int) bool {
+		return lessByKey(users[i].Name, users[j].Name, users[i].ID.String(), users[j].ID.String(), "asc")
+	})
+
+	for _, user := range users {
+
+		if err := fn(user); err != nil {
+			return err
+		}
+
+	}
+
+	return nil
+
+}
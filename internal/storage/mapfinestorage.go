@@ -0,0 +1,64 @@
+package storage
+
+import (
+	"github.com/google/uuid"
+	"library/internal/domain/models"
+	"library/internal/storage/storageerror"
+	"sort"
+	"time"
+)
+
+func (ms *MapStorage) SaveFine(fine models.FineStruct) (string, error) {
+
+	ms.mu.Lock()
+	defer ms.mu.Unlock()
+
+	if _, ok := ms.userStorage[fine.UserID.String()]; !ok {
+		return "", storageerror.ErrUserNotFound
+	}
+
+	return ms.saveFine(fine), nil
+
+}
+
+// saveFine stores the fine, the caller has checked its user.
+func (ms *MapStorage) saveFine(fine models.FineStruct) string {
+
+	ID := uuid.New()
+	IDStr := ID.String()
+
+	fine.ID = ID
+	fine.DateCreated = time.Now()
+
+	ms.fineStorage[IDStr] = fine
+
+	return IDStr
+
+}
+
+func (ms *MapStorage) GetUserFines(userID string) ([]models.FineStruct, error) {
+
+	ms.mu.RLock()
+	defer ms.mu.RUnlock()
+
+	if _, ok := ms.userStorage[userID]; !ok {
+		return nil, storageerror.ErrUserNotFound
+	}
+
+	var fines []models.FineStruct
+
+	for _, fn := range ms.fineStorage {
+
+		if fn.UserID.String() == userID {
+			fines = append(fines, fn)
+		}
+
+	}
+
+	sort.Slice(fines, func(i, j int) bool {
+		return fines[i].DateCreated.Before(fines[j].DateCreated)
+	})
+
+	return fines, nil
+
+}
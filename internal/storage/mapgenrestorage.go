@@ -0,0 +1,228 @@
+package storage
+
+import (
+	"github.com/google/uuid"
+	"library/internal/domain/models"
+	"library/internal/storage/storageerror"
+	"slices"
+	"sort"
+	"strings"
+)
+
+func (ms *MapStorage) GetGenres() ([]models.GenreStruct, error) {
+
+	ms.mu.RLock()
+	defer ms.mu.RUnlock()
+
+	genres := []models.GenreStruct{}
+
+	for _, genre := range ms.genreStorage {
+		genres = append(genres, genre)
+	}
+
+	sortGenres(genres)
+
+	return genres, nil
+
+}
+
+func (ms *MapStorage) GetGenre(id string) (models.GenreStruct, error) {
+
+	ms.mu.RLock()
+	defer ms.mu.RUnlock()
+
+	genre, ok := ms.genreStorage[id]
+
+	if !ok {
+		return models.GenreStruct{}, storageerror.ErrGenreNotFound
+	}
+
+	return genre, nil
+
+}
+
+func (ms *MapStorage) SaveGenre(genre models.GenreStruct) (string, error) {
+
+	ms.mu.Lock()
+	defer ms.mu.Unlock()
+
+	if err := ms.checkGenre("", genre); err != nil {
+		return "", err
+	}
+
+	genre.ID = uuid.New()
+
+	ms.genreStorage[genre.ID.String()] = genre
+
+	return genre.ID.String(), nil
+
+}
+
+func (ms *MapStorage) EditGenre(id string, genre models.GenreStruct) error {
+
+	ms.mu.Lock()
+	defer ms.mu.Unlock()
+
+	genreMS, ok := ms.genreStorage[id]
+
+	if !ok {
+		return storageerror.ErrGenreNotFound
+	}
+
+	if genre.ParentID != nil && slices.Contains(ms.genreSubtree(id), genre.ParentID.String()) {
+		return storageerror.ErrGenreCycle
+	}
+
+	if err := ms.checkGenre(id, genre); err != nil {
+		return err
+	}
+
+	genre.ID = genreMS.ID
+
+	ms.genreStorage[id] = genre
+
+	return nil
+
+}
+
+func (ms *MapStorage) DeleteGenre(id string) error {
+
+	ms.mu.Lock()
+	defer ms.mu.Unlock()
+
+	if _, ok := ms.genreStorage[id]; !ok {
+		return storageerror.ErrGenreNotFound
+	}
+
+	for _, genre := range ms.genreStorage {
+
+		if genre.ParentID != nil && genre.ParentID.String() == id {
+			return storageerror.ErrGenreHasChildren
+		}
+
+	}
+
+	delete(ms.genreStorage, id)
+
+	for bookID, genreIDs := range ms.bookGenreStorage {
+		ms.bookGenreStorage[bookID] = slices.DeleteFunc(genreIDs, func(g string) bool { return g == id })
+	}
+
+	return nil
+
+}
+
+func (ms *MapStorage) GetBookGenres(bookID string) ([]models.GenreStruct, error) {
+
+	ms.mu.RLock()
+	defer ms.mu.RUnlock()
+
+	genres := []models.GenreStruct{}
+
+	for _, id := range ms.bookGenreStorage[bookID] {
+		genres = append(genres, ms.genreStorage[id])
+	}
+
+	sortGenres(genres)
+
+	return genres, nil
+
+}
+
+func (ms *MapStorage) AddBookGenre(bookID string, genreID string) error {
+
+	ms.mu.Lock()
+	defer ms.mu.Unlock()
+
+	if _, ok := ms.activeBook(bookID); !ok {
+		return storageerror.ErrBookNotFound
+	}
+
+	if _, ok := ms.genreStorage[genreID]; !ok {
+		return storageerror.ErrGenreNotFound
+	}
+
+	if !slices.Contains(ms.bookGenreStorage[bookID], genreID) {
+		ms.bookGenreStorage[bookID] = append(ms.bookGenreStorage[bookID], genreID)
+	}
+
+	return nil
+
+}
+
+func (ms *MapStorage) RemoveBookGenre(bookID string, genreID string) error {
+
+	ms.mu.Lock()
+	defer ms.mu.Unlock()
+
+	genreIDs := ms.bookGenreStorage[bookID]
+
+	if !slices.Contains(genreIDs, genreID) {
+		return storageerror.ErrGenreNotFound
+	}
+
+	ms.bookGenreStorage[bookID] = slices.DeleteFunc(genreIDs, func(g string) bool { return g == genreID })
+
+	return nil
+
+}
+
+// checkGenre mirrors the foreign key and the unique index of the Genres table.
+func (ms *MapStorage) checkGenre(id string, genre models.GenreStruct) error {
+
+	if genre.ParentID != nil {
+
+		if _, ok := ms.genreStorage[genre.ParentID.String()]; !ok {
+			return storageerror.ErrGenreNotFound
+		}
+
+	}
+
+	for key, g := range ms.genreStorage {
+
+		if key != id && sameParent(g.ParentID, genre.ParentID) && strings.EqualFold(g.Name, genre.Name) {
+			return storageerror.ErrGenreAlreadyExist
+		}
+
+	}
+
+	return nil
+
+}
+
+// genreSubtree returns the genre and all genres below it.
+func (ms *MapStorage) genreSubtree(id string) []string {
+
+	subtree := []string{id}
+
+	for i := 0; i < len(subtree); i++ {
+
+		for key, genre := range ms.genreStorage {
+
+			if genre.ParentID != nil && genre.ParentID.String() == subtree[i] && !slices.Contains(subtree, key) {
+				subtree = append(subtree, key)
+			}
+
+		}
+
+	}
+
+	return subtree
+
+}
+
+func sameParent(a *uuid.UUID, b *uuid.UUID) bool {
+
+	if a == nil || b == nil {
+		return a == b
+	}
+
+	return *a == *b
+
+}
+
+func sortGenres(genres []models.GenreStruct) {
+	sort.Slice(genres, func(i, j int) bool {
+		return lessByKey(genres[i].Name, genres[j].Name, genres[i].ID.String(), genres[j].ID.String(), "asc")
+	})
+}
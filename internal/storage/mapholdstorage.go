@@ -0,0 +1,229 @@
+package storage
+
+import (
+	"github.com/google/uuid"
+	"library/internal/domain/models"
+	"library/internal/storage/storageerror"
+	"sort"
+	"time"
+)
+
+func holdActive(hold models.HoldStruct) bool {
+	return hold.Status == models.HoldWaiting || hold.Status == models.HoldReady
+}
+
+func (ms *MapStorage) PlaceHold(hold models.HoldPlaceStruct) (string, error) {
+
+	ms.mu.Lock()
+	defer ms.mu.Unlock()
+
+	userID, err := parseID(hold.UserID)
+
+	if err != nil {
+		return "", err
+	}
+
+	bookID, err := parseID(hold.BookID)
+
+	if err != nil {
+		return "", err
+	}
+
+	if err = ms.activeUser(userID.String()); err != nil {
+		return "", err
+	}
+
+	if _, ok := ms.activeBook(bookID.String()); !ok {
+		return "", storageerror.ErrBookNotFound
+	}
+
+	for _, cp := range ms.copyStorage {
+
+		if cp.BookID == bookID && ms.copyFree(cp.ID) {
+			return "", storageerror.ErrBookAvailable
+		}
+
+	}
+
+	for _, hl := range ms.holdStorage {
+
+		if hl.UserID == userID && hl.BookID == bookID && holdActive(hl) {
+			return "", storageerror.ErrHoldAlreadyExist
+		}
+
+	}
+
+	ID := uuid.New()
+	IDStr := ID.String()
+
+	ms.holdStorage[IDStr] = models.HoldStruct{
+		ID:         ID,
+		UserID:     userID,
+		BookID:     bookID,
+		Status:     models.HoldWaiting,
+		DatePlaced: time.Now(),
+	}
+
+	return IDStr, nil
+
+}
+
+func (ms *MapStorage) GetHold(id string) (models.HoldStruct, error) {
+
+	ms.mu.RLock()
+	defer ms.mu.RUnlock()
+
+	hold, ok := ms.holdStorage[id]
+
+	if !ok {
+		return models.HoldStruct{}, storageerror.ErrHoldNotFound
+	}
+
+	return hold, nil
+
+}
+
+func (ms *MapStorage) CancelHold(id string) (models.HoldStruct, error) {
+
+	ms.mu.Lock()
+	defer ms.mu.Unlock()
+
+	hold, ok := ms.holdStorage[id]
+
+	if !ok {
+		return models.HoldStruct{}, storageerror.ErrHoldNotFound
+	}
+
+	if !holdActive(hold) {
+		return hold, storageerror.ErrHoldNotActive
+	}
+
+	cancelled := hold
+	cancelled.Status = models.HoldCancelled
+
+	ms.holdStorage[id] = cancelled
+
+	return hold, nil
+
+}
+
+func (ms *MapStorage) GetUserHolds(userID string) ([]models.HoldStruct, error) {
+
+	ms.mu.RLock()
+	defer ms.mu.RUnlock()
+
+	ID, err := parseID(userID)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return ms.getActiveHolds(func(hold models.HoldStruct) bool { return hold.UserID == ID }), nil
+
+}
+
+func (ms *MapStorage) GetBookHolds(bookID string) ([]models.HoldStruct, error) {
+
+	ms.mu.RLock()
+	defer ms.mu.RUnlock()
+
+	ID, err := parseID(bookID)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return ms.getActiveHolds(func(hold models.HoldStruct) bool { return hold.BookID == ID }), nil
+
+}
+
+func (ms *MapStorage) getActiveHolds(match func(models.HoldStruct) bool) []models.HoldStruct {
+
+	var holds []models.HoldStruct
+
+	for _, hl := range ms.holdStorage {
+
+		if holdActive(hl) && match(hl) {
+			holds = append(holds, hl)
+		}
+
+	}
+
+	sort.Slice(holds, func(i, j int) bool {
+		return holds[i].DatePlaced.Before(holds[j].DatePlaced)
+	})
+
+	return holds
+
+}
+
+func (ms *MapStorage) AllocateCopy(copyID string, expire time.Time) error {
+
+	ms.mu.Lock()
+	defer ms.mu.Unlock()
+
+	ms.allocateCopy(copyID, expire)
+
+	return nil
+
+}
+
+func (ms *MapStorage) allocateCopy(copyID string, expire time.Time) {
+
+	cp, ok := ms.copyStorage[copyID]
+
+	if !ok || !ms.copyFree(cp.ID) {
+		return
+	}
+
+	var head *models.HoldStruct
+
+	for _, hl := range ms.holdStorage {
+
+		if hl.BookID != cp.BookID || hl.Status != models.HoldWaiting {
+			continue
+		}
+
+		if head == nil || hl.DatePlaced.Before(head.DatePlaced) {
+			head = &hl
+		}
+
+	}
+
+	if head == nil {
+		return
+	}
+
+	head.Status = models.HoldReady
+	head.CopyID = &cp.ID
+	head.DateExpire = &expire
+
+	ms.holdStorage[head.ID.String()] = *head
+
+}
+
+func (ms *MapStorage) ExpireHolds(now time.Time) ([]string, error) {
+
+	ms.mu.Lock()
+	defer ms.mu.Unlock()
+
+	var copies []string
+
+	for key, hl := range ms.holdStorage {
+
+		if hl.Status != models.HoldReady || hl.DateExpire == nil || !hl.DateExpire.Before(now) {
+			continue
+		}
+
+		hl.Status = models.HoldExpired
+		ms.holdStorage[key] = hl
+
+		if hl.CopyID != nil {
+			copies = append(copies, hl.CopyID.String())
+		}
+
+	}
+
+	return copies, nil
+
+}
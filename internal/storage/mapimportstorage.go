@@ -0,0 +1,35 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"library/internal/domain/models"
+	"library/internal/storage/storageerror"
+)
+
+// ImportBooks has nothing to batch in memory, the rows are saved one by one. It takes no lock itself,
+// SaveBook does for each row. An error stops the import, the rows before it stay saved and marked.
+func (ms *MapStorage) ImportBooks(ctx context.Context, rows []models.ImportRowStruct, _ int) error {
+
+	for i := range rows {
+
+		if rows[i].Status != "" {
+			continue
+		}
+
+		id, err := ms.SaveBook(ctx, rows[i].Book)
+
+		switch {
+		case errors.Is(err, storageerror.ErrBookAlreadyExist):
+			rows[i].Status = models.ImportDuplicate
+		case err != nil:
+			return err
+		default:
+			rows[i].Status, rows[i].ID = models.ImportCreated, id
+		}
+
+	}
+
+	return nil
+
+}
@@ -0,0 +1,307 @@
+package storage
+
+import (
+	"github.com/google/uuid"
+	"library/internal/domain/models"
+	"library/internal/storage/storageerror"
+	"sort"
+	"time"
+)
+
+func (ms *MapStorage) CheckoutBook(loan models.LoanCheckoutStruct, due time.Time) (string, error) {
+
+	ms.mu.Lock()
+	defer ms.mu.Unlock()
+
+	userID, err := parseID(loan.UserID)
+
+	if err != nil {
+		return "", err
+	}
+
+	bookID, err := parseID(loan.BookID)
+
+	if err != nil {
+		return "", err
+	}
+
+	if err = ms.activeUser(userID.String()); err != nil {
+		return "", err
+	}
+
+	if _, ok := ms.activeBook(bookID.String()); !ok {
+		return "", storageerror.ErrBookNotFound
+	}
+
+	copyID, err := ms.pickCopy(userID, bookID, loan.CopyID)
+
+	if err != nil {
+		return "", err
+	}
+
+	ID := uuid.New()
+	IDStr := ID.String()
+
+	ms.loanStorage[IDStr] = models.LoanStruct{
+		ID:           ID,
+		UserID:       userID,
+		BookID:       bookID,
+		CopyID:       copyID,
+		DateCheckout: time.Now(),
+		DateDue:      due,
+	}
+
+	for key, hold := range ms.holdStorage {
+
+		if hold.UserID == userID && hold.BookID == bookID && holdActive(hold) {
+			hold.Status = models.HoldFulfilled
+			ms.holdStorage[key] = hold
+		}
+
+	}
+
+	return IDStr, nil
+
+}
+
+func (ms *MapStorage) pickCopy(userID uuid.UUID, bookID uuid.UUID, requested string) (uuid.UUID, error) {
+
+	if requested != "" {
+
+		cp, ok := ms.copyStorage[requested]
+
+		if !ok || cp.BookID != bookID {
+			return uuid.UUID{}, storageerror.ErrCopyNotFound
+		}
+
+		if ms.copyLoaned(cp.ID) {
+			return uuid.UUID{}, storageerror.ErrCopyAlreadyLoaned
+		}
+
+		if hold, ok := ms.copyReserved(cp.ID); ok && hold.UserID != userID {
+			return uuid.UUID{}, storageerror.ErrCopyReserved
+		}
+
+		return cp.ID, nil
+
+	}
+
+	for _, hold := range ms.holdStorage {
+
+		if hold.UserID == userID && hold.BookID == bookID && hold.Status == models.HoldReady && hold.CopyID != nil {
+			return *hold.CopyID, nil
+		}
+
+	}
+
+	var free []models.CopyStruct
+	total := 0
+
+	for _, cp := range ms.copyStorage {
+
+		if cp.BookID != bookID {
+			continue
+		}
+
+		total++
+
+		if ms.copyFree(cp.ID) {
+			free = append(free, cp)
+		}
+
+	}
+
+	if total == 0 {
+		return uuid.UUID{}, storageerror.ErrBookNoCopies
+	}
+
+	if len(free) == 0 {
+		return uuid.UUID{}, storageerror.ErrBookAlreadyLoaned
+	}
+
+	sort.Slice(free, func(i, j int) bool { return free[i].Barcode < free[j].Barcode })
+
+	return free[0].ID, nil
+
+}
+
+func (ms *MapStorage) copyLoaned(copyID uuid.UUID) bool {
+
+	for _, ln := range ms.loanStorage {
+
+		if ln.CopyID == copyID && ln.DateReturn == nil {
+			return true
+		}
+
+	}
+
+	return false
+
+}
+
+func (ms *MapStorage) copyReserved(copyID uuid.UUID) (models.HoldStruct, bool) {
+
+	for _, hold := range ms.holdStorage {
+
+		if hold.Status == models.HoldReady && hold.CopyID != nil && *hold.CopyID == copyID {
+			return hold, true
+		}
+
+	}
+
+	return models.HoldStruct{}, false
+
+}
+
+func (ms *MapStorage) copyFree(copyID uuid.UUID) bool {
+
+	if ms.copyLoaned(copyID) {
+		return false
+	}
+
+	_, reserved := ms.copyReserved(copyID)
+
+	return !reserved
+
+}
+
+// ReturnBook follows DBStorage, the loan, the fine and the hold change under one lock.
+func (ms *MapStorage) ReturnBook(id string, fine func(models.LoanStruct) (models.FineStruct, bool), expire time.Time) (models.LoanStruct, error) {
+
+	ms.mu.Lock()
+	defer ms.mu.Unlock()
+
+	loan, ok := ms.loanStorage[id]
+
+	if !ok {
+		return models.LoanStruct{}, storageerror.ErrLoanNotFound
+	}
+
+	if loan.DateReturn != nil {
+		return loan, storageerror.ErrLoanAlreadyReturned
+	}
+
+	now := time.Now()
+	loan.DateReturn = &now
+
+	ms.loanStorage[id] = loan
+
+	if charge, due := fine(loan); due {
+		ms.saveFine(charge)
+	}
+
+	ms.allocateCopy(loan.CopyID.String(), expire)
+
+	return loan, nil
+
+}
+
+func (ms *MapStorage) RenewLoan(id string, extend time.Duration, maxRenewals int) (models.LoanStruct, error) {
+
+	ms.mu.Lock()
+	defer ms.mu.Unlock()
+
+	loan, ok := ms.loanStorage[id]
+
+	if !ok {
+		return models.LoanStruct{}, storageerror.ErrLoanNotFound
+	}
+
+	switch {
+	case loan.DateReturn != nil:
+		return loan, storageerror.ErrLoanAlreadyReturned
+	case loan.Renewals >= maxRenewals:
+		return loan, storageerror.ErrLoanRenewalLimit
+	case loan.DateDue.Before(time.Now()):
+		return loan, storageerror.ErrLoanOverdue
+	}
+
+	for _, hold := range ms.holdStorage {
+
+		if hold.BookID == loan.BookID && hold.Status == models.HoldWaiting {
+			return loan, storageerror.ErrLoanHoldPending
+		}
+
+	}
+
+	loan.DateDue = loan.DateDue.Add(extend)
+	loan.Renewals++
+
+	ms.loanStorage[id] = loan
+
+	return loan, nil
+
+}
+
+func (ms *MapStorage) GetOverdueLoans(now time.Time) ([]models.LoanStruct, error) {
+
+	ms.mu.RLock()
+	defer ms.mu.RUnlock()
+
+	var loans []models.LoanStruct
+
+	for _, ln := range ms.loanStorage {
+
+		if ln.DateReturn == nil && ln.DateDue.Before(now) {
+			loans = append(loans, ln)
+		}
+
+	}
+
+	sort.Slice(loans, func(i, j int) bool {
+		return loans[i].DateDue.Before(loans[j].DateDue)
+	})
+
+	return loans, nil
+
+}
+
+func (ms *MapStorage) GetUserLoans(userID string) ([]models.LoanStruct, error) {
+
+	ms.mu.RLock()
+	defer ms.mu.RUnlock()
+
+	ID, err := parseID(userID)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return ms.getActiveLoans(func(loan models.LoanStruct) bool { return loan.UserID == ID }), nil
+
+}
+
+func (ms *MapStorage) GetBookLoans(bookID string) ([]models.LoanStruct, error) {
+
+	ms.mu.RLock()
+	defer ms.mu.RUnlock()
+
+	ID, err := parseID(bookID)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return ms.getActiveLoans(func(loan models.LoanStruct) bool { return loan.BookID == ID }), nil
+
+}
+
+func (ms *MapStorage) getActiveLoans(match func(models.LoanStruct) bool) []models.LoanStruct {
+
+	var loans []models.LoanStruct
+
+	for _, ln := range ms.loanStorage {
+
+		if ln.DateReturn == nil && match(ln) {
+			loans = append(loans, ln)
+		}
+
+	}
+
+	sort.Slice(loans, func(i, j int) bool {
+		return loans[i].DateCheckout.Before(loans[j].DateCheckout)
+	})
+
+	return loans
+
+}
@@ -0,0 +1,138 @@
+package storage
+
+import (
+	"context"
+	"golang.org/x/crypto/bcrypt"
+	"library/internal/domain/models"
+	"library/internal/storage/storageerror"
+)
+
+func (ms *MapStorage) PatchBook(_ context.Context, id string, patch models.BookPatchStruct) error {
+
+	ms.mu.Lock()
+	defer ms.mu.Unlock()
+
+	book, ok := ms.activeBook(id)
+
+	if !ok {
+		return storageerror.ErrBookNotFound
+	}
+
+	if patch.Version != 0 && patch.Version != book.Version {
+		return storageerror.ErrVersionConflict
+	}
+
+	// Like the UPDATE of DBStorage, a patch without columns changes nothing and keeps the version.
+	if patch.Name == nil && patch.Description == nil && patch.Author == nil &&
+		patch.ISBN10 == nil && patch.ISBN13 == nil && patch.DateWriting == nil {
+		return nil
+	}
+
+	patched := book
+
+	if patch.Name != nil {
+		patched.Name = *patch.Name
+	}
+
+	if patch.Description != nil {
+		patched.Description = *patch.Description
+	}
+
+	if patch.Author != nil {
+		patched.Author = *patch.Author
+	}
+
+	if patch.ISBN10 != nil {
+		patched.ISBN10 = *patch.ISBN10
+	}
+
+	if patch.ISBN13 != nil {
+		patched.ISBN13 = *patch.ISBN13
+	}
+
+	if patch.DateWriting != nil {
+		patched.DateWriting = *patch.DateWriting
+	}
+
+	if ms.editTaken(book, patched) {
+		return storageerror.ErrBookAlreadyExist
+	}
+
+	patched.Version++
+
+	ms.unindexBook(book)
+	ms.bookStorage[id] = patched
+	ms.indexBook(patched)
+	ms.saveRevision(patched)
+
+	return nil
+
+}
+
+func (ms *MapStorage) PatchUser(_ context.Context, id string, patch models.UserPatchStruct) error {
+
+	ms.mu.Lock()
+	defer ms.mu.Unlock()
+
+	user, ok := ms.userStorage[id]
+
+	if !ok {
+		return storageerror.ErrUserNotFound
+	}
+
+	if user.DeletedAt != nil {
+		return storageerror.ErrUserInactive
+	}
+
+	if patch.Version != 0 && patch.Version != user.Version {
+		return storageerror.ErrVersionConflict
+	}
+
+	if patch == (models.UserPatchStruct{Version: patch.Version}) {
+		return nil
+	}
+
+	email := user.Email
+
+	if patch.Email != nil && *patch.Email != user.Email {
+
+		if _, taken := ms.userEmails[*patch.Email]; taken {
+			return storageerror.ErrUserAlreadyExist
+		}
+
+		user.Email = *patch.Email
+
+	}
+
+	if patch.Name != nil {
+		user.Name = *patch.Name
+	}
+
+	if patch.Password != nil {
+
+		hash, err := bcrypt.GenerateFromPassword([]byte(*patch.Password), bcrypt.MinCost)
+
+		if err != nil {
+			return err
+		}
+
+		user.Password = string(hash)
+
+	}
+
+	if patch.Age != nil {
+		user.Age = *patch.Age
+	}
+
+	if patch.Role != nil {
+		user.Role = *patch.Role
+	}
+
+	user.Version++
+
+	ms.userStorage[id] = user
+	ms.indexUserEmail(id, email, user.Email)
+
+	return nil
+
+}
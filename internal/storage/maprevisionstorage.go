@@ -0,0 +1,57 @@
+package storage
+
+import (
+	"context"
+	"library/internal/domain/models"
+	"library/internal/storage/storageerror"
+	"slices"
+	"time"
+)
+
+func (ms *MapStorage) saveRevision(book models.BookStruct) {
+
+	id := book.ID.String()
+
+	ms.revisionStorage[id] = append(ms.revisionStorage[id], models.BookRevisionStruct{
+		Rev:         len(ms.revisionStorage[id]) + 1,
+		Book:        book,
+		DateCreated: time.Now().UTC(),
+	})
+
+}
+
+func (ms *MapStorage) GetBookRevisions(_ context.Context, id string) ([]models.BookRevisionStruct, error) {
+
+	ms.mu.RLock()
+	defer ms.mu.RUnlock()
+
+	if _, ok := ms.activeBook(id); !ok {
+		return nil, storageerror.ErrBookNotFound
+	}
+
+	revisions := slices.Clone(ms.revisionStorage[id])
+
+	slices.Reverse(revisions)
+
+	return revisions, nil
+
+}
+
+func (ms *MapStorage) GetBookRevision(_ context.Context, id string, rev int) (models.BookRevisionStruct, error) {
+
+	ms.mu.RLock()
+	defer ms.mu.RUnlock()
+
+	if _, ok := ms.activeBook(id); !ok {
+		return models.BookRevisionStruct{}, storageerror.ErrBookNotFound
+	}
+
+	revisions := ms.revisionStorage[id]
+
+	if rev < 1 || rev > len(revisions) {
+		return models.BookRevisionStruct{}, storageerror.ErrRevisionNotFound
+	}
+
+	return revisions[rev-1], nil
+
+}
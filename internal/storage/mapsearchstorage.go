@@ -0,0 +1,143 @@
+package storage
+
+import (
+	"context"
+	"library/internal/domain/models"
+	"sort"
+	"strings"
+	"unicode"
+)
+
+// Field weights follow the A/B/C weights of the Search column in DBStorage.
+const (
+	searchWeightName   = 1.0
+	searchWeightAuthor = 0.4
+	searchWeightDesc   = 0.2
+)
+
+// SearchBooks is a plain token match: every query word has to be a prefix of some word
+// in the name, author or description of the book.
+func (ms *MapStorage) SearchBooks(_ context.Context, search models.BookSearchStruct) ([]models.BookHitStruct, int, error) {
+
+	ms.mu.RLock()
+	defer ms.mu.RUnlock()
+
+	terms := searchTokens(search.Query)
+
+	hits := []models.BookHitStruct{}
+
+	if len(terms) == 0 {
+		return hits, 0, nil
+	}
+
+	for _, bk := range ms.bookStorage {
+
+		if bk.DeletedAt != nil {
+			continue
+		}
+
+		fields := []struct {
+			tokens []string
+			weight float32
+		}{
+			{searchTokens(bk.Name), searchWeightName},
+			{searchTokens(bk.Author), searchWeightAuthor},
+			{searchTokens(bk.Description), searchWeightDesc},
+		}
+
+		var rank float32
+		matched := true
+
+		for _, term := range terms {
+
+			var found bool
+
+			for _, field := range fields {
+
+				for _, token := range field.tokens {
+
+					if strings.HasPrefix(token, term) {
+						rank += field.weight
+						found = true
+					}
+
+				}
+
+			}
+
+			if !found {
+				matched = false
+				break
+			}
+
+		}
+
+		if !matched {
+			continue
+		}
+
+		hits = append(hits, models.BookHitStruct{
+			Book:    bk,
+			Rank:    rank,
+			Snippet: searchSnippet(strings.Join([]string{bk.Name, bk.Author, bk.Description}, " "), terms),
+		})
+
+	}
+
+	sort.Slice(hits, func(i, j int) bool {
+
+		if hits[i].Rank != hits[j].Rank {
+			return hits[i].Rank > hits[j].Rank
+		}
+
+		return hits[i].Book.ID.String() < hits[j].Book.ID.String()
+
+	})
+
+	start, end := pageBounds(len(hits), models.ListParamsStruct{Limit: search.Limit, Offset: search.Offset})
+
+	return hits[start:end], len(hits), nil
+
+}
+
+func searchTokens(text string) []string {
+	return strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+}
+
+// searchSnippet wraps the matched words in <b></b>, the same markers ts_headline uses in DBStorage.
+func searchSnippet(text string, terms []string) string {
+
+	words := strings.Fields(text)
+
+	for i, word := range words {
+
+		for _, token := range searchTokens(word) {
+
+			if matchesAny(token, terms) {
+				words[i] = "<b>" + word + "</b>"
+				break
+			}
+
+		}
+
+	}
+
+	return strings.Join(words, " ")
+
+}
+
+func matchesAny(token string, terms []string) bool {
+
+	for _, term := range terms {
+
+		if strings.HasPrefix(token, term) {
+			return true
+		}
+
+	}
+
+	return false
+
+}
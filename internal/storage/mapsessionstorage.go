@@ -0,0 +1,119 @@
+package storage
+
+import (
+	"library/internal/domain/models"
+	"library/internal/storage/storageerror"
+	"time"
+)
+
+func (ms *MapStorage) SaveSession(session models.SessionStruct) error {
+
+	ms.mu.Lock()
+	defer ms.mu.Unlock()
+
+	session.DateCreated = time.Now()
+
+	ms.sessionStorage[session.ID.String()] = session
+
+	return nil
+
+}
+
+func (ms *MapStorage) GetSession(id string) (models.SessionStruct, error) {
+
+	ms.mu.RLock()
+	defer ms.mu.RUnlock()
+
+	session, ok := ms.sessionStorage[id]
+
+	if !ok {
+		return models.SessionStruct{}, storageerror.ErrSessionNotFound
+	}
+
+	return session, nil
+
+}
+
+func (ms *MapStorage) GetSessionByToken(tokenHash string) (models.SessionStruct, error) {
+
+	ms.mu.RLock()
+	defer ms.mu.RUnlock()
+
+	for _, session := range ms.sessionStorage {
+
+		if session.TokenHash == tokenHash {
+			return session, nil
+		}
+
+	}
+
+	return models.SessionStruct{}, storageerror.ErrSessionNotFound
+
+}
+
+func (ms *MapStorage) RotateSession(id string, tokenHash string, expire time.Time) error {
+
+	ms.mu.Lock()
+	defer ms.mu.Unlock()
+
+	session, ok := ms.sessionStorage[id]
+
+	if !ok || session.Revoked {
+		return storageerror.ErrSessionRevoked
+	}
+
+	session.TokenHash = tokenHash
+	session.DateExpire = expire
+
+	ms.sessionStorage[id] = session
+
+	return nil
+
+}
+
+func (ms *MapStorage) RevokeSession(id string) error {
+
+	ms.mu.Lock()
+	defer ms.mu.Unlock()
+
+	session, ok := ms.sessionStorage[id]
+
+	if !ok {
+		return storageerror.ErrSessionNotFound
+	}
+
+	session.Revoked = true
+
+	ms.sessionStorage[id] = session
+
+	return nil
+
+}
+
+func (ms *MapStorage) RevokeUserSessions(userID string) error {
+
+	ms.mu.Lock()
+	defer ms.mu.Unlock()
+
+	return ms.revokeUserSessions(userID)
+
+}
+
+func (ms *MapStorage) revokeUserSessions(userID string) error {
+
+	if _, ok := ms.userStorage[userID]; !ok {
+		return storageerror.ErrUserNotFound
+	}
+
+	for key, session := range ms.sessionStorage {
+
+		if session.UserID.String() == userID {
+			session.Revoked = true
+			ms.sessionStorage[key] = session
+		}
+
+	}
+
+	return nil
+
+}
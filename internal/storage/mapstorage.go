@@ -1,23 +1,69 @@
 package storage
 
 import (
-	"fmt"
+	"context"
 	"github.com/google/uuid"
 	"golang.org/x/crypto/bcrypt"
 	"library/internal/domain/models"
 	"library/internal/storage/storageerror"
+	"slices"
+	"sort"
+	"strings"
+	"sync"
 	"time"
 )
 
+// MapStorage is safe for concurrent use. The exported methods take mu,
+// the unexported helpers expect the caller to hold it.
 type MapStorage struct {
+	mu sync.RWMutex
+
 	userStorage map[string]models.UserStruct
 	bookStorage map[string]models.BookStruct
+	loanStorage map[string]models.LoanStruct
+	copyStorage map[string]models.CopyStruct
+	holdStorage map[string]models.HoldStruct
+	fineStorage map[string]models.FineStruct
+
+	sessionStorage    map[string]models.SessionStruct
+	authorStorage     map[string]models.AuthorStruct
+	bookAuthorStorage map[string][]string // book ID -> author IDs in order
+	genreStorage      map[string]models.GenreStruct
+	bookGenreStorage  map[string][]string // book ID -> genre IDs
+	bookTagStorage    map[string][]string // book ID -> tags
+	auditStorage      []models.AuditStruct
+	revisionStorage   map[string][]models.BookRevisionStruct // book ID -> revisions, oldest first
+
+	userEmails map[string]string    // email -> user ID, every user
+	bookNames  map[bookKey][]string // name and author -> IDs of the books not in the trash
+	bookISBNs  map[string]string    // ISBN13 -> ID of the book not in the trash
+}
+
+type bookKey struct {
+	name   string
+	author string
 }
 
 func NewMapStorage() *MapStorage { // Откуда IDE знает что я хочу написать??? Она и эту строку сама сгенерировала
 
 	return &MapStorage{userStorage: make(map[string]models.UserStruct),
-		bookStorage: make(map[string]models.BookStruct)} /// И эту строку тоже
+		bookStorage: make(map[string]models.BookStruct), /// И эту строку тоже
+		loanStorage: make(map[string]models.LoanStruct),
+		copyStorage: make(map[string]models.CopyStruct),
+		holdStorage: make(map[string]models.HoldStruct),
+		fineStorage: make(map[string]models.FineStruct),
+
+		sessionStorage:    make(map[string]models.SessionStruct),
+		authorStorage:     make(map[string]models.AuthorStruct),
+		bookAuthorStorage: make(map[string][]string),
+		genreStorage:      make(map[string]models.GenreStruct),
+		bookGenreStorage:  make(map[string][]string),
+		bookTagStorage:    make(map[string][]string),
+		revisionStorage:   make(map[string][]models.BookRevisionStruct),
+
+		userEmails: make(map[string]string),
+		bookNames:  make(map[bookKey][]string),
+		bookISBNs:  make(map[string]string)}
 
 }
 
@@ -41,23 +87,61 @@ func NewMapStorage() *MapStorage { // Откуда IDE знает что я хо
 //
 //}
 
-func (ms *MapStorage) GetUsers() ([]models.UserStruct, error) {
+func (ms *MapStorage) GetUsers(_ context.Context, filter models.UserFilterStruct) ([]models.UserStruct, int, error) {
 
-	if len(ms.userStorage) == 0 {
-		return nil, storageerror.ErrUserStorageEmpty
-	}
+	ms.mu.RLock()
+	defer ms.mu.RUnlock()
 
-	var users []models.UserStruct
+	users := []models.UserStruct{}
 
 	for _, usr := range ms.userStorage {
+
+		if filter.Role != "" && usr.Role != filter.Role {
+			continue
+		}
+
+		if filter.Status == models.UserStatusActive && usr.DeletedAt != nil ||
+			filter.Status == models.UserStatusInactive && usr.DeletedAt == nil {
+			continue
+		}
+
+		if !inDateRange(usr.DateRegistration, filter.ListParamsStruct) {
+			continue
+		}
+
 		users = append(users, usr)
+
 	}
 
-	return users, nil
+	sort.Slice(users, func(i, j int) bool {
+
+		var a, b string
+
+		switch filter.Sort {
+		case "email":
+			a, b = users[i].Email, users[j].Email
+		case "date":
+			if !users[i].DateRegistration.Equal(users[j].DateRegistration) {
+				return users[i].DateRegistration.Before(users[j].DateRegistration) != (filter.Order == "desc")
+			}
+		default:
+			a, b = users[i].Name, users[j].Name
+		}
+
+		return lessByKey(a, b, users[i].ID.String(), users[j].ID.String(), filter.Order)
+
+	})
+
+	start, end := pageBounds(len(users), filter.ListParamsStruct)
+
+	return users[start:end], len(users), nil
 
 }
 
-func (ms *MapStorage) GetUser(id string) (models.UserStruct, error) {
+func (ms *MapStorage) GetUser(_ context.Context, id string) (models.UserStruct, error) {
+
+	ms.mu.RLock()
+	defer ms.mu.RUnlock()
 
 	user, ok := ms.userStorage[id]
 
@@ -69,14 +153,13 @@ func (ms *MapStorage) GetUser(id string) (models.UserStruct, error) {
 
 }
 
-func (ms *MapStorage) SaveUser(user models.UserStruct) (string, error) {
+func (ms *MapStorage) SaveUser(_ context.Context, user models.UserStruct) (string, error) {
 
-	for _, usr := range ms.userStorage {
-
-		if usr.Email == user.Email {
-			return "", storageerror.ErrUserAlreadyExist
-		}
+	ms.mu.Lock()
+	defer ms.mu.Unlock()
 
+	if _, ok := ms.userEmails[user.Email]; ok {
+		return "", storageerror.ErrUserAlreadyExist
 	}
 
 	hash, err := bcrypt.GenerateFromPassword([]byte(user.Password), bcrypt.MinCost)
@@ -92,34 +175,49 @@ func (ms *MapStorage) SaveUser(user models.UserStruct) (string, error) {
 
 	user.ID = id
 	user.DateRegistration = time.Now()
+	user.DeletedAt, user.AnonymizedAt = nil, nil
+	user.Version = 1
+
+	if user.Role == "" {
+		user.Role = models.RolePatron
+	}
 
 	ms.userStorage[idStr] = user
+	ms.userEmails[user.Email] = idStr
 
 	return idStr, nil
 
 }
 
-func (ms *MapStorage) ValidateUser(user models.UserLoginStruct) (string, error) {
-
-	for key, userMS := range ms.userStorage {
+func (ms *MapStorage) ValidateUser(_ context.Context, user models.UserLoginStruct) (models.UserStruct, error) {
 
-		if userMS.Email == user.Email {
+	ms.mu.RLock()
+	defer ms.mu.RUnlock()
 
-			if err := bcrypt.CompareHashAndPassword([]byte(userMS.Password), []byte(user.Password)); err != nil {
-				return "", storageerror.ErrUserInvalidPassword
-			}
+	userMS, ok := ms.userStorage[ms.userEmails[user.Email]]
 
-			return key, nil
+	if !ok {
+		return models.UserStruct{}, storageerror.ErrUserNotFound
+	}
 
-		}
+	if err := bcrypt.CompareHashAndPassword([]byte(userMS.Password), []byte(user.Password)); err != nil {
+		return models.UserStruct{}, storageerror.ErrUserInvalidPassword
+	}
 
+	if userMS.DeletedAt != nil {
+		return models.UserStruct{}, storageerror.ErrUserInactive
 	}
 
-	return "", storageerror.ErrUserNotFound
+	userMS.Password = ""
+
+	return userMS, nil
 
 }
 
-func (ms *MapStorage) EditUser(id string, user models.UserStruct) error {
+func (ms *MapStorage) EditUser(_ context.Context, id string, user models.UserStruct) error {
+
+	ms.mu.Lock()
+	defer ms.mu.Unlock()
 
 	userMS, ok := ms.userStorage[id]
 
@@ -127,16 +225,16 @@ func (ms *MapStorage) EditUser(id string, user models.UserStruct) error {
 		return storageerror.ErrUserNotFound
 	}
 
-	if userMS.Email != user.Email {
-
-		for _, userCheck := range ms.userStorage {
-
-			if userCheck.Email == user.Email {
-				return fmt.Errorf("user with email %s already exists", user.Email)
-			}
+	if userMS.DeletedAt != nil {
+		return storageerror.ErrUserInactive
+	}
 
-		}
+	if user.Version != 0 && user.Version != userMS.Version {
+		return storageerror.ErrVersionConflict
+	}
 
+	if _, taken := ms.userEmails[user.Email]; taken && userMS.Email != user.Email {
+		return storageerror.ErrUserAlreadyExist
 	}
 
 	if errCompare := bcrypt.CompareHashAndPassword([]byte(userMS.Password), []byte(user.Password)); errCompare != nil {
@@ -155,46 +253,134 @@ func (ms *MapStorage) EditUser(id string, user models.UserStruct) error {
 
 	user.ID = userMS.ID
 	user.DateRegistration = userMS.DateRegistration
+	user.DeletedAt, user.AnonymizedAt = nil, nil
+	user.Version = userMS.Version + 1
+
+	if user.Role == "" {
+		user.Role = userMS.Role
+	}
 
 	ms.userStorage[id] = user
+	ms.indexUserEmail(id, userMS.Email, user.Email)
 
 	return nil
 
 }
 
-func (ms *MapStorage) DeleteUser(id string) error {
+func (ms *MapStorage) DeleteUser(_ context.Context, id string) error {
+
+	ms.mu.Lock()
+	defer ms.mu.Unlock()
 
-	_, ok := ms.userStorage[id]
+	user, ok := ms.userStorage[id]
 
 	if !ok {
 		return storageerror.ErrUserNotFound
 	}
 
-	delete(ms.userStorage, id)
+	if user.DeletedAt != nil {
+		return storageerror.ErrUserInactive
+	}
 
-	return nil
+	now := time.Now().UTC()
+	user.DeletedAt = &now
+	user.Version++
+
+	ms.userStorage[id] = user
+
+	return ms.revokeUserSessions(id)
 
 }
 
-func (ms *MapStorage) GetBooks() ([]models.BookStruct, error) {
+func (ms *MapStorage) GetBooks(_ context.Context, filter models.BookFilterStruct) ([]models.BookStruct, int, error) {
 
-	if len(ms.bookStorage) == 0 {
-		return nil, storageerror.ErrBookStorageEmpty
-	}
+	ms.mu.RLock()
+	defer ms.mu.RUnlock()
+
+	books := []models.BookStruct{}
+
+	author := strings.ToLower(filter.Author)
 
-	var books []models.BookStruct
+	var genres []string
+
+	if filter.Genre != "" {
+		genres = ms.genreSubtree(filter.Genre)
+	}
 
 	for _, bk := range ms.bookStorage {
+
+		if bk.DeletedAt != nil {
+			continue
+		}
+
+		if author != "" && !strings.Contains(strings.ToLower(bk.Author), author) {
+			continue
+		}
+
+		if !ms.hasAllTags(bk.ID.String(), filter.Tags) {
+			continue
+		}
+
+		if genres != nil && !slices.ContainsFunc(ms.bookGenreStorage[bk.ID.String()], func(g string) bool {
+			return slices.Contains(genres, g)
+		}) {
+			continue
+		}
+
+		if !inDateRange(bk.DateWriting, filter.ListParamsStruct) {
+			continue
+		}
+
 		books = append(books, bk)
+
+	}
+
+	sort.Slice(books, func(i, j int) bool {
+
+		var a, b string
+
+		switch filter.Sort {
+		case "author":
+			a, b = books[i].Author, books[j].Author
+		case "date":
+			if !books[i].DateWriting.Equal(books[j].DateWriting) {
+				return books[i].DateWriting.Before(books[j].DateWriting) != (filter.Order == "desc")
+			}
+		default:
+			a, b = books[i].Name, books[j].Name
+		}
+
+		return lessByKey(a, b, books[i].ID.String(), books[j].ID.String(), filter.Order)
+
+	})
+
+	start, end := pageBounds(len(books), filter.ListParamsStruct)
+
+	return books[start:end], len(books), nil
+
+}
+
+func (ms *MapStorage) GetBookByISBN(_ context.Context, isbn13 string) (models.BookStruct, error) {
+
+	ms.mu.RLock()
+	defer ms.mu.RUnlock()
+
+	book, ok := ms.bookStorage[ms.bookISBNs[isbn13]]
+
+	if !ok {
+		return models.BookStruct{}, storageerror.ErrBookNotFound
 	}
 
-	return books, nil
+	return book, nil
 
 }
 
-func (ms *MapStorage) GetBook(id string) (models.BookStruct, error) {
+func (ms *MapStorage) GetBook(_ context.Context, id string) (models.BookStruct, error) {
 
-	book, ok := ms.bookStorage[id] // IDE сама
+	ms.mu.RLock()
+	defer ms.mu.RUnlock()
+
+	book, ok := ms.activeBook(id) // IDE сама
 
 	if !ok { // IDE сама
 		return models.BookStruct{}, storageerror.ErrBookNotFound // IDE сама
@@ -204,68 +390,251 @@ func (ms *MapStorage) GetBook(id string) (models.BookStruct, error) {
 
 }
 
-func (ms *MapStorage) SaveBook(book models.BookStruct) (string, error) {
+func (ms *MapStorage) SaveBook(_ context.Context, book models.BookStruct) (string, error) {
 
-	for _, bk := range ms.bookStorage {
-
-		if bk.Name == book.Name && bk.Author == book.Author {
-			return "", storageerror.ErrBookAlreadyExist // IDE сама написала строку
-		}
+	ms.mu.Lock()
+	defer ms.mu.Unlock()
 
+	if ms.bookTaken(book, "") {
+		return "", storageerror.ErrBookAlreadyExist // IDE сама написала строку
 	}
 
 	ID := uuid.New()
 	IDStr := ID.String()
 
 	book.ID = ID
+	book.AuthorIDs, book.Authors = nil, nil // kept in bookAuthorStorage, like the BookAuthors table
+	book.Genres, book.Tags = nil, nil
+	book.DeletedAt = nil
+	book.Version = 1
 
 	ms.bookStorage[IDStr] = book
+	ms.indexBook(book)
+	ms.saveRevision(book)
 
 	return IDStr, nil
 
 }
 
-func (ms *MapStorage) EditBook(id string, book models.BookStruct) error {
+func (ms *MapStorage) EditBook(_ context.Context, id string, book models.BookStruct) error {
+
+	ms.mu.Lock()
+	defer ms.mu.Unlock()
 
-	bookMS, ok := ms.bookStorage[id]
+	bookMS, ok := ms.activeBook(id)
 
 	if !ok {
 		return storageerror.ErrBookNotFound
 	}
 
-	if bookMS.Name != book.Name || bookMS.Author != book.Author {
-
-		for _, bookCheck := range ms.bookStorage {
-
-			if bookCheck.Name == book.Name && bookCheck.Author == book.Author {
-				return fmt.Errorf("book with name %s and author %s already exists", book.Name, book.Author)
-			}
-
-		}
+	if book.Version != 0 && book.Version != bookMS.Version {
+		return storageerror.ErrVersionConflict
+	}
 
+	if ms.editTaken(bookMS, book) {
+		return storageerror.ErrBookAlreadyExist
 	}
 
 	book.ID = bookMS.ID
+	book.AuthorIDs, book.Authors = nil, nil
+	book.Genres, book.Tags = nil, nil
+	book.DeletedAt = nil
+	book.Version = bookMS.Version + 1
 
+	ms.unindexBook(bookMS)
 	ms.bookStorage[id] = book
+	ms.indexBook(book)
+	ms.saveRevision(book)
 
 	return nil
 
 }
 
-func (ms *MapStorage) DeleteBook(id string) error {
+func (ms *MapStorage) DeleteBook(_ context.Context, id string) error {
 
-	_, ok := ms.bookStorage[id]
+	ms.mu.Lock()
+	defer ms.mu.Unlock()
+
+	book, ok := ms.activeBook(id)
 
 	if !ok {
 		return storageerror.ErrBookNotFound
 	}
 
-	delete(ms.bookStorage, id)
+	now := time.Now().UTC()
+	book.DeletedAt = &now
+	book.Version++
+
+	ms.bookStorage[id] = book
+	ms.unindexBook(book)
 
 	return nil
 
 }
-func (ms *MapStorage) DeleteBooks() error {
-	return nil
+
+// DeleteBooks purges the books moved to the trash before the given time, except those with loans or holds,
+// and everything that belongs to them, as the cascades of DBStorage do.
+func (ms *MapStorage) DeleteBooks(_ context.Context, before time.Time) (int, error) {
+
+	ms.mu.Lock()
+	defer ms.mu.Unlock()
+
+	var purged int
+
+	for id, book := range ms.bookStorage {
+
+		if book.DeletedAt == nil || !book.DeletedAt.Before(before) || ms.bookReferenced(book.ID) {
+			continue
+		}
+
+		delete(ms.bookStorage, id)
+		delete(ms.bookAuthorStorage, id)
+		delete(ms.bookGenreStorage, id)
+		delete(ms.bookTagStorage, id)
+		delete(ms.revisionStorage, id)
+
+		for key, cp := range ms.copyStorage {
+
+			if cp.BookID == book.ID {
+				delete(ms.copyStorage, key)
+			}
+
+		}
+
+		purged++
+
+	}
+
+	return purged, nil
+
+}
+
+// indexUserEmail moves the user from one email to another in userEmails.
+func (ms *MapStorage) indexUserEmail(id string, from string, to string) {
+	delete(ms.userEmails, from)
+	ms.userEmails[to] = id
+}
+
+// bookTaken is the duplicate rule of a new or restored book: the ISBN13 when the book has one,
+// the name and author otherwise. The book except is not counted.
+func (ms *MapStorage) bookTaken(book models.BookStruct, except string) bool {
+
+	if book.ISBN13 != "" {
+		other, ok := ms.bookISBNs[book.ISBN13]
+		return ok && other != except
+	}
+
+	return slices.ContainsFunc(ms.bookNames[bookKey{book.Name, book.Author}], func(id string) bool { return id != except })
+
+}
+
+// editTaken is the duplicate rule of an edit, the one of bookTaken: the new ISBN13 may not be used by another book,
+// and without an ISBN13 the new name and author may not be used by another book.
+func (ms *MapStorage) editTaken(from models.BookStruct, to models.BookStruct) bool {
+	return ms.bookTaken(to, from.ID.String())
+}
+
+// indexBook adds a book that is not in the trash to bookNames and bookISBNs, unindexBook takes it out.
+func (ms *MapStorage) indexBook(book models.BookStruct) {
+
+	key := bookKey{book.Name, book.Author}
+
+	ms.bookNames[key] = append(ms.bookNames[key], book.ID.String())
+
+	if book.ISBN13 != "" {
+		ms.bookISBNs[book.ISBN13] = book.ID.String()
+	}
+
+}
+
+func (ms *MapStorage) unindexBook(book models.BookStruct) {
+
+	id := book.ID.String()
+	key := bookKey{book.Name, book.Author}
+
+	if ids := slices.DeleteFunc(ms.bookNames[key], func(other string) bool { return other == id }); len(ids) > 0 {
+		ms.bookNames[key] = ids
+	} else {
+		delete(ms.bookNames, key)
+	}
+
+	if ms.bookISBNs[book.ISBN13] == id {
+		delete(ms.bookISBNs, book.ISBN13)
+	}
+
+}
+
+// activeBook returns the book unless it does not exist or is in the trash.
+func (ms *MapStorage) activeBook(id string) (models.BookStruct, bool) {
+
+	book, ok := ms.bookStorage[id]
+
+	return book, ok && book.DeletedAt == nil
+
+}
+
+func (ms *MapStorage) bookReferenced(id uuid.UUID) bool {
+
+	for _, loan := range ms.loanStorage {
+
+		if loan.BookID == id {
+			return true
+		}
+
+	}
+
+	for _, hold := range ms.holdStorage {
+
+		if hold.BookID == id {
+			return true
+		}
+
+	}
+
+	return false
+
+}
+
+// inDateRange mirrors the from/to filter of DBStorage, the "to" day is included.
+func inDateRange(date time.Time, params models.ListParamsStruct) bool {
+
+	if !params.DateFrom.IsZero() && date.Before(params.DateFrom) {
+		return false
+	}
+
+	if !params.DateTo.IsZero() && !date.Before(params.DateTo.AddDate(0, 0, 1)) {
+		return false
+	}
+
+	return true
+
+}
+
+// lessByKey compares by the sort key and then by ID, like the ORDER BY of DBStorage.
+func lessByKey(a string, b string, idA string, idB string, order string) bool {
+
+	if a == b {
+		return idA < idB
+	}
+
+	if order == "desc" {
+		return a > b
+	}
+
+	return a < b
+
+}
+
+func pageBounds(total int, params models.ListParamsStruct) (int, int) {
+
+	start := min(params.Offset, total)
+	end := min(start+params.Limit, total)
+
+	return start, end
+
+}
+
+// PoolStats is all zeros, the map storage has no connections.
+func (ms *MapStorage) PoolStats() models.PoolStatsStruct {
+	return models.PoolStatsStruct{}
 }
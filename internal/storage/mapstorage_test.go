@@ -0,0 +1,351 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"library/internal/domain/models"
+	"library/internal/storage/storageerror"
+	"sync"
+	"testing"
+)
+
+// The tests below are meant for go test -race, most of them only check that concurrent calls
+// keep MapStorage consistent and leave the detection of unsynchronized access to the race detector.
+
+const workers = 16
+
+func TestMapStorageConcurrentUsers(t *testing.T) {
+
+	ms := NewMapStorage()
+	ctx := context.Background()
+
+	var wg sync.WaitGroup
+
+	for i := 0; i < workers; i++ {
+
+		wg.Add(1)
+
+		go func(i int) {
+
+			defer wg.Done()
+
+			email := fmt.Sprintf("user%d@example.com", i)
+
+			id, err := ms.SaveUser(ctx, models.UserStruct{Name: "User", Email: email, Password: "password1", Age: 20})
+
+			if err != nil {
+				t.Errorf("SaveUser: %v", err)
+				return
+			}
+
+			if _, err = ms.ValidateUser(ctx, models.UserLoginStruct{Email: email, Password: "password1"}); err != nil {
+				t.Errorf("ValidateUser: %v", err)
+			}
+
+			name := "Renamed"
+
+			if err = ms.PatchUser(ctx, id, models.UserPatchStruct{Name: &name}); err != nil {
+				t.Errorf("PatchUser: %v", err)
+			}
+
+			if _, _, err = ms.GetUsers(ctx, models.UserFilterStruct{ListParamsStruct: models.ListParamsStruct{Limit: 10}}); err != nil {
+				t.Errorf("GetUsers: %v", err)
+			}
+
+			if err = ms.ExportUsers(ctx, func(models.UserStruct) error { return nil }); err != nil {
+				t.Errorf("ExportUsers: %v", err)
+			}
+
+		}(i)
+
+	}
+
+	wg.Wait()
+
+	if len(ms.userStorage) != workers || len(ms.userEmails) != workers {
+		t.Fatalf("got %d users and %d emails, want %d", len(ms.userStorage), len(ms.userEmails), workers)
+	}
+
+}
+
+func TestMapStorageConcurrentSameEmail(t *testing.T) {
+
+	ms := NewMapStorage()
+
+	var wg sync.WaitGroup
+	var mu sync.Mutex
+	var saved, duplicates int
+
+	for i := 0; i < workers; i++ {
+
+		wg.Add(1)
+
+		go func() {
+
+			defer wg.Done()
+
+			_, err := ms.SaveUser(context.Background(), models.UserStruct{Email: "same@example.com", Password: "password1"})
+
+			mu.Lock()
+			defer mu.Unlock()
+
+			switch {
+			case err == nil:
+				saved++
+			case errors.Is(err, storageerror.ErrUserAlreadyExist):
+				duplicates++
+			default:
+				t.Errorf("SaveUser: %v", err)
+			}
+
+		}()
+
+	}
+
+	wg.Wait()
+
+	if saved != 1 || duplicates != workers-1 {
+		t.Fatalf("got %d saved and %d duplicates, want 1 and %d", saved, duplicates, workers-1)
+	}
+
+}
+
+func TestMapStorageConcurrentBooks(t *testing.T) {
+
+	ms := NewMapStorage()
+	ctx := context.Background()
+
+	var wg sync.WaitGroup
+
+	for i := 0; i < workers; i++ {
+
+		wg.Add(1)
+
+		go func(i int) {
+
+			defer wg.Done()
+
+			book := models.BookStruct{Name: fmt.Sprintf("Book %d", i), Author: "Author"}
+
+			id, err := ms.SaveBook(ctx, book)
+
+			if err != nil {
+				t.Errorf("SaveBook: %v", err)
+				return
+			}
+
+			book.Name += " edited"
+
+			if err = ms.EditBook(ctx, id, book); err != nil {
+				t.Errorf("EditBook: %v", err)
+			}
+
+			if err = ms.AddBookTag(id, "tag"); err != nil {
+				t.Errorf("AddBookTag: %v", err)
+			}
+
+			if err = ms.DeleteBook(ctx, id); err != nil {
+				t.Errorf("DeleteBook: %v", err)
+			}
+
+			if err = ms.RestoreBook(ctx, id); err != nil {
+				t.Errorf("RestoreBook: %v", err)
+			}
+
+			if _, _, err = ms.GetBooks(ctx, models.BookFilterStruct{ListParamsStruct: models.ListParamsStruct{Limit: 10}}); err != nil {
+				t.Errorf("GetBooks: %v", err)
+			}
+
+			if _, _, err = ms.SearchBooks(ctx, models.BookSearchStruct{Query: "book", Limit: 10}); err != nil {
+				t.Errorf("SearchBooks: %v", err)
+			}
+
+			if _, err = ms.GetTags(); err != nil {
+				t.Errorf("GetTags: %v", err)
+			}
+
+		}(i)
+
+	}
+
+	wg.Wait()
+
+	if len(ms.bookStorage) != workers || len(ms.bookNames) != workers {
+		t.Fatalf("got %d books and %d names, want %d", len(ms.bookStorage), len(ms.bookNames), workers)
+	}
+
+}
+
+func TestMapStorageConcurrentSameBook(t *testing.T) {
+
+	ms := NewMapStorage()
+
+	var wg sync.WaitGroup
+	var mu sync.Mutex
+	var saved int
+
+	for i := 0; i < workers; i++ {
+
+		wg.Add(1)
+
+		go func() {
+
+			defer wg.Done()
+
+			_, err := ms.SaveBook(context.Background(), models.BookStruct{Name: "Same", Author: "Author"})
+
+			if err != nil && !errors.Is(err, storageerror.ErrBookAlreadyExist) {
+				t.Errorf("SaveBook: %v", err)
+			}
+
+			if err == nil {
+				mu.Lock()
+				saved++
+				mu.Unlock()
+			}
+
+		}()
+
+	}
+
+	wg.Wait()
+
+	if saved != 1 {
+		t.Fatalf("got %d saved books, want 1", saved)
+	}
+
+}
+
+func TestMapStorageEmailIndex(t *testing.T) {
+
+	ms := NewMapStorage()
+	ctx := context.Background()
+
+	id, err := ms.SaveUser(ctx, models.UserStruct{Email: "old@example.com", Password: "password1"})
+
+	if err != nil {
+		t.Fatalf("SaveUser: %v", err)
+	}
+
+	email := "new@example.com"
+
+	if err = ms.PatchUser(ctx, id, models.UserPatchStruct{Email: &email}); err != nil {
+		t.Fatalf("PatchUser: %v", err)
+	}
+
+	if _, err = ms.ValidateUser(ctx, models.UserLoginStruct{Email: "old@example.com", Password: "password1"}); !errors.Is(err, storageerror.ErrUserNotFound) {
+		t.Fatalf("login with the old email: got %v, want %v", err, storageerror.ErrUserNotFound)
+	}
+
+	if _, err = ms.ValidateUser(ctx, models.UserLoginStruct{Email: email, Password: "password1"}); err != nil {
+		t.Fatalf("login with the new email: %v", err)
+	}
+
+	if _, err = ms.SaveUser(ctx, models.UserStruct{Email: "old@example.com", Password: "password1"}); err != nil {
+		t.Fatalf("the old email is free again, SaveUser: %v", err)
+	}
+
+	if err = ms.EditUser(ctx, id, models.UserStruct{Email: "old@example.com", Password: "password1"}); !errors.Is(err, storageerror.ErrUserAlreadyExist) {
+		t.Fatalf("EditUser to a taken email: got %v, want %v", err, storageerror.ErrUserAlreadyExist)
+	}
+
+}
+
+func TestMapStorageBookIndex(t *testing.T) {
+
+	ms := NewMapStorage()
+	ctx := context.Background()
+
+	book := models.BookStruct{Name: "Name", Author: "Author", ISBN13: "9780306406157"}
+
+	id, err := ms.SaveBook(ctx, book)
+
+	if err != nil {
+		t.Fatalf("SaveBook: %v", err)
+	}
+
+	if _, err = ms.GetBookByISBN(ctx, book.ISBN13); err != nil {
+		t.Fatalf("GetBookByISBN: %v", err)
+	}
+
+	if err = ms.DeleteBook(ctx, id); err != nil {
+		t.Fatalf("DeleteBook: %v", err)
+	}
+
+	if _, err = ms.GetBookByISBN(ctx, book.ISBN13); !errors.Is(err, storageerror.ErrBookNotFound) {
+		t.Fatalf("GetBookByISBN of a book in the trash: got %v, want %v", err, storageerror.ErrBookNotFound)
+	}
+
+	other, err := ms.SaveBook(ctx, book)
+
+	if err != nil {
+		t.Fatalf("SaveBook while the first copy is in the trash: %v", err)
+	}
+
+	if err = ms.RestoreBook(ctx, id); !errors.Is(err, storageerror.ErrBookAlreadyExist) {
+		t.Fatalf("RestoreBook of a duplicate: got %v, want %v", err, storageerror.ErrBookAlreadyExist)
+	}
+
+	book.Name, book.ISBN13 = "Renamed", ""
+
+	if err = ms.EditBook(ctx, other, book); err != nil {
+		t.Fatalf("EditBook: %v", err)
+	}
+
+	if err = ms.RestoreBook(ctx, id); err != nil {
+		t.Fatalf("RestoreBook after the rename: %v", err)
+	}
+
+	if _, err = ms.SaveBook(ctx, models.BookStruct{Name: "Renamed", Author: "Author"}); !errors.Is(err, storageerror.ErrBookAlreadyExist) {
+		t.Fatalf("SaveBook of a renamed book: got %v, want %v", err, storageerror.ErrBookAlreadyExist)
+	}
+
+}
+
+func TestMapStorageEditEditions(t *testing.T) {
+
+	ms := NewMapStorage()
+	ctx := context.Background()
+
+	if _, err := ms.SaveBook(ctx, models.BookStruct{Name: "Name", Author: "Author", ISBN13: "9780306406157"}); err != nil {
+		t.Fatalf("SaveBook: %v", err)
+	}
+
+	edition := models.BookStruct{Name: "Other", Author: "Author", ISBN13: "9781861972712"}
+
+	id, err := ms.SaveBook(ctx, edition)
+
+	if err != nil {
+		t.Fatalf("SaveBook: %v", err)
+	}
+
+	edition.Name = "Name"
+
+	if err = ms.EditBook(ctx, id, edition); err != nil {
+		t.Fatalf("EditBook of an edition to the title of another: %v", err)
+	}
+
+	name := "Renamed"
+
+	if err = ms.PatchBook(ctx, id, models.BookPatchStruct{Name: &name}); err != nil {
+		t.Fatalf("PatchBook: %v", err)
+	}
+
+	edition.ISBN13 = "9780306406157"
+
+	if err = ms.EditBook(ctx, id, edition); !errors.Is(err, storageerror.ErrBookAlreadyExist) {
+		t.Fatalf("EditBook to a taken ISBN: got %v, want %v", err, storageerror.ErrBookAlreadyExist)
+	}
+
+	if _, err = ms.SaveBook(ctx, models.BookStruct{Name: "Plain", Author: "Author"}); err != nil {
+		t.Fatalf("SaveBook: %v", err)
+	}
+
+	edition.Name, edition.ISBN13 = "Plain", ""
+
+	if err = ms.EditBook(ctx, id, edition); !errors.Is(err, storageerror.ErrBookAlreadyExist) {
+		t.Fatalf("EditBook without an ISBN to a taken name: got %v, want %v", err, storageerror.ErrBookAlreadyExist)
+	}
+
+}
@@ -0,0 +1,101 @@
+package storage
+
+import (
+	"library/internal/domain/models"
+	"library/internal/storage/storageerror"
+	"slices"
+	"sort"
+)
+
+func (ms *MapStorage) GetTags() ([]models.TagStruct, error) {
+
+	ms.mu.RLock()
+	defer ms.mu.RUnlock()
+
+	counts := make(map[string]int)
+
+	for bookID, tags := range ms.bookTagStorage {
+
+		if _, ok := ms.activeBook(bookID); !ok {
+			continue
+		}
+
+		for _, tag := range tags {
+			counts[tag]++
+		}
+
+	}
+
+	tags := []models.TagStruct{}
+
+	for name, books := range counts {
+		tags = append(tags, models.TagStruct{Name: name, Books: books})
+	}
+
+	sort.Slice(tags, func(i, j int) bool { return tags[i].Name < tags[j].Name })
+
+	return tags, nil
+
+}
+
+func (ms *MapStorage) GetBookTags(bookID string) ([]string, error) {
+
+	ms.mu.RLock()
+	defer ms.mu.RUnlock()
+
+	tags := append([]string{}, ms.bookTagStorage[bookID]...)
+
+	sort.Strings(tags)
+
+	return tags, nil
+
+}
+
+func (ms *MapStorage) AddBookTag(bookID string, tag string) error {
+
+	ms.mu.Lock()
+	defer ms.mu.Unlock()
+
+	if _, ok := ms.activeBook(bookID); !ok {
+		return storageerror.ErrBookNotFound
+	}
+
+	if !slices.Contains(ms.bookTagStorage[bookID], tag) {
+		ms.bookTagStorage[bookID] = append(ms.bookTagStorage[bookID], tag)
+	}
+
+	return nil
+
+}
+
+func (ms *MapStorage) RemoveBookTag(bookID string, tag string) error {
+
+	ms.mu.Lock()
+	defer ms.mu.Unlock()
+
+	tags := ms.bookTagStorage[bookID]
+
+	if !slices.Contains(tags, tag) {
+		return storageerror.ErrTagNotFound
+	}
+
+	ms.bookTagStorage[bookID] = slices.DeleteFunc(tags, func(t string) bool { return t == tag })
+
+	return nil
+
+}
+
+// hasAllTags is the tag filter of GetBooks.
+func (ms *MapStorage) hasAllTags(bookID string, tags []string) bool {
+
+	for _, tag := range tags {
+
+		if !slices.Contains(ms.bookTagStorage[bookID], tag) {
+			return false
+		}
+
+	}
+
+	return true
+
+}
@@ -0,0 +1,64 @@
+package storage
+
+import (
+	"context"
+	"library/internal/domain/models"
+	"library/internal/storage/storageerror"
+	"sort"
+)
+
+func (ms *MapStorage) GetDeletedBooks(_ context.Context, params models.ListParamsStruct) ([]models.BookStruct, int, error) {
+
+	ms.mu.RLock()
+	defer ms.mu.RUnlock()
+
+	books := []models.BookStruct{}
+
+	for _, bk := range ms.bookStorage {
+
+		if bk.DeletedAt != nil && inDateRange(*bk.DeletedAt, params) {
+			books = append(books, bk)
+		}
+
+	}
+
+	sort.Slice(books, func(i, j int) bool {
+
+		if !books[i].DeletedAt.Equal(*books[j].DeletedAt) {
+			return books[i].DeletedAt.Before(*books[j].DeletedAt) != (params.Order == "desc")
+		}
+
+		return lessByKey("", "", books[i].ID.String(), books[j].ID.String(), params.Order)
+
+	})
+
+	start, end := pageBounds(len(books), params)
+
+	return books[start:end], len(books), nil
+
+}
+
+func (ms *MapStorage) RestoreBook(_ context.Context, id string) error {
+
+	ms.mu.Lock()
+	defer ms.mu.Unlock()
+
+	book, ok := ms.bookStorage[id]
+
+	if !ok || book.DeletedAt == nil {
+		return storageerror.ErrBookNotFound
+	}
+
+	if ms.bookTaken(book, id) {
+		return storageerror.ErrBookAlreadyExist
+	}
+
+	book.DeletedAt = nil
+	book.Version++
+
+	ms.bookStorage[id] = book
+	ms.indexBook(book)
+
+	return nil
+
+}
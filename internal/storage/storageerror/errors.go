@@ -6,9 +6,49 @@ var (
 	ErrBookAlreadyExist = errors.New("book already exists")
 	ErrBookStorageEmpty = errors.New("book storage is empty")
 	ErrBookNotFound     = errors.New("book not found")
+	ErrBookNoCopies     = errors.New("book has no copies")
+	ErrRevisionNotFound = errors.New("book revision not found")
 
 	ErrUserAlreadyExist    = errors.New("user already exists")
 	ErrUserStorageEmpty    = errors.New("user storage is empty")
 	ErrUserInvalidPassword = errors.New("user invalid password")
 	ErrUserNotFound        = errors.New("user not found")
+	ErrUserInactive        = errors.New("user is deactivated")
+	ErrUserActive          = errors.New("user is active")
+	ErrUserAnonymized      = errors.New("user is anonymized")
+
+	ErrAuthorNotFound = errors.New("author not found")
+	ErrAuthorHasBooks = errors.New("author has books")
+
+	ErrGenreAlreadyExist = errors.New("genre already exists")
+	ErrGenreNotFound     = errors.New("genre not found")
+	ErrGenreHasChildren  = errors.New("genre has subgenres")
+	ErrGenreCycle        = errors.New("genre can not be its own ancestor")
+	ErrTagNotFound       = errors.New("tag not found")
+
+	ErrLoanNotFound        = errors.New("loan not found")
+	ErrLoanAlreadyReturned = errors.New("loan already returned")
+	ErrBookAlreadyLoaned   = errors.New("book already on loan")
+	ErrLoanRenewalLimit    = errors.New("loan renewal limit reached")
+	ErrLoanOverdue         = errors.New("loan is overdue")
+	ErrLoanHoldPending     = errors.New("book is held by another user")
+
+	ErrCopyAlreadyExist  = errors.New("copy with this barcode already exists")
+	ErrCopyNotFound      = errors.New("copy not found")
+	ErrCopyAlreadyLoaned = errors.New("copy already on loan")
+	ErrCopyReserved      = errors.New("copy reserved for another user")
+
+	ErrHoldAlreadyExist = errors.New("user already holds this book")
+	ErrHoldNotFound     = errors.New("hold not found")
+	ErrHoldNotActive    = errors.New("hold is not active")
+	ErrBookAvailable    = errors.New("book has available copies")
+
+	ErrFinePaymentExceeds = errors.New("payment exceeds fine balance")
+
+	ErrSessionNotFound = errors.New("session not found")
+	ErrSessionRevoked  = errors.New("session revoked")
+	ErrSessionExpired  = errors.New("session expired")
+
+	ErrVersionConflict = errors.New("record was changed since it was read")
+	ErrInvalidID       = errors.New("invalid ID")
 )
@@ -0,0 +1,3 @@
+DROP TABLE IF EXISTS BookTags;
+DROP TABLE IF EXISTS BookGenres;
+DROP TABLE IF EXISTS Genres;
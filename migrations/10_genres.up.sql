@@ -0,0 +1,23 @@
+CREATE TABLE IF NOT EXISTS Genres(
+    ID varchar(36) not null primary key,
+    Name text not null,
+    ParentID varchar(36) references Genres(ID) on delete restrict
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS genres_name_idx ON Genres(coalesce(ParentID, ''), lower(Name));
+
+CREATE TABLE IF NOT EXISTS BookGenres(
+    BookID varchar(36) not null references Books(ID) on delete cascade,
+    GenreID varchar(36) not null references Genres(ID) on delete cascade,
+    primary key (BookID, GenreID)
+);
+
+CREATE INDEX IF NOT EXISTS book_genres_genre_idx ON BookGenres(GenreID);
+
+CREATE TABLE IF NOT EXISTS BookTags(
+    BookID varchar(36) not null references Books(ID) on delete cascade,
+    Tag text not null,
+    primary key (BookID, Tag)
+);
+
+CREATE INDEX IF NOT EXISTS book_tags_tag_idx ON BookTags(Tag);
@@ -0,0 +1,3 @@
+DROP INDEX IF EXISTS books_isbn13_idx;
+ALTER TABLE Books DROP COLUMN IF EXISTS ISBN13;
+ALTER TABLE Books DROP COLUMN IF EXISTS ISBN10;
@@ -0,0 +1,5 @@
+ALTER TABLE Books ADD COLUMN IF NOT EXISTS ISBN10 text;
+ALTER TABLE Books ADD COLUMN IF NOT EXISTS ISBN13 text;
+
+-- ISBN-13 is filled for every book with an ISBN, so it alone identifies the edition.
+CREATE UNIQUE INDEX IF NOT EXISTS books_isbn13_idx ON Books(ISBN13) WHERE ISBN13 IS NOT NULL;
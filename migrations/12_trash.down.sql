@@ -0,0 +1,8 @@
+DROP INDEX IF EXISTS books_deleted_idx;
+
+DROP INDEX IF EXISTS books_isbn13_idx;
+CREATE UNIQUE INDEX IF NOT EXISTS books_isbn13_idx ON Books(ISBN13) WHERE ISBN13 IS NOT NULL;
+
+ALTER TABLE Books ADD COLUMN IF NOT EXISTS Deleted boolean not null default false;
+UPDATE Books SET Deleted = true WHERE DeletedAt IS NOT NULL;
+ALTER TABLE Books DROP COLUMN IF EXISTS DeletedAt;
@@ -0,0 +1,9 @@
+ALTER TABLE Books ADD COLUMN IF NOT EXISTS DeletedAt timestamp;
+UPDATE Books SET DeletedAt = now() WHERE Deleted;
+ALTER TABLE Books DROP COLUMN IF EXISTS Deleted;
+
+-- A book in the trash no longer holds its ISBN, a restore checks for a newer edition instead.
+DROP INDEX IF EXISTS books_isbn13_idx;
+CREATE UNIQUE INDEX IF NOT EXISTS books_isbn13_idx ON Books(ISBN13) WHERE ISBN13 IS NOT NULL AND DeletedAt IS NULL;
+
+CREATE INDEX IF NOT EXISTS books_deleted_idx ON Books(DeletedAt) WHERE DeletedAt IS NOT NULL;
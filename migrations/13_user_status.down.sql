@@ -0,0 +1,2 @@
+ALTER TABLE Users DROP COLUMN IF EXISTS AnonymizedAt;
+ALTER TABLE Users DROP COLUMN IF EXISTS DeletedAt;
@@ -0,0 +1,2 @@
+ALTER TABLE Users ADD COLUMN IF NOT EXISTS DeletedAt timestamp;
+ALTER TABLE Users ADD COLUMN IF NOT EXISTS AnonymizedAt timestamp;
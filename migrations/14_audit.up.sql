@@ -0,0 +1,15 @@
+-- Actor and EntityID are not foreign keys: the log outlives purged books and anonymized users.
+CREATE TABLE IF NOT EXISTS AuditLog(
+    ID varchar(36) not null primary key,
+    Actor varchar(36),
+    Action text not null,
+    Entity text not null,
+    EntityID varchar(36),
+    Before jsonb,
+    After jsonb,
+    DateCreated timestamp not null default now()
+);
+
+CREATE INDEX IF NOT EXISTS audit_entity_idx ON AuditLog(Entity, EntityID);
+CREATE INDEX IF NOT EXISTS audit_actor_idx ON AuditLog(Actor);
+CREATE INDEX IF NOT EXISTS audit_date_idx ON AuditLog(DateCreated);
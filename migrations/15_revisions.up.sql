@@ -0,0 +1,17 @@
+-- Every saved state of a book, revision 1 is the book as it was created.
+CREATE TABLE IF NOT EXISTS BookRevisions(
+    BookID varchar(36) not null references Books(ID) on delete cascade,
+    Rev integer not null,
+    Name text not null,
+    Description text,
+    Author text not null,
+    ISBN10 text,
+    ISBN13 text,
+    DateWriting timestamp,
+    DateCreated timestamp not null default now(),
+    primary key (BookID, Rev)
+);
+
+INSERT INTO BookRevisions (BookID, Rev, Name, Description, Author, ISBN10, ISBN13, DateWriting)
+SELECT ID, 1, Name, Description, Author, ISBN10, ISBN13, DateWriting FROM Books
+ON CONFLICT DO NOTHING;
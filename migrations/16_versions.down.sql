@@ -0,0 +1,2 @@
+ALTER TABLE Users DROP COLUMN IF EXISTS Version;
+ALTER TABLE Books DROP COLUMN IF EXISTS Version;
@@ -0,0 +1,2 @@
+ALTER TABLE Books ADD COLUMN IF NOT EXISTS Version integer NOT NULL DEFAULT 1;
+ALTER TABLE Users ADD COLUMN IF NOT EXISTS Version integer NOT NULL DEFAULT 1;
@@ -0,0 +1 @@
+-- The redacted data is gone for good, there is nothing to restore.
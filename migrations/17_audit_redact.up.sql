@@ -0,0 +1,3 @@
+-- User snapshots in the log no longer keep the personal data, drop it from the entries written before.
+UPDATE AuditLog SET Before = Before - 'name' - 'email' - 'age' - 'pwd' WHERE Entity = 'user' AND Before IS NOT NULL;
+UPDATE AuditLog SET After = After - 'name' - 'email' - 'age' - 'pwd' WHERE Entity = 'user' AND After IS NOT NULL;
@@ -0,0 +1,2 @@
+DROP INDEX IF EXISTS loans_active_book_idx;
+DROP TABLE IF EXISTS Loans;
\ No newline at end of file
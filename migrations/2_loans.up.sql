@@ -0,0 +1,9 @@
+CREATE TABLE IF NOT EXISTS Loans(
+    ID varchar(36) not null primary key,
+    UserID varchar(36) not null references Users(ID),
+    BookID varchar(36) not null references Books(ID),
+    DateCheckout timestamp not null default now(),
+    DateReturn timestamp
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS loans_active_book_idx ON Loans(BookID) WHERE DateReturn IS NULL;
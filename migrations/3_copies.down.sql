@@ -0,0 +1,4 @@
+DROP INDEX IF EXISTS loans_active_copy_idx;
+CREATE UNIQUE INDEX IF NOT EXISTS loans_active_book_idx ON Loans(BookID) WHERE DateReturn IS NULL;
+ALTER TABLE Loans DROP COLUMN IF EXISTS CopyID;
+DROP TABLE IF EXISTS Copies;
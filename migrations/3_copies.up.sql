@@ -0,0 +1,21 @@
+CREATE TABLE IF NOT EXISTS Copies(
+    ID varchar(36) not null primary key,
+    BookID varchar(36) not null references Books(ID) on delete cascade,
+    Barcode text not null,
+    Condition text not null default '',
+    Location text not null default '',
+    DateAcquisition timestamp not null default now(),
+    unique (Barcode)
+);
+
+-- Every book that already exists is treated as a single copy, so loans made before
+-- the inventory model keep pointing at something.
+INSERT INTO Copies (ID, BookID, Barcode)
+SELECT gen_random_uuid()::text, ID, ID FROM Books;
+
+ALTER TABLE Loans ADD COLUMN IF NOT EXISTS CopyID varchar(36) references Copies(ID) on delete set null;
+
+UPDATE Loans SET CopyID = (SELECT c.ID FROM Copies c WHERE c.BookID = Loans.BookID LIMIT 1);
+
+DROP INDEX IF EXISTS loans_active_book_idx;
+CREATE UNIQUE INDEX IF NOT EXISTS loans_active_copy_idx ON Loans(CopyID) WHERE DateReturn IS NULL;
@@ -0,0 +1,4 @@
+DROP INDEX IF EXISTS holds_queue_idx;
+DROP INDEX IF EXISTS holds_ready_copy_idx;
+DROP INDEX IF EXISTS holds_active_user_book_idx;
+DROP TABLE IF EXISTS Holds;
@@ -0,0 +1,13 @@
+CREATE TABLE IF NOT EXISTS Holds(
+    ID varchar(36) not null primary key,
+    UserID varchar(36) not null references Users(ID),
+    BookID varchar(36) not null references Books(ID),
+    CopyID varchar(36) references Copies(ID) on delete set null,
+    Status text not null default 'waiting',
+    DatePlaced timestamp not null default now(),
+    DateExpire timestamp
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS holds_active_user_book_idx ON Holds(UserID, BookID) WHERE Status IN ('waiting', 'ready');
+CREATE UNIQUE INDEX IF NOT EXISTS holds_ready_copy_idx ON Holds(CopyID) WHERE Status = 'ready';
+CREATE INDEX IF NOT EXISTS holds_queue_idx ON Holds(BookID, DatePlaced) WHERE Status = 'waiting';
@@ -0,0 +1,4 @@
+DROP INDEX IF EXISTS fines_user_idx;
+DROP TABLE IF EXISTS Fines;
+ALTER TABLE Loans DROP COLUMN IF EXISTS Renewals;
+ALTER TABLE Loans DROP COLUMN IF EXISTS DateDue;
@@ -0,0 +1,17 @@
+ALTER TABLE Loans ADD COLUMN IF NOT EXISTS DateDue timestamp;
+UPDATE Loans SET DateDue = DateCheckout + interval '14 days' WHERE DateDue IS NULL;
+ALTER TABLE Loans ALTER COLUMN DateDue SET NOT NULL;
+
+ALTER TABLE Loans ADD COLUMN IF NOT EXISTS Renewals integer not null default 0;
+
+CREATE TABLE IF NOT EXISTS Fines(
+    ID varchar(36) not null primary key,
+    UserID varchar(36) not null references Users(ID),
+    LoanID varchar(36) references Loans(ID),
+    Type text not null,
+    Amount bigint not null check (Amount > 0),
+    Note text not null default '',
+    DateCreated timestamp not null default now()
+);
+
+CREATE INDEX IF NOT EXISTS fines_user_idx ON Fines(UserID);
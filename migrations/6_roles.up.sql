@@ -0,0 +1 @@
+ALTER TABLE Users ADD COLUMN IF NOT EXISTS Role text not null default 'patron';
@@ -0,0 +1,2 @@
+DROP INDEX IF EXISTS sessions_user_idx;
+DROP TABLE IF EXISTS Sessions;
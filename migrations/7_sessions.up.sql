@@ -0,0 +1,11 @@
+CREATE TABLE IF NOT EXISTS Sessions(
+    ID varchar(36) not null primary key,
+    UserID varchar(36) not null references Users(ID) on delete cascade,
+    TokenHash text not null,
+    DateCreated timestamp not null default now(),
+    DateExpire timestamp not null,
+    Revoked boolean not null default false,
+    unique (TokenHash)
+);
+
+CREATE INDEX IF NOT EXISTS sessions_user_idx ON Sessions(UserID);
@@ -0,0 +1,2 @@
+DROP INDEX IF EXISTS books_search_idx;
+ALTER TABLE Books DROP COLUMN IF EXISTS Search;
@@ -0,0 +1,6 @@
+ALTER TABLE Books ADD COLUMN IF NOT EXISTS Search tsvector GENERATED ALWAYS AS (
+    setweight(to_tsvector('simple', coalesce(Name, '')), 'A') ||
+    setweight(to_tsvector('simple', coalesce(Author, '')), 'B') ||
+    setweight(to_tsvector('simple', coalesce(Description, '')), 'C')
+) STORED;
+CREATE INDEX IF NOT EXISTS books_search_idx ON Books USING GIN (Search);
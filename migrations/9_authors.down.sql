@@ -0,0 +1,2 @@
+DROP TABLE IF EXISTS BookAuthors;
+DROP TABLE IF EXISTS Authors;
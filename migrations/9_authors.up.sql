@@ -0,0 +1,26 @@
+CREATE TABLE IF NOT EXISTS Authors(
+    ID varchar(36) not null primary key,
+    Name text not null,
+    DateBirth timestamp,
+    DateDeath timestamp,
+    Bio text not null default ''
+);
+
+CREATE INDEX IF NOT EXISTS authors_name_idx ON Authors(lower(Name));
+
+CREATE TABLE IF NOT EXISTS BookAuthors(
+    BookID varchar(36) not null references Books(ID) on delete cascade,
+    AuthorID varchar(36) not null references Authors(ID) on delete restrict,
+    Position integer not null default 0,
+    primary key (BookID, AuthorID)
+);
+
+CREATE INDEX IF NOT EXISTS book_authors_author_idx ON BookAuthors(AuthorID);
+
+-- Every distinct Books.Author becomes one author. Books.Author stays as the display line
+-- of the book, so search and the name+author uniqueness keep working.
+INSERT INTO Authors (ID, Name)
+SELECT gen_random_uuid()::text, a.Author FROM (SELECT DISTINCT Author FROM Books) a;
+
+INSERT INTO BookAuthors (BookID, AuthorID)
+SELECT b.ID, a.ID FROM Books b JOIN Authors a ON a.Name = b.Author;